@@ -2,6 +2,7 @@ package config
 
 import (
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"strconv"
@@ -299,6 +300,16 @@ func (c *ServiceConfig) buildStorageBackend(config StorageBackendConfig) (simple
 			URLPrefix:          getString(config.Config, "url_prefix", ""),
 			SignatureSecretKey: getString(config.Config, "signature_secret_key", ""),
 			PresignExpires:     time.Duration(presignExpires) * time.Second,
+			Compression:        getString(config.Config, "compression", ""),
+			KeyPrefix:          getString(config.Config, "key_prefix", ""),
+			ShardDepth:         getInt(config.Config, "shard_depth", 0),
+		}
+		if key := getString(config.Config, "encryption_key", ""); key != "" {
+			encryptionKey, err := hex.DecodeString(key)
+			if err != nil {
+				return nil, fmt.Errorf("invalid encryption_key: expected 64 hex characters: %w", err)
+			}
+			fsConfig.EncryptionKey = encryptionKey
 		}
 		return fsstorage.New(fsConfig)
 
@@ -0,0 +1,80 @@
+package simplecontent
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// OpenFunc creates a BlobStore from a parsed DSN. Implementations should
+// reject query options they do not recognise.
+type OpenFunc func(dsn *url.URL) (BlobStore, error)
+
+var (
+	schemesMu sync.RWMutex
+	schemes   = map[string]OpenFunc{}
+)
+
+// RegisterScheme makes a backend available to Open under a DSN scheme.
+// Storage backend packages register their schemes when imported, so import
+// the ones a program may open:
+//
+//	import _ "github.com/tendant/simple-content/pkg/simplecontent/storage/fs"
+//
+// Registering an existing scheme replaces it.
+func RegisterScheme(scheme string, open OpenFunc) {
+	schemesMu.Lock()
+	defer schemesMu.Unlock()
+	schemes[strings.ToLower(scheme)] = open
+}
+
+// Open creates a BlobStore from a connection string such as
+//
+//	fs:///var/data?url_prefix=/files&sign_key=secret&presign_expires=1h
+//	mem://
+//	s3://bucket?region=eu-west-1&endpoint=http://localhost:9000
+//
+// The scheme selects the backend and the query carries its options, so a
+// store's whole configuration fits in one environment variable. Unknown
+// schemes and malformed or unknown options return descriptive errors; the
+// DSN itself is never included, as it may hold secrets.
+func Open(dsn string) (BlobStore, error) {
+	if dsn == "" {
+		return nil, errors.New("empty storage DSN")
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.New("malformed storage DSN")
+	}
+	if u.Scheme == "" {
+		return nil, errors.New("storage DSN has no scheme")
+	}
+
+	schemesMu.RLock()
+	open, ok := schemes[u.Scheme]
+	schemesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown storage scheme %q (registered: %s)", u.Scheme, strings.Join(registeredSchemes(), ", "))
+	}
+
+	store, err := open(u)
+	if err != nil {
+		return nil, fmt.Errorf("open %s storage: %w", u.Scheme, err)
+	}
+	return store, nil
+}
+
+// registeredSchemes returns the registered schemes in order
+func registeredSchemes() []string {
+	schemesMu.RLock()
+	defer schemesMu.RUnlock()
+	names := make([]string, 0, len(schemes))
+	for name := range schemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,61 @@
+package simplecontent_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	fsstorage "github.com/tendant/simple-content/pkg/simplecontent/storage/fs"
+	_ "github.com/tendant/simple-content/pkg/simplecontent/storage/memory"
+	_ "github.com/tendant/simple-content/pkg/simplecontent/storage/s3"
+)
+
+func TestOpen(t *testing.T) {
+	store, err := simplecontent.Open("mem://")
+	if err != nil {
+		t.Fatalf("open mem: %v", err)
+	}
+	if err := store.Upload(context.Background(), "a", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	dir := t.TempDir()
+	store, err = simplecontent.Open("fs://" + dir + "?url_prefix=/files&sign_key=secret&presign_expires=1h")
+	if err != nil {
+		t.Fatalf("open fs: %v", err)
+	}
+	if _, ok := store.(*fsstorage.Backend); !ok {
+		t.Fatalf("expected fs backend, got %T", store)
+	}
+	url, err := store.GetDownloadURL(context.Background(), "docs/a.txt", "")
+	if err != nil {
+		t.Fatalf("download url: %v", err)
+	}
+	if !strings.HasPrefix(url, "/files/download/docs/a.txt?") || !strings.Contains(url, "signature=") {
+		t.Fatalf("expected signed URL under the prefix, got %s", url)
+	}
+}
+
+func TestOpen_Errors(t *testing.T) {
+	cases := map[string]string{
+		"":                                     "empty",
+		"/var/data":                            "no scheme",
+		"ftp://host/data":                      `unknown storage scheme "ftp"`,
+		"mem://?size=1":                        `unknown option "size"`,
+		"fs://":                                "base directory is required",
+		"fs:///tmp/x?presign_expires=soon":     "invalid presign_expires",
+		"fs:///tmp/x?sign_key=hunter2&bogus=1": `unknown option "bogus"`,
+		"s3://":                                "bucket is required",
+		"s3://bucket?use_ssl=maybe":            "invalid use_ssl",
+	}
+	for dsn, want := range cases {
+		_, err := simplecontent.Open(dsn)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("%q: expected error containing %q, got %v", dsn, want, err)
+		}
+		if err != nil && strings.Contains(err.Error(), "hunter2") {
+			t.Errorf("%q: error leaks the DSN: %v", dsn, err)
+		}
+	}
+}
@@ -14,6 +14,57 @@ var (
 	
 	// ErrObjectNotFound indicates an object was not found
 	ErrObjectNotFound = errors.New("object not found")
+
+	// ErrInvalidKey indicates an object key that cannot be mapped safely onto storage
+	ErrInvalidKey = errors.New("invalid object key")
+
+	// ErrRangeNotSatisfiable indicates a byte range outside the bounds of an object
+	ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+	// ErrSizeMismatch indicates uploaded content did not match its declared size
+	ErrSizeMismatch = errors.New("content size mismatch")
+
+	// ErrChecksumMismatch indicates content did not match its recorded or expected checksum
+	ErrChecksumMismatch = errors.New("checksum mismatch")
+
+	// ErrLeaseHeld indicates another holder has a live lease on an object
+	ErrLeaseHeld = errors.New("lease held")
+
+	// ErrObjectBusy indicates an object could not be replaced because another process holds it open
+	ErrObjectBusy = errors.New("object busy")
+
+	// ErrDirectoryFull indicates a storage directory has reached its configured entry limit
+	ErrDirectoryFull = errors.New("directory full")
+
+	// ErrObjectTooLarge indicates uploaded content exceeded the size limit for its content type
+	ErrObjectTooLarge = errors.New("object too large")
+
+	// ErrSourceRead indicates the reader supplying an upload failed before all content was read
+	ErrSourceRead = errors.New("failed to read upload source")
+
+	// ErrPreconditionFailed indicates an object no longer matched the ETag a conditional operation required
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrAliasLoop indicates an object alias refers back to itself or chains too many aliases
+	ErrAliasLoop = errors.New("alias loop")
+
+	// ErrObjectExists indicates an object or a live reservation already exists at a key
+	ErrObjectExists = errors.New("object already exists")
+
+	// ErrNotSeekable indicates a seek a forward-only reader cannot perform, such as a backward seek
+	ErrNotSeekable = errors.New("reader not seekable")
+
+	// ErrDecryptionFailed indicates stored content could not be decrypted or failed authentication
+	ErrDecryptionFailed = errors.New("decryption failed")
+
+	// ErrReadOnly indicates a write to a store configured as read-only
+	ErrReadOnly = errors.New("store is read-only")
+
+	// ErrUploadNotFound indicates a multipart upload ID that is unknown, completed or aborted
+	ErrUploadNotFound = errors.New("multipart upload not found")
+
+	// ErrInvalidPart indicates a multipart part number out of range, or parts missing at completion
+	ErrInvalidPart = errors.New("invalid multipart part")
 	
 	// ErrStorageBackendNotFound indicates a storage backend was not found
 	ErrStorageBackendNotFound = errors.New("storage backend not found")
@@ -90,4 +141,27 @@ func (e *StorageError) Error() string {
 
 func (e *StorageError) Unwrap() error {
 	return e.Err
-}
\ No newline at end of file
+}
+
+// ObjectTooLargeError reports an upload rejected by a size limit. It matches
+// ErrObjectTooLarge with errors.Is.
+type ObjectTooLargeError struct {
+	Limit       int64  // Configured limit, in bytes
+	ContentType string // Content type the limit applied to, empty for MaxObjectSize alone
+	Size        int64  // Declared size of the upload, or 0 when it was cut off while streaming
+}
+
+func (e *ObjectTooLargeError) Error() string {
+	limit := fmt.Sprintf("the %d byte limit", e.Limit)
+	if e.ContentType != "" {
+		limit += " for " + e.ContentType
+	}
+	if e.Size > 0 {
+		return fmt.Sprintf("%v: %d bytes exceeds %s", ErrObjectTooLarge, e.Size, limit)
+	}
+	return fmt.Sprintf("%v: upload exceeds %s", ErrObjectTooLarge, limit)
+}
+
+func (e *ObjectTooLargeError) Unwrap() error {
+	return ErrObjectTooLarge
+}
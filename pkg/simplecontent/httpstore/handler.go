@@ -0,0 +1,391 @@
+// Package httpstore serves a simplecontent.BlobStore over HTTP using the URL
+// layout emitted by the filesystem backend's URL methods:
+//
+//	PUT /upload/{key}    - streams the request body into the store
+//	GET /download/{key}  - serves the object as an attachment (?filename=)
+//	GET /preview/{key}   - serves the object inline
+//
+// Stores implementing Previewer serve a generated preview on the preview
+// route instead of the original object. A type query parameter on the
+// preview route forces the Content-Type of the response; when signing is
+// enabled the store must implement PreviewTypeValidator to accept it.
+// Likewise content_type and disposition=inline parameters on the download
+// route override its Content-Type and disposition, requiring the store to
+// implement presigned.DownloadOptionsValidator when signing is enabled. Stores
+// implementing SizeLimiter have uploads whose Content-Length exceeds their
+// limit rejected before the body is read.
+//
+// Downloads and previews support Range, If-None-Match and If-Modified-Since
+// when the store returns a seekable reader; other readers still serve single
+// byte ranges through DownloadRange. When the store implements
+// presigned.SignatureValidator and has signing enabled, every request must
+// carry a valid signature and expiration.
+//
+// Mount the handler at the URLPrefix configured on the backend, stripping
+// any path component of the prefix:
+//
+//	http.Handle("/files/", http.StripPrefix("/files", httpstore.NewHandler(store)))
+package httpstore
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/presigned"
+)
+
+// Option is a functional option for configuring the handler
+type Option func(*handler)
+
+// WithReadOnly disables the upload route
+func WithReadOnly() Option {
+	return func(h *handler) {
+		h.readOnly = true
+	}
+}
+
+// Previewer is implemented by stores that can substitute a generated preview
+// for an object, returning the key of the object to serve
+type Previewer interface {
+	GeneratePreview(ctx context.Context, objectKey string) (string, error)
+}
+
+// SizeLimiter is implemented by stores limiting upload sizes, returning the
+// largest upload accepted for a content type, or 0 when unlimited
+type SizeLimiter interface {
+	MaxObjectSize(contentType string) int64
+}
+
+// PreviewTypeValidator is implemented by stores that sign preview URLs
+// forcing a content type, validating the signature including the type
+type PreviewTypeValidator interface {
+	ValidatePreviewTypeSignature(objectKey, contentType, signature string, expiresAt int64) error
+}
+
+type handler struct {
+	store    simplecontent.BlobStore
+	readOnly bool
+}
+
+// NewHandler returns an http.Handler serving upload, download and preview
+// routes for the given store
+func NewHandler(store simplecontent.BlobStore, opts ...Option) http.Handler {
+	h := &handler{store: store}
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	r := chi.NewRouter()
+	if !h.readOnly {
+		r.Put("/upload/*", h.handleUpload)
+	}
+	r.Get("/download/*", h.handleDownload)
+	r.Head("/download/*", h.handleDownload)
+	r.Get("/preview/*", h.handlePreview)
+	r.Head("/preview/*", h.handlePreview)
+	return r
+}
+
+func (h *handler) handleUpload(w http.ResponseWriter, r *http.Request) {
+	objectKey := chi.URLParam(r, "*")
+	if objectKey == "" {
+		writeError(w, http.StatusBadRequest, "missing_object_key", "object key is required in URL path")
+		return
+	}
+
+	if !h.checkSignature(w, r, func(v presigned.SignatureValidator, signature string, expiresAt int64) error {
+		return v.ValidateUploadSignature(objectKey, signature, expiresAt)
+	}) {
+		return
+	}
+
+	params := simplecontent.UploadParams{
+		ObjectKey: objectKey,
+		MimeType:  r.Header.Get("Content-Type"),
+	}
+	if r.ContentLength > 0 {
+		params.Size = r.ContentLength
+	}
+	if l, ok := h.store.(SizeLimiter); ok {
+		if limit := l.MaxObjectSize(params.MimeType); limit > 0 && params.Size > limit {
+			err := &simplecontent.ObjectTooLargeError{Limit: limit, ContentType: params.MimeType, Size: params.Size}
+			writeError(w, http.StatusRequestEntityTooLarge, "object_too_large", err.Error())
+			return
+		}
+	}
+
+	if err := h.store.UploadWithParams(r.Context(), r.Body, params); err != nil {
+		log.Printf("httpstore: upload failed for objectKey %s: %v", objectKey, err)
+		switch {
+		case errors.Is(err, simplecontent.ErrInvalidKey):
+			writeError(w, http.StatusBadRequest, "invalid_object_key", err.Error())
+		case errors.Is(err, simplecontent.ErrSizeMismatch):
+			writeError(w, http.StatusBadRequest, "size_mismatch", err.Error())
+		case errors.Is(err, simplecontent.ErrSourceRead):
+			writeError(w, http.StatusBadRequest, "upload_incomplete", err.Error())
+		case errors.Is(err, simplecontent.ErrObjectTooLarge):
+			writeError(w, http.StatusRequestEntityTooLarge, "object_too_large", err.Error())
+		default:
+			writeError(w, http.StatusInternalServerError, "upload_failed", "failed to upload file")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *handler) handleDownload(w http.ResponseWriter, r *http.Request) {
+	objectKey := chi.URLParam(r, "*")
+	filename := r.URL.Query().Get("filename")
+	contentType := r.URL.Query().Get("content_type")
+	if contentType != "" {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_content_type", "content_type parameter must be a valid content type")
+			return
+		}
+	}
+	inline := r.URL.Query().Get("disposition") == "inline"
+
+	if !h.checkSignature(w, r, func(v presigned.SignatureValidator, signature string, expiresAt int64) error {
+		if contentType == "" && !inline {
+			return v.ValidateDownloadSignature(objectKey, signature, expiresAt, filename)
+		}
+		ov, ok := h.store.(presigned.DownloadOptionsValidator)
+		if !ok {
+			return errors.New("store does not support signed download options")
+		}
+		return ov.ValidateDownloadOptionsSignature(objectKey, filename, contentType, inline, signature, expiresAt)
+	}) {
+		return
+	}
+
+	disposition := ""
+	switch {
+	case filename != "" && inline:
+		disposition = mime.FormatMediaType("inline", map[string]string{"filename": filename})
+	case filename != "":
+		disposition = mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+	case inline:
+		disposition = "inline"
+	}
+	h.serveObject(w, r, objectKey, disposition, contentType)
+}
+
+func (h *handler) handlePreview(w http.ResponseWriter, r *http.Request) {
+	objectKey := chi.URLParam(r, "*")
+	contentType := r.URL.Query().Get("type")
+	if contentType != "" {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_type", "type parameter must be a valid content type")
+			return
+		}
+	}
+
+	if !h.checkSignature(w, r, func(v presigned.SignatureValidator, signature string, expiresAt int64) error {
+		if contentType == "" {
+			return v.ValidatePreviewSignature(objectKey, signature, expiresAt)
+		}
+		tv, ok := h.store.(PreviewTypeValidator)
+		if !ok {
+			return errors.New("store does not support signed preview types")
+		}
+		return tv.ValidatePreviewTypeSignature(objectKey, contentType, signature, expiresAt)
+	}) {
+		return
+	}
+
+	if p, ok := h.store.(Previewer); ok && objectKey != "" {
+		previewKey, err := p.GeneratePreview(r.Context(), objectKey)
+		switch {
+		case errors.Is(err, simplecontent.ErrInvalidKey):
+			writeError(w, http.StatusBadRequest, "invalid_object_key", err.Error())
+			return
+		case errors.Is(err, simplecontent.ErrObjectNotFound):
+			writeError(w, http.StatusNotFound, "not_found", "object not found")
+			return
+		case err != nil:
+			log.Printf("httpstore: preview error for objectKey %s: %v", objectKey, err)
+			writeError(w, http.StatusInternalServerError, "preview_failed", "failed to generate preview")
+			return
+		}
+		objectKey = previewKey
+	}
+
+	h.serveObject(w, r, objectKey, "inline", contentType)
+}
+
+// serveObject writes an object with its content headers, delegating to
+// http.ServeContent for conditional and range requests when possible. A
+// non-empty contentType replaces the object's own.
+func (h *handler) serveObject(w http.ResponseWriter, r *http.Request, objectKey, disposition, contentType string) {
+	if objectKey == "" {
+		writeError(w, http.StatusBadRequest, "missing_object_key", "object key is required in URL path")
+		return
+	}
+
+	meta, err := h.store.GetObjectMeta(r.Context(), objectKey)
+	if err != nil {
+		if errors.Is(err, simplecontent.ErrInvalidKey) {
+			writeError(w, http.StatusBadRequest, "invalid_object_key", err.Error())
+			return
+		}
+		writeError(w, http.StatusNotFound, "not_found", "object not found")
+		return
+	}
+
+	rc, err := h.store.Download(r.Context(), objectKey)
+	if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "object not found")
+		return
+	}
+	defer rc.Close()
+
+	if contentType == "" {
+		contentType = meta.ContentType
+	}
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if meta.ETag != "" {
+		w.Header().Set("ETag", strconv.Quote(meta.ETag))
+	}
+	if meta.CacheControl != "" {
+		w.Header().Set("Cache-Control", meta.CacheControl)
+	}
+	if disposition != "" {
+		w.Header().Set("Content-Disposition", disposition)
+	}
+
+	if rs, ok := rc.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, "", meta.UpdatedAt, rs)
+		return
+	}
+
+	w.Header().Set("Accept-Ranges", "bytes")
+	if offset, length, ok := parseRange(r.Header.Get("Range"), meta.Size); ok && r.Header.Get("If-Range") == "" {
+		h.serveRange(w, r, objectKey, offset, length, meta.Size)
+		return
+	}
+
+	w.Header().Set("Content-Length", strconv.FormatInt(meta.Size, 10))
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, rc); err != nil {
+		log.Printf("httpstore: copy error for objectKey %s: %v", objectKey, err)
+	}
+}
+
+// serveRange answers a single-range request for a non-seekable object with
+// DownloadRange
+func (h *handler) serveRange(w http.ResponseWriter, r *http.Request, objectKey string, offset, length, size int64) {
+	rng, err := h.store.DownloadRange(r.Context(), objectKey, offset, length)
+	if errors.Is(err, simplecontent.ErrRangeNotSatisfiable) {
+		w.Header().Set("Content-Range", fmt.Sprintf("bytes */%d", size))
+		writeError(w, http.StatusRequestedRangeNotSatisfiable, "range_not_satisfiable", "requested range is outside the object")
+		return
+	} else if err != nil {
+		writeError(w, http.StatusNotFound, "not_found", "object not found")
+		return
+	}
+	defer rng.Close()
+
+	w.Header().Set("Content-Range", rng.ContentRange())
+	w.Header().Set("Content-Length", strconv.FormatInt(rng.Length, 10))
+	w.WriteHeader(http.StatusPartialContent)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if _, err := io.Copy(w, rng); err != nil {
+		log.Printf("httpstore: copy error for objectKey %s: %v", objectKey, err)
+	}
+}
+
+// parseRange parses a Range header naming a single byte range, as
+// "bytes=first-last", "bytes=first-" or the suffix form "bytes=-n", into an
+// offset and a length (negative for the rest of the object). Other headers,
+// including multiple ranges, report false and are served in full.
+func parseRange(header string, size int64) (offset, length int64, ok bool) {
+	spec, found := strings.CutPrefix(header, "bytes=")
+	if !found || strings.Contains(spec, ",") {
+		return 0, 0, false
+	}
+	first, last, found := strings.Cut(strings.TrimSpace(spec), "-")
+	if !found {
+		return 0, 0, false
+	}
+	if first == "" {
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		return max(size-n, 0), -1, true
+	}
+	offset, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || offset < 0 {
+		return 0, 0, false
+	}
+	if last == "" {
+		return offset, -1, true
+	}
+	end, err := strconv.ParseInt(last, 10, 64)
+	if err != nil || end < offset {
+		return 0, 0, false
+	}
+	return offset, end - offset + 1, true
+}
+
+// checkSignature validates the signature and expires query parameters when
+// the store has signed URLs enabled. It writes an error response and returns
+// false when the request must be rejected.
+func (h *handler) checkSignature(w http.ResponseWriter, r *http.Request, validate func(presigned.SignatureValidator, string, int64) error) bool {
+	validator, ok := h.store.(presigned.SignatureValidator)
+	if !ok || !validator.IsSignedURLEnabled() {
+		return true
+	}
+
+	signature := r.URL.Query().Get("signature")
+	expiresStr := r.URL.Query().Get("expires")
+	if signature == "" {
+		writeError(w, http.StatusUnauthorized, "missing_signature", "signature parameter is required")
+		return false
+	}
+	if expiresStr == "" {
+		writeError(w, http.StatusUnauthorized, "missing_expires", "expires parameter is required")
+		return false
+	}
+
+	expiresAt, err := strconv.ParseInt(expiresStr, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_expires", "expires parameter must be a valid timestamp")
+		return false
+	}
+
+	if err := validate(validator, signature, expiresAt); err != nil {
+		writeError(w, http.StatusForbidden, "invalid_signature", err.Error())
+		return false
+	}
+	return true
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"error": map[string]string{
+			"code":    code,
+			"message": message,
+		},
+	})
+}
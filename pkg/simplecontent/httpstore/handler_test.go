@@ -0,0 +1,276 @@
+package httpstore_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/httpstore"
+	fsstorage "github.com/tendant/simple-content/pkg/simplecontent/storage/fs"
+)
+
+func requestURI(t *testing.T, rawURL string) string {
+	t.Helper()
+	u, err := url.Parse(rawURL)
+	require.NoError(t, err)
+	return u.RequestURI()
+}
+
+func TestHandler_SignedRoundTrip(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{
+		BaseDir:            t.TempDir(),
+		URLPrefix:          "http://files.example.com",
+		SignatureSecretKey: "test-secret-key-for-httpstore-tests",
+	})
+	require.NoError(t, err)
+	h := httpstore.NewHandler(store)
+	ctx := context.Background()
+
+	uploadURL, err := store.GetUploadURL(ctx, "docs/report.txt")
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodPut, requestURI(t, uploadURL), strings.NewReader("hello handler"))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	downloadURL, err := store.GetDownloadURL(ctx, "docs/report.txt", "report.txt")
+	require.NoError(t, err)
+
+	t.Run("Download", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, requestURI(t, downloadURL), nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "hello handler", rec.Body.String())
+		assert.Contains(t, rec.Header().Get("Content-Disposition"), `attachment; filename=report.txt`)
+		assert.NotEmpty(t, rec.Header().Get("ETag"))
+	})
+
+	t.Run("Range", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, requestURI(t, downloadURL), nil)
+		req.Header.Set("Range", "bytes=6-12")
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusPartialContent, rec.Code)
+		assert.Equal(t, "handler", rec.Body.String())
+	})
+
+	t.Run("Preview", func(t *testing.T) {
+		previewURL, err := store.GetPreviewURL(ctx, "docs/report.txt")
+		require.NoError(t, err)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, requestURI(t, previewURL), nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "inline", rec.Header().Get("Content-Disposition"))
+	})
+
+	t.Run("MissingSignature", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download/docs/report.txt", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("TamperedKey", func(t *testing.T) {
+		tampered := strings.Replace(requestURI(t, downloadURL), "report.txt?", "other.txt?", 1)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tampered, nil))
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+}
+
+func TestHandler_UnsignedNotFoundAndReadOnly(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{BaseDir: t.TempDir(), URLPrefix: "/files"})
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	httpstore.NewHandler(store).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/download/missing", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = httptest.NewRecorder()
+	httpstore.NewHandler(store, httpstore.WithReadOnly()).ServeHTTP(rec,
+		httptest.NewRequest(http.MethodPut, "/upload/x", strings.NewReader("x")))
+	assert.NotEqual(t, http.StatusOK, rec.Code)
+}
+
+func TestHandler_GeneratedPreview(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{
+		BaseDir: t.TempDir(),
+		Previews: map[string]fsstorage.PreviewFunc{
+			"text/*": func(ctx context.Context, key string, src io.Reader, w io.Writer) error {
+				_, err := io.Copy(w, io.LimitReader(src, 5))
+				return err
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.NoError(t, store.Upload(context.Background(), "notes.txt", strings.NewReader("hello preview")))
+	h := httpstore.NewHandler(store)
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/preview/notes.txt", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "hello", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/preview/missing.txt", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestHandler_PreviewContentType(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{
+		BaseDir:            t.TempDir(),
+		URLPrefix:          "http://files.example.com",
+		SignatureSecretKey: "test-secret-key-for-httpstore-tests",
+		PreviewContentType: "image/webp",
+		Previews: map[string]fsstorage.PreviewFunc{
+			"text/*": func(ctx context.Context, key string, src io.Reader, w io.Writer) error {
+				_, err := io.Copy(w, io.LimitReader(src, 5))
+				return err
+			},
+		},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Upload(ctx, "notes.txt", strings.NewReader("hello preview")))
+	h := httpstore.NewHandler(store)
+
+	// Generated previews carry the configured type
+	previewURL, err := store.GetPreviewURL(ctx, "notes.txt")
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, requestURI(t, previewURL), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/webp", rec.Header().Get("Content-Type"))
+
+	// A per-URL type overrides it and is covered by the signature
+	previewURL, err = store.(*fsstorage.Backend).GetPreviewURLWithType(ctx, "notes.txt", "image/svg+xml")
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, requestURI(t, previewURL), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+
+	tampered := strings.Replace(requestURI(t, previewURL), "svg%2Bxml", "png", 1)
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tampered, nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+}
+
+func TestHandler_DownloadOptions(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{
+		BaseDir:            t.TempDir(),
+		URLPrefix:          "http://files.example.com",
+		SignatureSecretKey: "test-secret-key-for-httpstore-tests",
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.UploadWithParams(ctx, strings.NewReader("<svg/>"), simplecontent.UploadParams{
+		ObjectKey: "logo.bin",
+		MimeType:  "application/octet-stream",
+	}))
+	h := httpstore.NewHandler(store)
+
+	downloadURL, err := store.(*fsstorage.Backend).GetDownloadURLWithOptions(ctx, "logo.bin", fsstorage.DownloadOptions{
+		Filename:    "logo.svg",
+		ContentType: "image/svg+xml",
+		Inline:      true,
+	})
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, requestURI(t, downloadURL), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "image/svg+xml", rec.Header().Get("Content-Type"))
+	assert.Equal(t, `inline; filename=logo.svg`, rec.Header().Get("Content-Disposition"))
+	assert.Equal(t, "<svg/>", rec.Body.String())
+
+	// The options are covered by the signature
+	for _, tampered := range []string{
+		strings.Replace(requestURI(t, downloadURL), "svg%2Bxml", "html", 1),
+		strings.Replace(requestURI(t, downloadURL), "&disposition=inline", "", 1),
+	} {
+		rec = httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tampered, nil))
+		assert.Equal(t, http.StatusForbidden, rec.Code, tampered)
+	}
+
+	_, err = store.(*fsstorage.Backend).GetDownloadURLWithOptions(ctx, "logo.bin", fsstorage.DownloadOptions{ContentType: "not a type"})
+	assert.Error(t, err)
+}
+
+func TestHandler_CacheControlByContentType(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{
+		BaseDir: t.TempDir(),
+		CacheControlByContentType: map[string]string{
+			"image/*":          "public, max-age=31536000, immutable",
+			"application/json": "no-store",
+		},
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.UploadWithParams(ctx, strings.NewReader("\x89PNG\r\n\x1a\n"), simplecontent.UploadParams{ObjectKey: "logo.png", MimeType: "image/png"}))
+	require.NoError(t, store.UploadWithParams(ctx, strings.NewReader(`{"a":1}`), simplecontent.UploadParams{ObjectKey: "data.json", MimeType: "application/json; charset=utf-8"}))
+	require.NoError(t, store.Upload(ctx, "notes.txt", strings.NewReader("plain")))
+	h := httpstore.NewHandler(store)
+
+	for path, want := range map[string]string{
+		"/download/logo.png":  "public, max-age=31536000, immutable",
+		"/download/data.json": "no-store",
+		"/preview/notes.txt":  "",
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, want, rec.Header().Get("Cache-Control"), path)
+	}
+}
+
+func TestHandler_RangeCompressed(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{BaseDir: t.TempDir(), Compression: fsstorage.CodecGzip})
+	require.NoError(t, err)
+	require.NoError(t, store.Upload(context.Background(), "doc.txt", strings.NewReader("0123456789")))
+	h := httpstore.NewHandler(store)
+
+	req := httptest.NewRequest(http.MethodGet, "/download/doc.txt", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "bytes 2-4/10", rec.Header().Get("Content-Range"))
+	assert.Equal(t, "234", rec.Body.String())
+
+	req.Header.Set("Range", "bytes=-3")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Equal(t, "789", rec.Body.String())
+
+	req.Header.Set("Range", "bytes=20-")
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestedRangeNotSatisfiable, rec.Code)
+	assert.Equal(t, "bytes */10", rec.Header().Get("Content-Range"))
+}
+
+func TestHandler_UploadContentLengthOverLimit(t *testing.T) {
+	store, err := fsstorage.New(fsstorage.Config{BaseDir: t.TempDir(), MaxObjectSize: 4})
+	require.NoError(t, err)
+	h := httpstore.NewHandler(store)
+
+	req := httptest.NewRequest(http.MethodPut, "/upload/big.txt", strings.NewReader("0123456789"))
+	req.Header.Set("Content-Type", "text/plain")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Contains(t, rec.Body.String(), "4 byte limit")
+
+	exists, err := store.Exists(context.Background(), "big.txt")
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
@@ -2,6 +2,7 @@ package simplecontent
 
 import (
 	"context"
+	"fmt"
 	"io"
 	"time"
 
@@ -28,11 +29,57 @@ type BlobStore interface {
 	// Download downloads content directly
 	Download(ctx context.Context, objectKey string) (io.ReadCloser, error)
 
+	// DownloadRange downloads at most length bytes starting at offset, or
+	// to the end when length is negative. Offsets outside the object return
+	// ErrRangeNotSatisfiable.
+	DownloadRange(ctx context.Context, objectKey string, offset, length int64) (*ObjectRange, error)
+
 	// Delete deletes content
 	Delete(ctx context.Context, objectKey string) error
 
+	// DeleteMany deletes every key, carrying on past keys that fail. The
+	// map records the error of each key that was not deleted; the error is
+	// only non-nil when the batch as a whole failed.
+	DeleteMany(ctx context.Context, keys []string) (map[string]error, error)
+
+	// Copy duplicates the object at srcKey to dstKey, replacing any object
+	// there. A missing source returns ErrObjectNotFound.
+	Copy(ctx context.Context, srcKey, dstKey string) error
+
+	// Move re-keys the object at srcKey to dstKey, replacing any object
+	// there. A missing source returns ErrObjectNotFound.
+	Move(ctx context.Context, srcKey, dstKey string) error
+
+	// List returns the metadata of every object whose key starts with
+	// prefix, ordered by key. An empty prefix lists all objects.
+	List(ctx context.Context, prefix string) ([]ObjectMeta, error)
+
 	// GetObjectMeta retrieves metadata for an object
 	GetObjectMeta(ctx context.Context, objectKey string) (*ObjectMeta, error)
+
+	// Exists reports whether an object is stored, without reading it
+	Exists(ctx context.Context, objectKey string) (bool, error)
+
+	// Stats aggregates the objects whose key starts with prefix in a single
+	// listing. Stores may serve it from a short-lived cache, so recent
+	// writes can be missing.
+	Stats(ctx context.Context, prefix string) (StorageStats, error)
+
+	// InitiateMultipart starts an upload to objectKey that is assembled
+	// from parts, returning the ID the other multipart methods take
+	InitiateMultipart(ctx context.Context, objectKey string) (uploadID string, err error)
+
+	// UploadPart stores part partNumber, counting from 1, of an upload.
+	// Uploading a part number again replaces it.
+	UploadPart(ctx context.Context, uploadID string, partNumber int, r io.Reader) error
+
+	// CompleteMultipart stores the parts of an upload, concatenated in
+	// order, as its object. Parts must be numbered 1 to N without gaps, or
+	// it fails with ErrInvalidPart and the upload is kept.
+	CompleteMultipart(ctx context.Context, uploadID string) error
+
+	// AbortMultipart discards an upload and its parts
+	AbortMultipart(ctx context.Context, uploadID string) error
 }
 
 // Repository defines the interface for content and object persistence
@@ -121,15 +168,99 @@ type ObjectMeta struct {
 	Key         string
 	Size        int64
 	ContentType string
+	CreatedAt   time.Time
 	UpdatedAt   time.Time
 	ETag        string
 	Metadata    map[string]string
+
+	// CacheControl is the Cache-Control policy to serve the object with, if
+	// the store has one for it
+	CacheControl string
+}
+
+// ObjectRange is a byte range of an object returned by DownloadRange. Reading
+// it yields the Length bytes starting at Offset; the caller must Close it.
+type ObjectRange struct {
+	io.ReadCloser
+	Offset int64 // First byte of the range
+	Length int64 // Number of bytes in the range
+	Size   int64 // Total size of the object
+}
+
+// ContentRange returns the range formatted for a Content-Range header
+func (r *ObjectRange) ContentRange() string {
+	if r.Length == 0 {
+		return fmt.Sprintf("bytes */%d", r.Size)
+	}
+	return fmt.Sprintf("bytes %d-%d/%d", r.Offset, r.Offset+r.Length-1, r.Size)
+}
+
+// ClampRange validates a requested range against an object of size bytes,
+// resolving a negative length to the end of the object and clipping lengths
+// past it, as DownloadRange implementations do
+func ClampRange(offset, length, size int64) (int64, error) {
+	if offset < 0 || offset >= size {
+		return 0, ErrRangeNotSatisfiable
+	}
+	if length < 0 || length > size-offset {
+		length = size - offset
+	}
+	return length, nil
+}
+
+// StorageStats aggregates the objects under a prefix, as returned by Stats
+type StorageStats struct {
+	Objects       int64                       // Number of objects
+	Bytes         int64                       // Total size of the objects
+	ByContentType map[string]ContentTypeStats // Objects and bytes by content type ("" when unknown)
+	Oldest        time.Time                   // Earliest UpdatedAt, if the store reports one
+	Newest        time.Time                   // Latest UpdatedAt, if the store reports one
+}
+
+// ContentTypeStats counts the objects of one content type
+type ContentTypeStats struct {
+	Objects int64
+	Bytes   int64
+}
+
+// Add counts an object in the stats
+func (s *StorageStats) Add(meta ObjectMeta) {
+	s.Objects++
+	s.Bytes += meta.Size
+	if s.ByContentType == nil {
+		s.ByContentType = make(map[string]ContentTypeStats)
+	}
+	byType := s.ByContentType[meta.ContentType]
+	byType.Objects++
+	byType.Bytes += meta.Size
+	s.ByContentType[meta.ContentType] = byType
+	if meta.UpdatedAt.IsZero() {
+		return
+	}
+	if s.Oldest.IsZero() || meta.UpdatedAt.Before(s.Oldest) {
+		s.Oldest = meta.UpdatedAt
+	}
+	if meta.UpdatedAt.After(s.Newest) {
+		s.Newest = meta.UpdatedAt
+	}
 }
 
 // UploadParams contains parameters for uploading an object
 type UploadParams struct {
 	ObjectKey string
 	MimeType  string
+	Size      int64  // Declared content length in bytes (0 = unknown)
+	SHA256    string // Expected hex SHA-256 of the content; a mismatch fails with ErrChecksumMismatch (fs and memory backends)
+
+	// IfNotExists fails the upload with ErrObjectExists, keeping the
+	// existing object, when the key already holds one. Of concurrent
+	// uploads to a free key exactly one succeeds. The S3 backend sends
+	// If-None-Match: *, so the store must support conditional writes.
+	IfNotExists bool
+
+	// Metadata is user metadata stored with the object and returned in
+	// ObjectMeta.Metadata (fs backend)
+	Metadata map[string]string
 }
 
 // CreateDerivedContentParams contains parameters for creating derived content relationships
@@ -0,0 +1,213 @@
+package simplecontent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// AsFS returns a read-only io/fs view of a store, for use with
+// http.FileServerFS, fs.WalkDir, templates and other io/fs consumers. Object
+// keys are paths, with "/" separating directories; a directory exists when
+// an object exists beneath it. The result implements fs.StatFS and
+// fs.ReadDirFS. Files are opened with Download and are seekable when the
+// store's reader is; directories are read with List.
+func AsFS(store BlobStore) fs.FS {
+	return &storeFS{store: store}
+}
+
+type storeFS struct {
+	store BlobStore
+}
+
+var (
+	_ fs.StatFS    = (*storeFS)(nil)
+	_ fs.ReadDirFS = (*storeFS)(nil)
+)
+
+func (s *storeFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	info, err := s.stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	if info.IsDir() {
+		entries, err := s.readDir(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+		}
+		return &storeDir{info: info, entries: entries}, nil
+	}
+
+	rc, err := s.store.Download(context.Background(), name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fsError(err)}
+	}
+	if _, ok := rc.(io.Seeker); ok {
+		return &seekableFile{storeFile{ReadCloser: rc, info: info}}, nil
+	}
+	return &storeFile{ReadCloser: rc, info: info}, nil
+}
+
+func (s *storeFS) Stat(name string) (fs.FileInfo, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrInvalid}
+	}
+	info, err := s.stat(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: err}
+	}
+	return info, nil
+}
+
+func (s *storeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrInvalid}
+	}
+	entries, err := s.readDir(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+	}
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	return entries, nil
+}
+
+// stat describes name as an object or, failing that, as a directory
+func (s *storeFS) stat(name string) (fs.FileInfo, error) {
+	if name == "." {
+		return dirInfo("."), nil
+	}
+
+	meta, err := s.store.GetObjectMeta(context.Background(), name)
+	if err == nil {
+		return objectInfo{meta: meta}, nil
+	} else if !errors.Is(err, ErrObjectNotFound) {
+		return nil, fsError(err)
+	}
+
+	objects, err := s.store.List(context.Background(), name+"/")
+	if err != nil {
+		return nil, fsError(err)
+	}
+	if len(objects) == 0 {
+		return nil, fs.ErrNotExist
+	}
+	return dirInfo(path.Base(name)), nil
+}
+
+// readDir lists the immediate children of directory name, sorted by name
+func (s *storeFS) readDir(name string) ([]fs.DirEntry, error) {
+	prefix := ""
+	if name != "." {
+		prefix = name + "/"
+	}
+	objects, err := s.store.List(context.Background(), prefix)
+	if err != nil {
+		return nil, fsError(err)
+	}
+
+	var entries []fs.DirEntry
+	dirs := make(map[string]bool)
+	for i := range objects {
+		rest := strings.TrimPrefix(objects[i].Key, prefix)
+		if dir, _, ok := strings.Cut(rest, "/"); ok {
+			if !dirs[dir] {
+				dirs[dir] = true
+				entries = append(entries, fs.FileInfoToDirEntry(dirInfo(dir)))
+			}
+			continue
+		}
+		entries = append(entries, fs.FileInfoToDirEntry(objectInfo{meta: &objects[i]}))
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// fsError maps store errors onto the io/fs sentinel errors
+func fsError(err error) error {
+	switch {
+	case errors.Is(err, ErrObjectNotFound):
+		return fs.ErrNotExist
+	case errors.Is(err, ErrInvalidKey):
+		return fs.ErrInvalid
+	}
+	return err
+}
+
+// objectInfo is the fs.FileInfo of an object
+type objectInfo struct {
+	meta *ObjectMeta
+}
+
+func (i objectInfo) Name() string       { return path.Base(i.meta.Key) }
+func (i objectInfo) Size() int64        { return i.meta.Size }
+func (i objectInfo) Mode() fs.FileMode  { return 0444 }
+func (i objectInfo) ModTime() time.Time { return i.meta.UpdatedAt }
+func (i objectInfo) IsDir() bool        { return false }
+func (i objectInfo) Sys() any           { return i.meta }
+
+// dirInfo is the fs.FileInfo of a directory implied by object keys
+type dirInfo string
+
+func (d dirInfo) Name() string       { return string(d) }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0555 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() any           { return nil }
+
+// storeFile is an open object
+type storeFile struct {
+	io.ReadCloser
+	info fs.FileInfo
+}
+
+func (f *storeFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+
+// seekableFile is an open object whose reader can seek, as range requests
+// through http.FileServerFS need
+type seekableFile struct {
+	storeFile
+}
+
+func (f *seekableFile) Seek(offset int64, whence int) (int64, error) {
+	return f.ReadCloser.(io.Seeker).Seek(offset, whence)
+}
+
+// storeDir is an open directory
+type storeDir struct {
+	info    fs.FileInfo
+	entries []fs.DirEntry
+}
+
+func (d *storeDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *storeDir) Close() error               { return nil }
+
+func (d *storeDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.Name(), Err: errors.New("is a directory")}
+}
+
+func (d *storeDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	if n <= 0 {
+		entries := d.entries
+		d.entries = nil
+		return entries, nil
+	}
+	if len(d.entries) == 0 {
+		return nil, io.EOF
+	}
+	n = min(n, len(d.entries))
+	entries := d.entries[:n]
+	d.entries = d.entries[n:]
+	return entries, nil
+}
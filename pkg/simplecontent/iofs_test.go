@@ -0,0 +1,45 @@
+package simplecontent_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/storage/memory"
+)
+
+func TestAsFS_Memory(t *testing.T) {
+	store := memory.New()
+	if err := store.Upload(context.Background(), "docs/a.txt", strings.NewReader("hello")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	fsys := simplecontent.AsFS(store)
+
+	f, err := fsys.Open("docs/a.txt")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil || info.Name() != "a.txt" || info.Size() != 5 || info.IsDir() {
+		t.Fatalf("unexpected stat %v: %v", info, err)
+	}
+	if data, _ := io.ReadAll(f); string(data) != "hello" {
+		t.Fatalf("unexpected content %q", data)
+	}
+
+	if _, err := fs.Stat(fsys, "docs/missing.txt"); err == nil {
+		t.Fatalf("expected error for missing object")
+	}
+	if _, err := fsys.Open("../escape"); !errors.Is(err, fs.ErrInvalid) {
+		t.Fatalf("expected fs.ErrInvalid for invalid path, got %v", err)
+	}
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil || len(entries) != 1 || entries[0].Name() != "docs" || !entries[0].IsDir() {
+		t.Fatalf("unexpected root entries %v: %v", entries, err)
+	}
+}
@@ -0,0 +1,136 @@
+// Package mimetype detects content types from leading bytes using an
+// extensible magic-number table, falling back to http.DetectContentType for
+// anything the table does not recognise.
+//
+// The default table covers formats http.DetectContentType misclassifies or
+// does not know (AVIF, HEIC/HEIF, FLAC, OOXML and OpenDocument files).
+// Applications can add their own signatures with RegisterMagic; registered
+// signatures take precedence over the defaults.
+package mimetype
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+)
+
+// SniffLen is the number of leading bytes Detect considers
+const SniffLen = 512
+
+// magic matches a signature at a fixed offset, optionally refined by a
+// custom match function
+type magic struct {
+	offset      int
+	signature   []byte
+	contentType string
+	match       func(data []byte) (string, bool)
+}
+
+func (m magic) detect(data []byte) (string, bool) {
+	end := m.offset + len(m.signature)
+	if len(data) < end || !bytes.Equal(data[m.offset:end], m.signature) {
+		return "", false
+	}
+	if m.match != nil {
+		return m.match(data)
+	}
+	return m.contentType, true
+}
+
+var (
+	mu         sync.RWMutex
+	registered []magic
+)
+
+// RegisterMagic adds a signature that identifies contentType when data
+// starts with prefix. Later registrations are checked first.
+func RegisterMagic(prefix []byte, contentType string) {
+	sig := make([]byte, len(prefix))
+	copy(sig, prefix)
+
+	mu.Lock()
+	defer mu.Unlock()
+	registered = append([]magic{{signature: sig, contentType: contentType}}, registered...)
+}
+
+// Match returns the content type for data according to the registered and
+// default signatures, reporting false when none match
+func Match(data []byte) (string, bool) {
+	if len(data) > SniffLen {
+		data = data[:SniffLen]
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, m := range registered {
+		if ct, ok := m.detect(data); ok {
+			return ct, true
+		}
+	}
+	for _, m := range defaults {
+		if ct, ok := m.detect(data); ok {
+			return ct, true
+		}
+	}
+	return "", false
+}
+
+// Detect returns the content type of data, consulting the magic-number table
+// before falling back to http.DetectContentType. It always returns a valid
+// MIME type.
+func Detect(data []byte) string {
+	if ct, ok := Match(data); ok {
+		return ct
+	}
+	return http.DetectContentType(data)
+}
+
+var zipHeader = []byte("PK\x03\x04")
+
+// defaults is the built-in signature table
+var defaults = []magic{
+	{offset: 0, signature: []byte("RIFF"), match: func(data []byte) (string, bool) {
+		if len(data) >= 12 && bytes.Equal(data[8:12], []byte("WEBP")) {
+			return "image/webp", true
+		}
+		return "", false
+	}},
+	{offset: 4, signature: []byte("ftypavif"), contentType: "image/avif"},
+	{offset: 4, signature: []byte("ftypavis"), contentType: "image/avif"},
+	{offset: 4, signature: []byte("ftypheic"), contentType: "image/heic"},
+	{offset: 4, signature: []byte("ftypheix"), contentType: "image/heic"},
+	{offset: 4, signature: []byte("ftyphevc"), contentType: "image/heic-sequence"},
+	{offset: 4, signature: []byte("ftypmif1"), contentType: "image/heif"},
+	{offset: 4, signature: []byte("ftypmsf1"), contentType: "image/heif-sequence"},
+	{offset: 0, signature: []byte("fLaC"), contentType: "audio/flac"},
+	{offset: 0, signature: zipHeader, match: matchZipDocument},
+}
+
+// matchZipDocument identifies office documents stored as ZIP containers by
+// the entry names visible in the first local file headers
+func matchZipDocument(data []byte) (string, bool) {
+	// OpenDocument stores its MIME type uncompressed as the first entry
+	if i := bytes.Index(data, []byte("mimetypeapplication/vnd.oasis.opendocument.")); i >= 0 {
+		start := i + len("mimetype")
+		end := start
+		for end < len(data) && isLowerMIMEChar(data[end]) {
+			end++
+		}
+		return string(data[start:end]), true
+	}
+
+	switch {
+	case bytes.Contains(data, []byte("word/")):
+		return "application/vnd.openxmlformats-officedocument.wordprocessingml.document", true
+	case bytes.Contains(data, []byte("xl/")):
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet", true
+	case bytes.Contains(data, []byte("ppt/")):
+		return "application/vnd.openxmlformats-officedocument.presentationml.presentation", true
+	}
+	return "", false
+}
+
+// isLowerMIMEChar reports whether c may appear in a lowercase MIME type
+func isLowerMIMEChar(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= '0' && c <= '9') || c == '.' || c == '-' || c == '+' || c == '/'
+}
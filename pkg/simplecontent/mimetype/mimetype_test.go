@@ -0,0 +1,54 @@
+package mimetype
+
+import (
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"webp", []byte("RIFF\x24\x00\x00\x00WEBPVP8 "), "image/webp"},
+		{"avif", []byte("\x00\x00\x00\x1cftypavif\x00\x00\x00\x00"), "image/avif"},
+		{"heic", []byte("\x00\x00\x00\x18ftypheic\x00\x00\x00\x00"), "image/heic"},
+		{"heif", []byte("\x00\x00\x00\x18ftypmif1\x00\x00\x00\x00"), "image/heif"},
+		{"flac", []byte("fLaC\x00\x00\x00\x22"), "audio/flac"},
+		{"docx", []byte("PK\x03\x04\x14\x00\x06\x00[Content_Types].xmlPK\x03\x04word/document.xml"),
+			"application/vnd.openxmlformats-officedocument.wordprocessingml.document"},
+		{"xlsx", []byte("PK\x03\x04\x14\x00\x06\x00[Content_Types].xmlPK\x03\x04xl/workbook.xml"),
+			"application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"},
+		{"odt", []byte("PK\x03\x04\x14\x00\x00\x00mimetypeapplication/vnd.oasis.opendocument.textPK\x03\x04"),
+			"application/vnd.oasis.opendocument.text"},
+		{"plain zip", []byte("PK\x03\x04\x14\x00\x00\x00notes.txt"), "application/zip"},
+		{"fallback png", []byte("\x89PNG\r\n\x1a\n"), "image/png"},
+		{"fallback text", []byte("hello world"), "text/plain; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.data); got != tt.want {
+				t.Fatalf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegisterMagic(t *testing.T) {
+	data := []byte("SCNT\x01payload")
+	if _, ok := Match(data); ok {
+		t.Fatalf("expected no match before registration")
+	}
+
+	RegisterMagic([]byte("SCNT"), "application/x-simple-content")
+	if got := Detect(data); got != "application/x-simple-content" {
+		t.Fatalf("Detect() = %q after registration", got)
+	}
+
+	// Registered signatures take precedence over the defaults
+	RegisterMagic([]byte("fLaC"), "audio/x-flac")
+	if got := Detect([]byte("fLaC\x00\x00\x00\x22")); got != "audio/x-flac" {
+		t.Fatalf("Detect() = %q, expected registered override", got)
+	}
+}
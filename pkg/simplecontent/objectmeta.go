@@ -0,0 +1,91 @@
+package simplecontent
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// objectMetaJSON is the wire form of ObjectMeta. Field names are part of the
+// API and must not change.
+type objectMetaJSON struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type,omitempty"`
+	CreatedAt    string            `json:"created_at,omitempty"`
+	UpdatedAt    string            `json:"updated_at,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+	CacheControl string            `json:"cache_control,omitempty"`
+}
+
+// MarshalJSON encodes the metadata with snake_case field names and RFC 3339
+// timestamps in UTC. Empty optional fields and zero times are omitted.
+func (m ObjectMeta) MarshalJSON() ([]byte, error) {
+	return json.Marshal(objectMetaJSON{
+		Key:          m.Key,
+		Size:         m.Size,
+		ContentType:  m.ContentType,
+		CreatedAt:    formatMetaTime(m.CreatedAt),
+		UpdatedAt:    formatMetaTime(m.UpdatedAt),
+		ETag:         m.ETag,
+		Metadata:     m.Metadata,
+		CacheControl: m.CacheControl,
+	})
+}
+
+// UnmarshalJSON decodes metadata encoded by MarshalJSON
+func (m *ObjectMeta) UnmarshalJSON(data []byte) error {
+	var v objectMetaJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	createdAt, err := parseMetaTime(v.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("invalid created_at: %w", err)
+	}
+	updatedAt, err := parseMetaTime(v.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("invalid updated_at: %w", err)
+	}
+
+	*m = ObjectMeta{
+		Key:          v.Key,
+		Size:         v.Size,
+		ContentType:  v.ContentType,
+		CreatedAt:    createdAt,
+		UpdatedAt:    updatedAt,
+		ETag:         v.ETag,
+		Metadata:     v.Metadata,
+		CacheControl: v.CacheControl,
+	}
+	return nil
+}
+
+// String describes the object for logging, e.g.
+// "docs/a.txt (12 bytes, text/plain, etag 17f2-c)"
+func (m ObjectMeta) String() string {
+	details := []string{fmt.Sprintf("%d bytes", m.Size)}
+	if m.ContentType != "" {
+		details = append(details, m.ContentType)
+	}
+	if m.ETag != "" {
+		details = append(details, "etag "+m.ETag)
+	}
+	return fmt.Sprintf("%s (%s)", m.Key, strings.Join(details, ", "))
+}
+
+func formatMetaTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func parseMetaTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339Nano, s)
+}
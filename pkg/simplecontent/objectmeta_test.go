@@ -0,0 +1,64 @@
+package simplecontent
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestObjectMetaJSONRoundTrip(t *testing.T) {
+	meta := ObjectMeta{
+		Key:          "docs/report.pdf",
+		Size:         1024,
+		ContentType:  "application/pdf",
+		CreatedAt:    time.Date(2024, 3, 1, 9, 30, 0, 123456789, time.UTC),
+		UpdatedAt:    time.Date(2024, 3, 2, 10, 0, 0, 0, time.FixedZone("CET", 3600)),
+		ETag:         "17f2-400",
+		Metadata:     map[string]string{"owner": "alice"},
+		CacheControl: "public, max-age=60",
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	for _, field := range []string{`"content_type":"application/pdf"`, `"created_at":"2024-03-01T09:30:00.123456789Z"`, `"updated_at":"2024-03-02T09:00:00Z"`, `"cache_control":`} {
+		if !strings.Contains(string(data), field) {
+			t.Fatalf("expected %s in %s", field, data)
+		}
+	}
+
+	var decoded ObjectMeta
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if !decoded.CreatedAt.Equal(meta.CreatedAt) || !decoded.UpdatedAt.Equal(meta.UpdatedAt) {
+		t.Fatalf("timestamps changed: %v, %v", decoded.CreatedAt, decoded.UpdatedAt)
+	}
+	decoded.CreatedAt, decoded.UpdatedAt = meta.CreatedAt, meta.UpdatedAt
+	if !reflect.DeepEqual(decoded, meta) {
+		t.Fatalf("round trip changed metadata:\n got %+v\nwant %+v", decoded, meta)
+	}
+}
+
+func TestObjectMetaJSONOmitsEmpty(t *testing.T) {
+	data, err := json.Marshal(ObjectMeta{Key: "a.txt"})
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(data) != `{"key":"a.txt","size":0}` {
+		t.Fatalf("unexpected encoding %s", data)
+	}
+	if err := json.Unmarshal([]byte(`{"key":"a","created_at":"yesterday"}`), new(ObjectMeta)); err == nil {
+		t.Fatalf("expected an error for an invalid timestamp")
+	}
+}
+
+func TestObjectMetaString(t *testing.T) {
+	meta := ObjectMeta{Key: "docs/a.txt", Size: 12, ContentType: "text/plain", ETag: "17f2-c"}
+	if got := meta.String(); got != "docs/a.txt (12 bytes, text/plain, etag 17f2-c)" {
+		t.Fatalf("unexpected string %q", got)
+	}
+}
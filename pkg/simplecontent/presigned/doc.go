@@ -59,6 +59,26 @@
 //   - Monitor for invalid signature attempts
 //   - Consider rate limiting upload endpoints
 //
+// # Zero-Downtime Key Rotation
+//
+// During a rolling deploy some nodes still sign with the old key while
+// others have the new one. Rotate in two deploys so every node accepts both
+// keys before any node signs with the new one:
+//
+//  1. Deploy with the old key as primary and the new key as a grace key:
+//     presigned.WithSecretKey(oldKey), presigned.WithGraceKey(newKey, deadline)
+//  2. Once every node runs step 1, deploy with the new key as primary and the
+//     old key as a grace key whose deadline covers the longest outstanding
+//     URL expiration:
+//     presigned.WithSecretKey(newKey), presigned.WithGraceKey(oldKey, deadline)
+//  3. After the deadline passes, drop the grace key.
+//
+// WithVerificationKeys accepts previous keys without a deadline instead;
+// the overlap then lasts until a deploy drops them.
+//
+// Signer.AcceptsKey reports whether a given key currently validates, which is
+// useful for checking a node's configuration mid-rotation.
+//
 // # Example: Complete Upload Workflow
 //
 //	// Server: Generate presigned URL
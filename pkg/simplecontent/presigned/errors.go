@@ -21,6 +21,9 @@ var (
 
 	// ErrInvalidSignature is returned when the signature is invalid
 	ErrInvalidSignature = errors.New("presigned: invalid signature")
+
+	// ErrInvalidMethod is returned when the HTTP method is empty or not a valid token
+	ErrInvalidMethod = errors.New("presigned: invalid HTTP method")
 )
 
 // IsAuthError returns true if the error is a signature validation error
@@ -29,5 +32,6 @@ func IsAuthError(err error) bool {
 		errors.Is(err, ErrMissingExpiration) ||
 		errors.Is(err, ErrInvalidExpiration) ||
 		errors.Is(err, ErrExpired) ||
-		errors.Is(err, ErrInvalidSignature)
+		errors.Is(err, ErrInvalidSignature) ||
+		errors.Is(err, ErrInvalidMethod)
 }
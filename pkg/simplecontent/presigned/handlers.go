@@ -1,9 +1,11 @@
 package presigned
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"mime"
 	"net/http"
 	"strconv"
 
@@ -20,6 +22,14 @@ type SignatureValidator interface {
 	ValidatePreviewSignature(objectKey, signature string, expiresAt int64) error
 }
 
+// DownloadOptionsValidator is implemented by backends that sign download URLs
+// forcing a content type or inline disposition, validating the signature
+// including them. Signed download URLs carrying content_type or disposition
+// are only accepted from backends implementing it.
+type DownloadOptionsValidator interface {
+	ValidateDownloadOptionsSignature(objectKey, filename, contentType string, inline bool, signature string, expiresAt int64) error
+}
+
 // Handlers provides HTTP handlers for presigned upload/download URLs
 // These handlers work with storage backends that support HMAC signature validation
 type Handlers struct {
@@ -111,6 +121,8 @@ func (h *Handlers) HandleUpload(w http.ResponseWriter, r *http.Request) {
 // HandleDownload handles GET requests to presigned download URLs
 // This endpoint mimics S3 presigned URL behavior for filesystem storage
 // URL format: GET /download/{objectKey...}?signature={hmac}&expires={timestamp}&filename={name}
+// Optional content_type={type} and disposition=inline parameters override the
+// response's Content-Type and serve it inline rather than as an attachment
 // The objectKey can contain slashes (e.g., "originals/objects/ab/cd1234_file.pdf")
 //
 // Authentication:
@@ -125,6 +137,14 @@ func (h *Handlers) HandleDownload(w http.ResponseWriter, r *http.Request) {
 	}
 
 	filename := r.URL.Query().Get("filename")
+	contentType := r.URL.Query().Get("content_type")
+	if contentType != "" {
+		if _, _, err := mime.ParseMediaType(contentType); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_content_type", "content_type parameter must be a valid content type", nil)
+			return
+		}
+	}
+	inline := r.URL.Query().Get("disposition") == "inline"
 
 	// Get the default storage backend (assumes filesystem)
 	blobStore, ok := h.blobStores[h.defaultBackend]
@@ -155,8 +175,18 @@ func (h *Handlers) HandleDownload(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Validate signature
-		if err := validator.ValidateDownloadSignature(objectKey, signature, expiresAt, filename); err != nil {
+		// Validate signature, including any options
+		validate := func() error {
+			if contentType == "" && !inline {
+				return validator.ValidateDownloadSignature(objectKey, signature, expiresAt, filename)
+			}
+			ov, ok := blobStore.(DownloadOptionsValidator)
+			if !ok {
+				return errors.New("backend does not support signed download options")
+			}
+			return ov.ValidateDownloadOptionsSignature(objectKey, filename, contentType, inline, signature, expiresAt)
+		}
+		if err := validate(); err != nil {
 			log.Printf("Presigned download signature validation failed for objectKey %s: %v", objectKey, err)
 			writeError(w, http.StatusForbidden, "invalid_signature", err.Error(), nil)
 			return
@@ -175,12 +205,20 @@ func (h *Handlers) HandleDownload(w http.ResponseWriter, r *http.Request) {
 	defer rc.Close()
 
 	// Set content headers
-	if meta, err := blobStore.GetObjectMeta(r.Context(), objectKey); err == nil {
+	if contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	} else if meta, err := blobStore.GetObjectMeta(r.Context(), objectKey); err == nil {
 		w.Header().Set("Content-Type", meta.ContentType)
 	}
 
+	disposition := "attachment"
+	if inline {
+		disposition = "inline"
+	}
 	if filename != "" {
-		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+		w.Header().Set("Content-Disposition", fmt.Sprintf("%s; filename=\"%s\"", disposition, filename))
+	} else if inline {
+		w.Header().Set("Content-Disposition", disposition)
 	}
 
 	// Stream file to response
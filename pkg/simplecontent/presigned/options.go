@@ -25,8 +25,15 @@ func WithDefaultExpiration(duration time.Duration) Option {
 // The pattern must contain {key} placeholder
 // Examples: "/upload/{key}", "/api/v1/upload/{key}", "/storage/{key}"
 func WithURLPattern(pattern string) Option {
+	return WithURLPatterns(pattern)
+}
+
+// WithURLPatterns sets several URL patterns for object key extraction, for a
+// signer shared by routes such as "/download/{key}" and "/preview/{key}".
+// Paths are matched against the patterns in order.
+func WithURLPatterns(patterns ...string) Option {
 	return func(s *Signer) {
-		s.urlPattern = pattern
+		s.urlPatterns = patterns
 	}
 }
 
@@ -38,3 +45,36 @@ func WithCustomPayloadFunc(fn func(method, path string, expiresAt int64) string)
 		s.customPayloadFunc = fn
 	}
 }
+
+// WithGraceKey accepts signatures made with a previous secret key until the
+// given deadline, so URLs issued before a key rotation keep working.
+// New URLs are always signed with the key set by WithSecretKey.
+// The deadline should be at least the longest URL expiration in use.
+func WithGraceKey(key string, until time.Time) Option {
+	return func(s *Signer) {
+		s.graceKeys = append(s.graceKeys, graceKey{key: []byte(key), until: until})
+	}
+}
+
+// WithVerificationKeys accepts signatures made with any of keys in addition
+// to the key set by WithSecretKey, with no deadline, for rotations whose
+// overlap window is ended by redeploying without the old keys.
+// New URLs are always signed with the key set by WithSecretKey.
+func WithVerificationKeys(keys ...string) Option {
+	return func(s *Signer) {
+		for _, key := range keys {
+			s.graceKeys = append(s.graceKeys, graceKey{key: []byte(key)})
+		}
+	}
+}
+
+// WithClockSkew sets how far the clocks of the server that signs URLs and
+// the one that validates them may drift apart. Validation accepts URLs for
+// that long past their expiration, so drift does not cause spurious
+// ErrExpired failures near the boundary.
+// Default is 30 seconds if not specified; 0 disables the tolerance
+func WithClockSkew(d time.Duration) Option {
+	return func(s *Signer) {
+		s.clockSkew = max(d, 0)
+	}
+}
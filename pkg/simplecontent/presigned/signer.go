@@ -16,15 +16,29 @@ import (
 type Signer struct {
 	secretKey          []byte
 	defaultExpiration  time.Duration
-	urlPattern         string // e.g., "/upload/{key}" or "/api/v1/upload/{key}"
+	urlPatterns        []string // e.g., "/upload/{key}" or "/api/v1/upload/{key}"
 	customPayloadFunc  func(method, path string, expiresAt int64) string
+	graceKeys          []graceKey // Previous keys still accepted during rotation
+	clockSkew          time.Duration // Tolerance for clock drift between signer and validator
+}
+
+// graceKey is a previous secret key accepted for validation until a deadline
+type graceKey struct {
+	key   []byte
+	until time.Time // Zero for keys accepted until the signer is reconfigured
+}
+
+// active reports whether the key is still accepted at now
+func (gk graceKey) active(now time.Time) bool {
+	return gk.until.IsZero() || !now.After(gk.until)
 }
 
 // New creates a new Signer with the given options
 func New(opts ...Option) *Signer {
 	s := &Signer{
 		defaultExpiration: 1 * time.Hour,
-		urlPattern:        "/upload/{key}",
+		urlPatterns:       []string{"/upload/{key}"},
+		clockSkew:         30 * time.Second,
 	}
 
 	for _, opt := range opts {
@@ -44,6 +58,10 @@ func (s *Signer) SignURL(method, path string, expiresIn time.Duration) (string,
 	if len(s.secretKey) == 0 {
 		return "", ErrNoSecretKey
 	}
+	method, err := canonicalMethod(method)
+	if err != nil {
+		return "", err
+	}
 
 	if expiresIn == 0 {
 		expiresIn = s.defaultExpiration
@@ -69,6 +87,40 @@ func (s *Signer) SignURL(method, path string, expiresIn time.Duration) (string,
 	return signedURL, nil
 }
 
+// SignURLs generates presigned URLs for many paths with a single expiration
+// timestamp, reusing one HMAC instance across all of them. The returned slice
+// is in the same order as paths.
+func (s *Signer) SignURLs(method string, paths []string, expiresIn time.Duration) ([]string, error) {
+	if len(s.secretKey) == 0 {
+		return nil, ErrNoSecretKey
+	}
+	method, err := canonicalMethod(method)
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresIn == 0 {
+		expiresIn = s.defaultExpiration
+	}
+	expiresAt := time.Now().Add(expiresIn).Unix()
+
+	h := hmac.New(sha256.New, s.secretKey)
+	signed := make([]string, len(paths))
+	for i, path := range paths {
+		h.Reset()
+		h.Write([]byte(s.createPayload(method, path, expiresAt)))
+		signature := hex.EncodeToString(h.Sum(nil))
+
+		separator := "?"
+		if strings.Contains(path, "?") {
+			separator = "&"
+		}
+		signed[i] = fmt.Sprintf("%s%ssignature=%s&expires=%d", path, separator, signature, expiresAt)
+	}
+
+	return signed, nil
+}
+
 // SignURLWithBase generates a presigned URL with a base URL prefix
 //
 // Example:
@@ -127,9 +179,18 @@ func (s *Signer) ValidateRequest(r *http.Request) error {
 }
 
 // Validate validates the signature and expiration for a given method, path, signature, and expiration timestamp
+// URLs stay valid for the configured clock skew past their expiration (see WithClockSkew)
+// It checks any route the same way: signatures are bound to both the method
+// and the full path, so a URL signed for GET never validates for PUT, even
+// on the same path
 func (s *Signer) Validate(method, path, signature string, expiresAt int64) error {
-	// Check expiration
-	if time.Now().Unix() > expiresAt {
+	method, err := canonicalMethod(method)
+	if err != nil {
+		return err
+	}
+
+	// Check expiration, tolerating drift between the signing and validating clocks
+	if time.Now().Add(-s.clockSkew).Unix() > expiresAt {
 		return ErrExpired
 	}
 
@@ -140,22 +201,61 @@ func (s *Signer) Validate(method, path, signature string, expiresAt int64) error
 	expectedSignature := s.generateSignature(payload)
 
 	// Compare signatures using constant-time comparison to prevent timing attacks
-	if !hmac.Equal([]byte(signature), []byte(expectedSignature)) {
-		return ErrInvalidSignature
+	if hmac.Equal([]byte(signature), []byte(expectedSignature)) {
+		return nil
+	}
+
+	// Fall back to previous keys still inside their grace window
+	now := time.Now()
+	for _, gk := range s.graceKeys {
+		if !gk.active(now) {
+			continue
+		}
+		if hmac.Equal([]byte(signature), []byte(signWithKey(gk.key, payload))) {
+			return nil
+		}
 	}
 
-	return nil
+	return ErrInvalidSignature
+}
+
+// AcceptsKey reports whether signatures made with key currently validate,
+// either because it is the signing key, a verification key or a previous key
+// inside its grace window. Intended for diagnostics during key rotation.
+func (s *Signer) AcceptsKey(key string) bool {
+	if len(s.secretKey) > 0 && hmac.Equal([]byte(key), s.secretKey) {
+		return true
+	}
+	now := time.Now()
+	for _, gk := range s.graceKeys {
+		if gk.active(now) && hmac.Equal([]byte(key), gk.key) {
+			return true
+		}
+	}
+	return false
 }
 
-// ExtractObjectKey extracts the object key from a URL path based on the configured URL pattern
+// ExtractObjectKey extracts the object key from a URL path based on the configured URL patterns,
+// using the first pattern the path matches
 //
 // Example:
 //   signer := New(WithURLPattern("/upload/{key}"))
 //   key, err := signer.ExtractObjectKey("/upload/myfile.pdf")
 //   // Returns: "myfile.pdf"
 func (s *Signer) ExtractObjectKey(path string) (string, error) {
+	err := fmt.Errorf("no URL pattern configured")
+	for _, pattern := range s.urlPatterns {
+		var key string
+		if key, err = extractKey(pattern, path); err == nil {
+			return key, nil
+		}
+	}
+	return "", err
+}
+
+// extractKey extracts the object key from a path matching a single URL pattern
+func extractKey(pattern, path string) (string, error) {
 	// Parse the URL pattern to find where {key} is located
-	pattern := s.urlPattern
 	placeholder := "{key}"
 
 	idx := strings.Index(pattern, placeholder)
@@ -185,8 +285,24 @@ func (s *Signer) IsEnabled() bool {
 	return len(s.secretKey) > 0
 }
 
+// canonicalMethod upper-cases an HTTP method, rejecting empty methods and
+// ones that are not HTTP tokens. Tokens cannot contain "|", so the payload
+// always splits back into the method, path and expiry that were signed.
+func canonicalMethod(method string) (string, error) {
+	if method == "" {
+		return "", ErrInvalidMethod
+	}
+	for _, c := range []byte(method) {
+		if c <= ' ' || c >= 0x7f || strings.IndexByte(`"(),/:;<=>?@[\]{}|`, c) >= 0 {
+			return "", ErrInvalidMethod
+		}
+	}
+	return strings.ToUpper(method), nil
+}
+
 // createPayload creates the signature payload
-// Default format: METHOD|PATH|EXPIRES
+// Default format: METHOD|PATH|EXPIRES, with the method canonicalised by
+// canonicalMethod and the full path, query included
 // Can be customized using WithCustomPayloadFunc
 func (s *Signer) createPayload(method, path string, expiresAt int64) string {
 	if s.customPayloadFunc != nil {
@@ -197,7 +313,12 @@ func (s *Signer) createPayload(method, path string, expiresAt int64) string {
 
 // generateSignature generates HMAC-SHA256 signature for the given payload
 func (s *Signer) generateSignature(payload string) string {
-	h := hmac.New(sha256.New, s.secretKey)
+	return signWithKey(s.secretKey, payload)
+}
+
+// signWithKey generates an HMAC-SHA256 signature of payload using key
+func signWithKey(key []byte, payload string) string {
+	h := hmac.New(sha256.New, key)
 	h.Write([]byte(payload))
 	return hex.EncodeToString(h.Sum(nil))
 }
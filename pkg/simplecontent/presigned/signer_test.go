@@ -0,0 +1,232 @@
+package presigned
+
+import (
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// parseSigned extracts the signature and expiration from a signed path
+func parseSigned(t *testing.T, signed string) (string, int64) {
+	t.Helper()
+	u, err := url.Parse(signed)
+	if err != nil {
+		t.Fatalf("parse signed url: %v", err)
+	}
+	expiresAt, err := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err != nil {
+		t.Fatalf("parse expires: %v", err)
+	}
+	return u.Query().Get("signature"), expiresAt
+}
+
+func TestSigner_SignAndValidate(t *testing.T) {
+	signer := New(WithSecretKey("primary-secret"))
+
+	signed, err := signer.SignURL("PUT", "/upload/a.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig, exp := parseSigned(t, signed)
+
+	if err := signer.Validate("PUT", "/upload/a.txt", sig, exp); err != nil {
+		t.Fatalf("expected valid signature: %v", err)
+	}
+	if err := signer.Validate("PUT", "/upload/b.txt", sig, exp); err != ErrInvalidSignature {
+		t.Fatalf("expected ErrInvalidSignature for other path, got %v", err)
+	}
+}
+
+func TestSigner_MethodBinding(t *testing.T) {
+	signer := New(WithSecretKey("primary-secret"))
+
+	cases := []struct{ signed, replayed string }{
+		{"GET", "PUT"},
+		{"PUT", "GET"},
+	}
+	for _, tc := range cases {
+		for _, path := range []string{"/download/x", "/upload/x"} {
+			signed, err := signer.SignURL(tc.signed, path, time.Minute)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			sig, exp := parseSigned(t, signed)
+			if err := signer.Validate(tc.signed, path, sig, exp); err != nil {
+				t.Fatalf("expected %s %s to validate: %v", tc.signed, path, err)
+			}
+			if err := signer.Validate(tc.replayed, path, sig, exp); err != ErrInvalidSignature {
+				t.Fatalf("expected %s signature replayed as %s on %s to fail, got %v", tc.signed, tc.replayed, path, err)
+			}
+		}
+
+		// A signature for one route does not carry over to the other
+		signed, err := signer.SignURL(tc.signed, "/download/x", time.Minute)
+		if err != nil {
+			t.Fatalf("sign: %v", err)
+		}
+		sig, exp := parseSigned(t, signed)
+		if err := signer.Validate(tc.replayed, "/upload/x", sig, exp); err != ErrInvalidSignature {
+			t.Fatalf("expected replay on /upload/x to fail, got %v", err)
+		}
+	}
+
+	// Methods are case-insensitive tokens
+	signed, err := signer.SignURL("get", "/download/x", time.Minute)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig, exp := parseSigned(t, signed)
+	if err := signer.Validate("GET", "/download/x", sig, exp); err != nil {
+		t.Fatalf("expected lower-case method to validate as GET: %v", err)
+	}
+	for _, method := range []string{"", "GET|/download/x", "GET PUT"} {
+		if _, err := signer.SignURL(method, "/x", time.Minute); err != ErrInvalidMethod {
+			t.Fatalf("expected ErrInvalidMethod signing %q, got %v", method, err)
+		}
+		if err := signer.Validate(method, "/x", sig, exp); err != ErrInvalidMethod {
+			t.Fatalf("expected ErrInvalidMethod validating %q, got %v", method, err)
+		}
+	}
+}
+
+func TestSigner_GraceKeyRotation(t *testing.T) {
+	oldSigner := New(WithSecretKey("old-secret"))
+	signed, err := oldSigner.SignURL("GET", "/download/a.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig, exp := parseSigned(t, signed)
+
+	// Rotated node: signs with the new key, still accepts the old one
+	rotated := New(
+		WithSecretKey("new-secret"),
+		WithGraceKey("old-secret", time.Now().Add(time.Hour)),
+	)
+	if err := rotated.Validate("GET", "/download/a.txt", sig, exp); err != nil {
+		t.Fatalf("old-key URL should validate during grace window: %v", err)
+	}
+	if !rotated.AcceptsKey("old-secret") || !rotated.AcceptsKey("new-secret") {
+		t.Fatalf("expected both keys accepted during grace window")
+	}
+	if rotated.AcceptsKey("unknown-secret") {
+		t.Fatalf("unexpected key accepted")
+	}
+
+	// New URLs use the primary key, which the old node does not know
+	newSigned, _ := rotated.SignURL("GET", "/download/a.txt", time.Minute)
+	newSig, newExp := parseSigned(t, newSigned)
+	if err := oldSigner.Validate("GET", "/download/a.txt", newSig, newExp); err != ErrInvalidSignature {
+		t.Fatalf("expected new-key URL rejected by old-only node, got %v", err)
+	}
+
+	// After the grace window the old key is rejected
+	expired := New(
+		WithSecretKey("new-secret"),
+		WithGraceKey("old-secret", time.Now().Add(-time.Second)),
+	)
+	if err := expired.Validate("GET", "/download/a.txt", sig, exp); err != ErrInvalidSignature {
+		t.Fatalf("expected old key rejected after grace window, got %v", err)
+	}
+	if expired.AcceptsKey("old-secret") {
+		t.Fatalf("expected old key not accepted after grace window")
+	}
+}
+
+func TestSigner_VerificationKeys(t *testing.T) {
+	oldSigner := New(WithSecretKey("old-secret"))
+	signed, err := oldSigner.SignURL("PUT", "/upload/a.txt", time.Minute)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig, exp := parseSigned(t, signed)
+
+	rotated := New(
+		WithSecretKey("new-secret"),
+		WithVerificationKeys("older-secret", "old-secret"),
+	)
+	if err := rotated.Validate("PUT", "/upload/a.txt", sig, exp); err != nil {
+		t.Fatalf("old-key URL should validate during the overlap: %v", err)
+	}
+	if !rotated.AcceptsKey("old-secret") || rotated.AcceptsKey("unknown-secret") {
+		t.Fatalf("expected only configured keys accepted")
+	}
+
+	// New URLs are signed with the primary key
+	newSigned, _ := rotated.SignURL("PUT", "/upload/a.txt", time.Minute)
+	newSig, newExp := parseSigned(t, newSigned)
+	if err := New(WithSecretKey("new-secret")).Validate("PUT", "/upload/a.txt", newSig, newExp); err != nil {
+		t.Fatalf("expected new URLs signed with the primary key: %v", err)
+	}
+
+	// Dropping the old key ends the overlap
+	if err := New(WithSecretKey("new-secret")).Validate("PUT", "/upload/a.txt", sig, exp); err != ErrInvalidSignature {
+		t.Fatalf("expected old key rejected once dropped, got %v", err)
+	}
+}
+
+func TestSigner_SignURLs(t *testing.T) {
+	signer := New(WithSecretKey("batch-secret"))
+	paths := []string{"/download/a", "/download/b?filename=b.txt"}
+
+	signed, err := signer.SignURLs("GET", paths, time.Minute)
+	if err != nil {
+		t.Fatalf("sign urls: %v", err)
+	}
+	for i, path := range paths {
+		single, _ := parseSigned(t, signed[i])
+		_, exp := parseSigned(t, signed[i])
+		if err := signer.Validate("GET", path, single, exp); err != nil {
+			t.Fatalf("batch-signed %s should validate: %v", path, err)
+		}
+	}
+}
+
+func TestSigner_ExtractObjectKeyPatterns(t *testing.T) {
+	signer := New(WithURLPatterns("/download/{key}", "/preview/{key}"))
+
+	for path, want := range map[string]string{
+		"/download/docs/a.pdf": "docs/a.pdf",
+		"/preview/docs/a.pdf":  "docs/a.pdf",
+	} {
+		key, err := signer.ExtractObjectKey(path)
+		if err != nil || key != want {
+			t.Fatalf("%s: expected %q, got %q (%v)", path, want, key, err)
+		}
+	}
+	if _, err := signer.ExtractObjectKey("/upload/docs/a.pdf"); err == nil {
+		t.Fatalf("expected an error for a path matching no pattern")
+	}
+	if key, err := New().ExtractObjectKey("/upload/a.txt"); err != nil || key != "a.txt" {
+		t.Fatalf("expected the default upload pattern, got %q (%v)", key, err)
+	}
+}
+
+func TestSigner_ClockSkew(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []Option
+		expiresIn time.Duration
+		wantErr   error
+	}{
+		{"inside default skew", nil, -20 * time.Second, nil},
+		{"outside default skew", nil, -40 * time.Second, ErrExpired},
+		{"inside configured skew", []Option{WithClockSkew(2 * time.Minute)}, -100 * time.Second, nil},
+		{"outside configured skew", []Option{WithClockSkew(2 * time.Minute)}, -130 * time.Second, ErrExpired},
+		{"skew disabled", []Option{WithClockSkew(0)}, -2 * time.Second, ErrExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signer := New(append([]Option{WithSecretKey("primary-secret")}, tt.opts...)...)
+			signed, err := signer.SignURL("PUT", "/upload/a.txt", tt.expiresIn)
+			if err != nil {
+				t.Fatalf("sign: %v", err)
+			}
+			sig, exp := parseSigned(t, signed)
+			if err := signer.Validate("PUT", "/upload/a.txt", sig, exp); err != tt.wantErr {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package simplecontent
+
+import (
+	"fmt"
+	"io"
+)
+
+// SeekableReader gives a forward-only reader, such as the body of a remote
+// download or a decompressing reader, the Seek method of a file. Forward
+// seeks read and discard the skipped bytes; seeks backward or relative to
+// the end return ErrNotSeekable and leave the position unchanged. As with a
+// file, seeking past the end succeeds and later reads return io.EOF.
+type SeekableReader struct {
+	rc  io.ReadCloser
+	pos int64
+}
+
+// NewSeekableReader returns rc itself when it can already seek, e.g. the
+// *os.File behind an uncompressed filesystem download, and otherwise wraps
+// it in a SeekableReader, so archive parsers can skip ahead uniformly
+// across backends
+func NewSeekableReader(rc io.ReadCloser) io.ReadSeekCloser {
+	if rsc, ok := rc.(io.ReadSeekCloser); ok {
+		return rsc
+	}
+	return &SeekableReader{rc: rc}
+}
+
+func (r *SeekableReader) Read(p []byte) (int, error) {
+	n, err := r.rc.Read(p)
+	r.pos += int64(n)
+	return n, err
+}
+
+// Seek moves forward to offset, relative to the start or the current
+// position
+func (r *SeekableReader) Seek(offset int64, whence int) (int64, error) {
+	var target int64
+	switch whence {
+	case io.SeekStart:
+		target = offset
+	case io.SeekCurrent:
+		target = r.pos + offset
+	case io.SeekEnd:
+		return r.pos, fmt.Errorf("%w: seek relative to the end", ErrNotSeekable)
+	default:
+		return r.pos, fmt.Errorf("invalid whence %d", whence)
+	}
+	if target < r.pos {
+		return r.pos, fmt.Errorf("%w: seek back from %d to %d", ErrNotSeekable, r.pos, target)
+	}
+
+	n, err := io.CopyN(io.Discard, r.rc, target-r.pos)
+	r.pos += n
+	if err == io.EOF {
+		// Past the end: reads report EOF from here on
+		r.pos = target
+		return target, nil
+	} else if err != nil {
+		return r.pos, err
+	}
+	return r.pos, nil
+}
+
+func (r *SeekableReader) Close() error {
+	return r.rc.Close()
+}
@@ -0,0 +1,57 @@
+package simplecontent_test
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestSeekableReader(t *testing.T) {
+	r := simplecontent.NewSeekableReader(io.NopCloser(strings.NewReader("header....footer")))
+	defer r.Close()
+
+	head := make([]byte, 6)
+	if _, err := io.ReadFull(r, head); err != nil || string(head) != "header" {
+		t.Fatalf("read header: %q, %v", head, err)
+	}
+	if pos, err := r.Seek(4, io.SeekCurrent); err != nil || pos != 10 {
+		t.Fatalf("seek forward: %d, %v", pos, err)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "footer" {
+		t.Fatalf("unexpected content after seek: %q", rest)
+	}
+
+	if pos, err := r.Seek(0, io.SeekStart); !errors.Is(err, simplecontent.ErrNotSeekable) || pos != 16 {
+		t.Fatalf("expected ErrNotSeekable seeking back, got %d, %v", pos, err)
+	}
+	if _, err := r.Seek(0, io.SeekEnd); !errors.Is(err, simplecontent.ErrNotSeekable) {
+		t.Fatalf("expected ErrNotSeekable seeking from the end, got %v", err)
+	}
+	if pos, err := r.Seek(100, io.SeekStart); err != nil || pos != 100 {
+		t.Fatalf("seek past the end: %d, %v", pos, err)
+	}
+	if n, err := r.Read(make([]byte, 1)); n != 0 || err != io.EOF {
+		t.Fatalf("expected EOF past the end, got %d, %v", n, err)
+	}
+}
+
+func TestSeekableReader_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f")
+	if err := os.WriteFile(path, []byte("0123456789"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := simplecontent.NewSeekableReader(f)
+	defer r.Close()
+	if r != io.ReadSeekCloser(f) {
+		t.Fatal("expected a file to be used as is")
+	}
+}
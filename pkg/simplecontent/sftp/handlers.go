@@ -0,0 +1,237 @@
+// Package sftp serves a simplecontent.BlobStore over SFTP through the
+// request server of github.com/pkg/sftp, so partners can browse prefixes as
+// directories and fetch objects with any SFTP client:
+//
+//	server := sftp.NewRequestServer(channel, simplesftp.NewHandlers(store))
+//	err := server.Serve()
+//
+// Object keys are paths below the SFTP root, with "/" separating
+// directories; a directory exists when an object exists beneath it. The
+// handlers are read-only unless WithWrites is given, in which case a put
+// stores the file with Upload once the client closes it.
+package sftp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/pkg/sftp"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// Option is a functional option for configuring the handlers
+type Option func(*handlers)
+
+// WithWrites enables uploads, stored with the store's Upload when the client
+// closes the file
+func WithWrites() Option {
+	return func(h *handlers) {
+		h.writable = true
+	}
+}
+
+type handlers struct {
+	store    simplecontent.BlobStore
+	fsys     fs.FS
+	writable bool
+}
+
+// NewHandlers returns SFTP request handlers serving the given store
+func NewHandlers(store simplecontent.BlobStore, opts ...Option) sftp.Handlers {
+	h := &handlers{store: store, fsys: simplecontent.AsFS(store)}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return sftp.Handlers{FileGet: h, FilePut: h, FileCmd: h, FileList: h}
+}
+
+// objectKey converts an SFTP path to an object key, or "." for the root
+func objectKey(p string) string {
+	key := strings.TrimPrefix(path.Clean("/"+p), "/")
+	if key == "" {
+		return "."
+	}
+	return key
+}
+
+func (h *handlers) Fileread(r *sftp.Request) (io.ReaderAt, error) {
+	key := objectKey(r.Filepath)
+	info, err := fs.Stat(h.fsys, key)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s is a directory", r.Filepath)
+	}
+	return &objectReader{store: h.store, key: key}, nil
+}
+
+func (h *handlers) Filewrite(r *sftp.Request) (io.WriterAt, error) {
+	if !h.writable {
+		return nil, sftp.ErrSSHFxPermissionDenied
+	}
+	key := objectKey(r.Filepath)
+	if key == "." {
+		return nil, fmt.Errorf("%s is a directory", r.Filepath)
+	}
+
+	// Clients may write chunks out of order, so the upload is assembled in a
+	// temporary file and stored on close
+	tmp, err := os.CreateTemp("", "simplecontent-sftp-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	return &objectWriter{store: h.store, key: key, file: tmp}, nil
+}
+
+func (h *handlers) Filecmd(r *sftp.Request) error {
+	switch r.Method {
+	case "Setstat", "Mkdir":
+		// Object stores keep no modes or times and directories are
+		// implicit, but clients issue these around uploads
+		if h.writable {
+			return nil
+		}
+		return sftp.ErrSSHFxPermissionDenied
+	}
+	return sftp.ErrSSHFxOpUnsupported
+}
+
+func (h *handlers) Filelist(r *sftp.Request) (sftp.ListerAt, error) {
+	key := objectKey(r.Filepath)
+	switch r.Method {
+	case "List":
+		entries, err := fs.ReadDir(h.fsys, key)
+		if err != nil {
+			return nil, err
+		}
+		infos := make(listerAt, 0, len(entries))
+		for _, e := range entries {
+			info, err := e.Info()
+			if err != nil {
+				return nil, err
+			}
+			infos = append(infos, info)
+		}
+		return infos, nil
+	case "Stat":
+		info, err := fs.Stat(h.fsys, key)
+		if err != nil {
+			return nil, err
+		}
+		return listerAt{info}, nil
+	}
+	return nil, sftp.ErrSSHFxOpUnsupported
+}
+
+// listerAt serves a fixed list of file infos
+type listerAt []os.FileInfo
+
+func (l listerAt) ListAt(ls []os.FileInfo, offset int64) (int, error) {
+	if offset >= int64(len(l)) {
+		return 0, io.EOF
+	}
+	n := copy(ls, l[offset:])
+	if n < len(ls) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+// objectReader serves reads at arbitrary offsets from a downloaded object.
+// Seekable downloads are read in place; other downloads are read forward and
+// reopened when a client reads behind the current position.
+type objectReader struct {
+	store simplecontent.BlobStore
+	key   string
+
+	mu  sync.Mutex
+	rc  io.ReadCloser
+	pos int64
+}
+
+func (o *objectReader) ReadAt(p []byte, off int64) (int, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if err := o.seek(off); err != nil {
+		return 0, err
+	}
+	n, err := io.ReadFull(o.rc, p)
+	o.pos += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// seek positions the download at off
+func (o *objectReader) seek(off int64) error {
+	if o.rc != nil && off == o.pos {
+		return nil
+	}
+	if s, ok := o.rc.(io.Seeker); ok {
+		if _, err := s.Seek(off, io.SeekStart); err != nil {
+			return err
+		}
+		o.pos = off
+		return nil
+	}
+
+	if o.rc == nil || off < o.pos {
+		if o.rc != nil {
+			o.rc.Close()
+			o.rc = nil
+		}
+		rc, err := o.store.Download(context.Background(), o.key)
+		if err != nil {
+			return err
+		}
+		o.rc, o.pos = rc, 0
+		if _, ok := rc.(io.Seeker); ok {
+			return o.seek(off)
+		}
+	}
+	n, err := io.CopyN(io.Discard, o.rc, off-o.pos)
+	o.pos += n
+	return err
+}
+
+func (o *objectReader) Close() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.rc == nil {
+		return nil
+	}
+	err := o.rc.Close()
+	o.rc = nil
+	return err
+}
+
+// objectWriter collects an upload in a temporary file and stores it on close
+type objectWriter struct {
+	store simplecontent.BlobStore
+	key   string
+	file  *os.File
+}
+
+func (o *objectWriter) WriteAt(p []byte, off int64) (int, error) {
+	return o.file.WriteAt(p, off)
+}
+
+func (o *objectWriter) Close() error {
+	defer os.Remove(o.file.Name())
+	defer o.file.Close()
+
+	if _, err := o.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	return o.store.Upload(context.Background(), o.key, o.file)
+}
@@ -0,0 +1,90 @@
+package sftp
+
+import (
+	"context"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	fsstorage "github.com/tendant/simple-content/pkg/simplecontent/storage/fs"
+)
+
+func newTestClient(t *testing.T, store simplecontent.BlobStore, opts ...Option) *sftp.Client {
+	t.Helper()
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, NewHandlers(store, opts...))
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func newTestStore(t *testing.T) simplecontent.BlobStore {
+	t.Helper()
+	store, err := fsstorage.New(fsstorage.Config{BaseDir: t.TempDir()})
+	require.NoError(t, err)
+	ctx := context.Background()
+	require.NoError(t, store.Upload(ctx, "reports/2024/q1.csv", strings.NewReader("a,b\n1,2\n")))
+	require.NoError(t, store.Upload(ctx, "reports/readme.txt", strings.NewReader("hello")))
+	return store
+}
+
+func TestHandlers_ReadOnly(t *testing.T) {
+	client := newTestClient(t, newTestStore(t))
+
+	entries, err := client.ReadDir("/reports")
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "2024", entries[0].Name())
+	assert.True(t, entries[0].IsDir())
+	assert.Equal(t, "readme.txt", entries[1].Name())
+	assert.Equal(t, int64(5), entries[1].Size())
+
+	f, err := client.Open("/reports/2024/q1.csv")
+	require.NoError(t, err)
+	content, err := io.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "a,b\n1,2\n", string(content))
+
+	buf := make([]byte, 3)
+	n, err := f.ReadAt(buf, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "1,2", string(buf[:n]))
+	require.NoError(t, f.Close())
+
+	_, err = client.Stat("/reports/missing.txt")
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	_, err = client.Create("/reports/new.txt")
+	assert.Error(t, err)
+}
+
+func TestHandlers_WithWrites(t *testing.T) {
+	store := newTestStore(t)
+	client := newTestClient(t, store, WithWrites())
+
+	require.NoError(t, client.MkdirAll("/uploads/partner"))
+	f, err := client.Create("/uploads/partner/data.bin")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(strings.Repeat("x", 100000)))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	rc, err := store.Download(context.Background(), "uploads/partner/data.bin")
+	require.NoError(t, err)
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	require.NoError(t, err)
+	assert.Equal(t, 100000, len(content))
+
+	assert.Error(t, client.Remove("/reports/readme.txt"))
+}
@@ -0,0 +1,142 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// aliasDir is the internal directory under baseDir holding alias records
+const aliasDir = ".aliases"
+
+// maxAliasHops bounds how many aliases are followed to reach an object
+const maxAliasHops = 8
+
+// aliasRecord is the content of an alias record
+type aliasRecord struct {
+	Target string `json:"target"`
+}
+
+// aliasPath returns the path of the alias record for aliasKey. Preview keys
+// are accepted so that reading one that is missing reports it not found, but
+// CreateAlias never records an alias for them.
+func (b *Backend) aliasPath(aliasKey string) (string, error) {
+	if _, err := b.readPath(aliasKey); err != nil {
+		return "", err
+	}
+	return b.reservedPath(aliasDir + b.keySeparator + aliasKey)
+}
+
+// CreateAlias makes aliasKey refer to targetKey, so Download, GetObjectMeta
+// and GetDownloadURL on aliasKey follow it to the object at targetKey, for
+// keeping old keys working after a rename. Aliases may point at other
+// aliases, up to eight hops. An object later uploaded at aliasKey shadows
+// the alias, and Delete on aliasKey removes only the alias. Creating an alias
+// replaces any existing alias at aliasKey.
+//
+// targetKey must resolve to an object, aliasKey must not hold one, and the
+// alias must not introduce a loop.
+func (b *Backend) CreateAlias(ctx context.Context, aliasKey, targetKey string) (err error) {
+	defer wrapError(&err, "create_alias", aliasKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	if _, err := b.objectPath(aliasKey); err != nil {
+		return err
+	}
+	aliasFile, err := b.aliasPath(aliasKey)
+	if err != nil {
+		return err
+	}
+	if exists, err := b.Exists(ctx, aliasKey); err != nil {
+		return err
+	} else if exists {
+		return fmt.Errorf("object exists at alias key %q", aliasKey)
+	}
+
+	resolved := targetKey
+	if exists, err := b.Exists(ctx, targetKey); err != nil {
+		return err
+	} else if !exists {
+		if resolved, _, err = b.resolveAlias(targetKey); err != nil {
+			return err
+		}
+	}
+	if resolved == aliasKey {
+		return fmt.Errorf("%w: %s resolves back to %s", simplecontent.ErrAliasLoop, targetKey, aliasKey)
+	}
+
+	data, err := json.Marshal(aliasRecord{Target: targetKey})
+	if err != nil {
+		return err
+	}
+	if err := b.mkdirAll(filepath.Dir(aliasFile)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	return writeReplace(ctx, aliasFile, bytes.NewReader(data), 0)
+}
+
+// resolveAlias follows the alias at objectKey to the key and path of the
+// object it refers to. It returns simplecontent.ErrObjectNotFound when
+// objectKey is not an alias or its chain ends without an object, and
+// simplecontent.ErrAliasLoop when the chain loops or is too long.
+func (b *Backend) resolveAlias(objectKey string) (string, string, error) {
+	key := objectKey
+	seen := map[string]bool{key: true}
+	for hops := 0; ; hops++ {
+		aliasFile, err := b.aliasPath(key)
+		if err != nil {
+			return "", "", err
+		}
+		data, err := os.ReadFile(aliasFile)
+		if os.IsNotExist(err) {
+			return "", "", simplecontent.ErrObjectNotFound
+		} else if err != nil {
+			return "", "", fmt.Errorf("failed to read alias: %w", err)
+		}
+		var record aliasRecord
+		if err := json.Unmarshal(data, &record); err != nil {
+			return "", "", fmt.Errorf("failed to read alias: %w", err)
+		}
+
+		key = record.Target
+		if seen[key] || hops+1 >= maxAliasHops {
+			return "", "", fmt.Errorf("%w: following %s", simplecontent.ErrAliasLoop, objectKey)
+		}
+		seen[key] = true
+
+		filePath, err := b.objectPath(key)
+		if err != nil {
+			return "", "", err
+		}
+		if info, err := os.Stat(filePath); err == nil && info.Mode().IsRegular() {
+			return key, filePath, nil
+		}
+		if entry, _ := b.packed(filePath); entry != nil {
+			return key, filePath, nil
+		}
+	}
+}
+
+// removeAlias deletes the alias record at aliasKey, reporting false if there
+// is none
+func (b *Backend) removeAlias(aliasKey string) (bool, error) {
+	aliasFile, err := b.aliasPath(aliasKey)
+	if err != nil {
+		return false, err
+	}
+	if err := os.Remove(aliasFile); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to delete alias: %w", err)
+	}
+	b.cleanupEmptyDirectories(filepath.Dir(aliasFile))
+	return true, nil
+}
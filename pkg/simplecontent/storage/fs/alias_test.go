@@ -0,0 +1,122 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Alias(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), URLPrefix: "/files"})
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "v2/report.txt", strings.NewReader("report")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.CreateAlias(ctx, "v1/report.txt", "v2/report.txt"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	if err := backend.CreateAlias(ctx, "v0/report", "v1/report.txt"); err != nil {
+		t.Fatalf("create chained alias: %v", err)
+	}
+
+	for _, key := range []string{"v1/report.txt", "v0/report"} {
+		if got := readObject(t, backend, key); got != "report" {
+			t.Fatalf("%s: expected target content, got %q", key, got)
+		}
+		meta, err := backend.GetObjectMeta(ctx, key)
+		if err != nil || meta.Key != "v2/report.txt" {
+			t.Fatalf("%s: expected target meta, got %+v err=%v", key, meta, err)
+		}
+		url, err := backend.GetDownloadURL(ctx, key, "")
+		if err != nil || url != "/files/download/v2/report.txt" {
+			t.Fatalf("%s: expected URL of the target, got %s err=%v", key, url, err)
+		}
+	}
+	if keys := listKeys(t, backend, ""); len(keys) != 1 || keys[0] != "v2/report.txt" {
+		t.Fatalf("expected aliases not to be listed, got %v", keys)
+	}
+
+	if err := backend.CreateAlias(ctx, "v2/report.txt", "v1/report.txt"); err == nil {
+		t.Fatalf("expected alias over an existing object to fail")
+	}
+	if err := backend.CreateAlias(ctx, "loop/a", "loop/b"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected alias to a missing target to fail, got %v", err)
+	}
+
+	// Deleting an alias leaves its target
+	if err := backend.Delete(ctx, "v1/report.txt"); err != nil {
+		t.Fatalf("delete alias: %v", err)
+	}
+	if _, err := backend.GetObjectMeta(ctx, "v1/report.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected alias removed, got %v", err)
+	}
+	if _, err := backend.Download(ctx, "v0/report"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected dangling alias to report not found, got %v", err)
+	}
+	if got := readObject(t, backend, "v2/report.txt"); got != "report" {
+		t.Fatalf("expected target kept, got %q", got)
+	}
+	if err := backend.Delete(ctx, "v1/report.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func TestFSBackend_AliasLoop(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "a", strings.NewReader("a")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.CreateAlias(ctx, "b", "a"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	if err := backend.Delete(ctx, "a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	// With a gone, b -> a -> b would loop
+	if err := backend.CreateAlias(ctx, "a", "b"); err == nil {
+		t.Fatalf("expected alias to a dangling alias to fail")
+	}
+	aliasFile, _ := backend.aliasPath("a")
+	if err := writeReplace(ctx, aliasFile, strings.NewReader(`{"target":"b"}`), 0); err != nil {
+		t.Fatalf("write alias: %v", err)
+	}
+	if _, err := backend.Download(ctx, "b"); !errors.Is(err, simplecontent.ErrAliasLoop) {
+		t.Fatalf("expected ErrAliasLoop, got %v", err)
+	}
+}
+
+func TestFSBackend_ReservedKeys(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "private/secret.txt", strings.NewReader("secret")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	// A forged alias record would redirect public/hello.txt to the secret
+	err := backend.Upload(ctx, ".aliases/public/hello.txt", strings.NewReader(`{"target":"private/secret.txt"}`))
+	if !errors.Is(err, simplecontent.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey uploading an alias record, got %v", err)
+	}
+	if _, err := backend.Download(ctx, "public/hello.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+
+	for _, key := range []string{".trash/a", ".previews/a", ".quarantine/a", ".packs/a", ".multipart/a", "./.aliases/a"} {
+		if err := backend.Upload(ctx, key, strings.NewReader("x")); !errors.Is(err, simplecontent.ErrInvalidKey) {
+			t.Errorf("expected ErrInvalidKey uploading %s, got %v", key, err)
+		}
+	}
+	if err := backend.CreateAlias(ctx, ".previews/private/secret.txt", "private/secret.txt"); !errors.Is(err, simplecontent.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey creating an alias under .previews, got %v", err)
+	}
+}
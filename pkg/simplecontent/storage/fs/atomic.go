@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// tempSuffix is the marker inserted between an object's file name and the
+// random part of its temporary staging file name.
+const tempSuffix = ".tmp-"
+
+// tempName returns a random, not-yet-created temporary path next to path.
+func tempName(path string) string {
+	var buf [8]byte
+	_, _ = rand.Read(buf[:])
+	return filepath.Join(filepath.Dir(path), filepath.Base(path)+tempSuffix+hex.EncodeToString(buf[:]))
+}
+
+// chmodTemp sets a staged file to mode, independent of the process umask the
+// file was created under. A zero mode leaves it unchanged; on failure the
+// staged file is removed.
+func chmodTemp(tmp string, mode os.FileMode) error {
+	if mode == 0 {
+		return nil
+	}
+	if err := os.Chmod(tmp, mode); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to set file mode: %w", err)
+	}
+	return nil
+}
+
+// isTempName reports whether a file name was produced by tempName, i.e. it
+// belongs to an upload that is in progress or was interrupted by a crash.
+func isTempName(name string) bool {
+	i := strings.LastIndex(name, tempSuffix)
+	if i < 0 {
+		return false
+	}
+	suffix := name[i+len(tempSuffix):]
+	if len(suffix) != 16 {
+		return false
+	}
+	_, err := hex.DecodeString(suffix)
+	return err == nil
+}
+
+// createTemp creates a new temporary file next to path. Unlike os.CreateTemp
+// the file is created with the same mode os.Create would use, so objects
+// committed by renaming it keep their usual permissions.
+func createTemp(path string) (*os.File, error) {
+	for {
+		f, err := os.OpenFile(tempName(path), os.O_RDWR|os.O_CREATE|os.O_EXCL, 0666)
+		if os.IsExist(err) {
+			continue
+		}
+		return f, err
+	}
+}
+
+// writeReplace streams r into a temporary file next to dst and renames it
+// into place once the copy has completed, so readers never see a partial
+// file at dst. A non-zero mode is applied before the rename.
+func writeReplace(ctx context.Context, dst string, r io.Reader, mode os.FileMode) error {
+	tmp, err := writeTemp(ctx, dst, r, mode)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dst); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to rename file: %w", err)
+	}
+	return nil
+}
+
+// writeTemp streams r into a new temporary file next to dst, returning its
+// path. A non-zero mode is applied to it.
+func writeTemp(ctx context.Context, dst string, r io.Reader, mode os.FileMode) (string, error) {
+	out, err := createTemp(dst)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	tmp := out.Name()
+
+	if _, err := io.Copy(out, contextReader{ctx: ctx, r: r}); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", ctxErr
+		}
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		os.Remove(tmp)
+		return "", err
+	}
+	if err := chmodTemp(tmp, mode); err != nil {
+		return "", err
+	}
+	return tmp, nil
+}
+
+// contextReader stops a copy from r with the context's error once ctx is
+// done, so cancelled uploads stop reading without waiting for r to end
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,79 @@
+package fs
+
+import (
+	"context"
+	"mime"
+	"path"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/mimetype"
+)
+
+// detectContentType determines an object's content type from its leading
+// bytes and key: a magic-number match wins, then the key's extension, then
+// generic sniffing
+func detectContentType(objectKey string, head []byte) string {
+	if ct, ok := mimetype.Match(head); ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(path.Ext(objectKey)); ct != "" {
+		return ct
+	}
+	if len(head) == 0 {
+		return "application/octet-stream"
+	}
+	return mimetype.Detect(head)
+}
+
+// sameMediaType compares two content types ignoring parameters and case
+func sameMediaType(a, b string) bool {
+	ma, _, errA := mime.ParseMediaType(a)
+	mb, _, errB := mime.ParseMediaType(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return ma == mb
+}
+
+// AuditContentTypes compares the content type recorded for each object under
+// prefix with a fresh detection (magic numbers, then extension, then
+// sniffing) and returns the mismatching keys mapped to the detected type.
+// With fix, the recorded type is replaced by the detected one. Objects
+// without a recorded content type are always detected on read and are not
+// reported.
+func (b *Backend) AuditContentTypes(ctx context.Context, prefix string, fix bool) (_ map[string]string, err error) {
+	defer wrapError(&err, "audit_content_types", prefix)
+
+	if fix {
+		if err := b.checkWritable(); err != nil {
+			return nil, err
+		}
+	}
+	mismatches := make(map[string]string)
+	err = b.walk(ctx, prefix, func(meta simplecontent.ObjectMeta) error {
+		if meta.ContentType == "" {
+			return nil
+		}
+		filePath, err := b.objectPath(meta.Key)
+		if err != nil {
+			return err
+		}
+		sc, err := b.loadSidecar(filePath)
+		if err != nil {
+			return err
+		}
+
+		detected := detectContentType(meta.Key, readHead(filePath, sc.Codec))
+		if sameMediaType(detected, sc.ContentType) {
+			return nil
+		}
+		mismatches[meta.Key] = detected
+
+		if !fix {
+			return nil
+		}
+		sc.ContentType = detected
+		return b.storeSidecar(filePath, sc)
+	})
+	return mismatches, err
+}
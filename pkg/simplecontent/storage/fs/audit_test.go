@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_AuditContentTypes(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	png := "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"
+	uploads := []simplecontent.UploadParams{
+		{ObjectKey: "img/logo.png", MimeType: "image/png"},
+		{ObjectKey: "img/photo.png", MimeType: "application/octet-stream"},
+		{ObjectKey: "docs/notes.txt", MimeType: "text/plain; charset=utf-8"},
+	}
+	bodies := []string{png, png, "some notes"}
+	for i, params := range uploads {
+		if err := backend.UploadWithParams(ctx, strings.NewReader(bodies[i]), params); err != nil {
+			t.Fatalf("upload %s: %v", params.ObjectKey, err)
+		}
+	}
+	// Detected on read, nothing recorded to drift
+	if err := backend.Upload(ctx, "img/undeclared.png", strings.NewReader(png)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	mismatches, err := backend.AuditContentTypes(ctx, "", false)
+	if err != nil {
+		t.Fatalf("audit: %v", err)
+	}
+	if len(mismatches) != 1 || mismatches["img/photo.png"] != "image/png" {
+		t.Fatalf("unexpected mismatches %v", mismatches)
+	}
+	if meta, _ := backend.GetObjectMeta(ctx, "img/photo.png"); meta.ContentType != "application/octet-stream" {
+		t.Fatalf("expected report-only audit to leave metadata, got %q", meta.ContentType)
+	}
+
+	if _, err := backend.AuditContentTypes(ctx, "img/", true); err != nil {
+		t.Fatalf("audit fix: %v", err)
+	}
+	if meta, _ := backend.GetObjectMeta(ctx, "img/photo.png"); meta.ContentType != "image/png" {
+		t.Fatalf("expected fixed content type, got %q", meta.ContentType)
+	}
+	if mismatches, _ := backend.AuditContentTypes(ctx, "", false); len(mismatches) != 0 {
+		t.Fatalf("expected no mismatches after fix, got %v", mismatches)
+	}
+}
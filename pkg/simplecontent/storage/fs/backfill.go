@@ -0,0 +1,134 @@
+package fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// BackfillChecksums computes and records the checksum of every object under
+// prefix whose sidecar has none, hashing up to concurrency objects at a time
+// (at least one). algo must be "sha256" (or empty), the only checksum the
+// backend records. It returns how many checksums were stored.
+//
+// Objects that already have a checksum are skipped, so a run interrupted by
+// cancelling ctx can be resumed by calling it again. An object replaced
+// while it is hashed is left to the checksum its upload records. Failures on
+// individual objects do not stop the others and are returned joined.
+func (b *Backend) BackfillChecksums(ctx context.Context, prefix string, concurrency int, algo string) (processed int, err error) {
+	defer wrapError(&err, "backfill_checksums", prefix)
+
+	if err := b.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if algo != "" && !strings.EqualFold(algo, "sha256") {
+		return 0, fmt.Errorf("unsupported checksum algorithm %q", algo)
+	}
+	concurrency = max(concurrency, 1)
+
+	var (
+		mu   sync.Mutex
+		errs []error
+		wg   sync.WaitGroup
+	)
+	paths := make(chan string)
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for filePath := range paths {
+				stored, err := b.backfillChecksum(ctx, filePath)
+				mu.Lock()
+				if stored {
+					processed++
+				}
+				if err != nil && ctx.Err() == nil {
+					errs = append(errs, err)
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	walkErr := b.walkObjects(ctx, prefix, func(filePath string, _ simplecontent.ObjectMeta, sc *sidecar) error {
+		if sc.SHA256 != "" {
+			return nil
+		}
+		select {
+		case paths <- filePath:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	close(paths)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return processed, err
+	}
+	return processed, errors.Join(append([]error{walkErr}, errs...)...)
+}
+
+// backfillChecksum hashes the object at filePath and records the checksum,
+// reporting whether it did. Nothing is recorded if the object was deleted,
+// replaced or given a checksum while it was hashed.
+func (b *Backend) backfillChecksum(ctx context.Context, filePath string) (bool, error) {
+	sc, err := b.loadSidecar(filePath)
+	if err != nil || sc.SHA256 != "" || sc.reserved() {
+		return false, err
+	}
+
+	file, err := openObject(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to open file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return false, fmt.Errorf("failed to get file info: %w", err)
+	}
+	content, err := openDecoded(file, sc.Codec)
+	if err != nil {
+		return false, err
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, contextReader{ctx: ctx, r: content}); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return false, ctxErr
+		}
+		return false, fmt.Errorf("failed to read %s: %w", filePath, err)
+	}
+
+	current, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get file info: %w", err)
+	}
+	if !os.SameFile(info, current) || fileETag(current) != fileETag(info) {
+		return false, nil
+	}
+	sc, err = b.loadSidecar(filePath)
+	if err != nil || sc.SHA256 != "" {
+		return false, err
+	}
+	sc.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	if err := b.storeSidecar(filePath, sc); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_BackfillChecksums(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	// Legacy objects have sidecars without a checksum
+	for i := range 10 {
+		key := fmt.Sprintf("legacy/%d.txt", i)
+		if err := backend.Upload(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+		if i < 7 {
+			filePath := mustObjectPath(t, backend, key)
+			sc, err := backend.loadSidecar(filePath)
+			if err != nil {
+				t.Fatalf("load sidecar: %v", err)
+			}
+			sc.SHA256 = ""
+			if err := backend.storeSidecar(filePath, sc); err != nil {
+				t.Fatalf("store sidecar: %v", err)
+			}
+		}
+	}
+
+	processed, err := backend.BackfillChecksums(ctx, "legacy/", 3, "sha256")
+	if err != nil {
+		t.Fatalf("backfill: %v", err)
+	}
+	if processed != 7 {
+		t.Fatalf("expected 7 checksums backfilled, got %d", processed)
+	}
+	for i := range 10 {
+		key := fmt.Sprintf("legacy/%d.txt", i)
+		sc, err := backend.loadSidecar(mustObjectPath(t, backend, key))
+		if err != nil {
+			t.Fatalf("load sidecar: %v", err)
+		}
+		sum := sha256.Sum256([]byte(key))
+		if sc.SHA256 != hex.EncodeToString(sum[:]) {
+			t.Fatalf("unexpected checksum of %s: %q", key, sc.SHA256)
+		}
+	}
+
+	// A second run has nothing left to do
+	if processed, err := backend.BackfillChecksums(ctx, "legacy/", 3, ""); err != nil || processed != 0 {
+		t.Fatalf("expected resumed run to do nothing, got %d, %v", processed, err)
+	}
+
+	if _, err := backend.BackfillChecksums(ctx, "", 1, "md5"); err == nil {
+		t.Fatal("expected an unsupported algorithm to fail")
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := backend.BackfillChecksums(cancelled, "", 2, "sha256"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
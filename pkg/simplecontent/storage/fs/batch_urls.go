@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent/presigned"
+)
+
+// GetDownloadURLs returns download URLs for many objects at once, keyed by
+// object key. When signing is enabled all URLs share one expiration and one
+// signer pass. An expiry of 0 uses the configured PresignExpires.
+func (b *Backend) GetDownloadURLs(ctx context.Context, keys []string, expiry time.Duration) (map[string]string, error) {
+	if b.urlPrefix == "" {
+		return nil, errors.New("direct download required for filesystem backend")
+	}
+	return b.batchURLs(b.downloadSigner, "GET", "/download/", keys, expiry)
+}
+
+// GetUploadURLs returns upload URLs for many objects at once, keyed by
+// object key. An expiry of 0 uses the configured PresignExpires.
+func (b *Backend) GetUploadURLs(ctx context.Context, keys []string, expiry time.Duration) (map[string]string, error) {
+	if b.urlPrefix == "" {
+		return nil, errors.New("direct upload required for filesystem backend")
+	}
+	return b.batchURLs(b.signer, "PUT", "/upload/", keys, expiry)
+}
+
+// batchURLs builds route+key paths for keys and signs them in one pass
+func (b *Backend) batchURLs(signer *presigned.Signer, method, route string, keys []string, expiry time.Duration) (map[string]string, error) {
+	paths := make([]string, len(keys))
+	for i, key := range keys {
+		paths[i] = route + key
+	}
+
+	if signer != nil {
+		if expiry == 0 {
+			expiry = b.presignExpires
+		}
+		signed, err := signer.SignURLs(method, paths, expiry)
+		if err != nil {
+			return nil, err
+		}
+		paths = signed
+	}
+
+	urls := make(map[string]string, len(keys))
+	for i, key := range keys {
+		urls[key] = b.urlPrefix + paths[i]
+	}
+	return urls, nil
+}
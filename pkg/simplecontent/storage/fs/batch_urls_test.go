@@ -0,0 +1,115 @@
+package fs
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFSBackend_GetDownloadURLs(t *testing.T) {
+	b, err := New(Config{
+		BaseDir:            t.TempDir(),
+		URLPrefix:          "https://cdn.example.com",
+		SignatureSecretKey: "batch-signing-secret",
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	keys := []string{"thumbs/1.jpg", "thumbs/2.jpg", "thumbs/3.jpg"}
+	urls, err := backend.GetDownloadURLs(ctx, keys, 10*time.Minute)
+	if err != nil {
+		t.Fatalf("get download urls: %v", err)
+	}
+	if len(urls) != len(keys) {
+		t.Fatalf("expected %d urls, got %d", len(keys), len(urls))
+	}
+
+	for _, key := range keys {
+		u, err := url.Parse(urls[key])
+		if err != nil {
+			t.Fatalf("parse url: %v", err)
+		}
+		if !strings.HasSuffix(u.Path, key) {
+			t.Fatalf("url %s does not reference key %s", urls[key], key)
+		}
+		expiresAt, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+		if err := backend.ValidateDownloadSignature(key, u.Query().Get("signature"), expiresAt, ""); err != nil {
+			t.Fatalf("signature for %s should validate: %v", key, err)
+		}
+	}
+
+	uploads, err := backend.GetUploadURLs(ctx, keys[:1], 0)
+	if err != nil {
+		t.Fatalf("get upload urls: %v", err)
+	}
+	u, _ := url.Parse(uploads[keys[0]])
+	expiresAt, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+	if err := backend.ValidateUploadSignature(keys[0], u.Query().Get("signature"), expiresAt); err != nil {
+		t.Fatalf("upload signature should validate: %v", err)
+	}
+}
+
+func TestFSBackend_GetDownloadURLsUnsigned(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), URLPrefix: "/files"})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	urls, err := b.(*Backend).GetDownloadURLs(context.Background(), []string{"a", "b"}, 0)
+	if err != nil {
+		t.Fatalf("get download urls: %v", err)
+	}
+	if urls["a"] != "/files/download/a" || urls["b"] != "/files/download/b" {
+		t.Fatalf("unexpected unsigned urls: %v", urls)
+	}
+}
+
+func TestFSBackend_URLsWithBase(t *testing.T) {
+	b, err := New(Config{
+		BaseDir:            t.TempDir(),
+		URLPrefix:          "https://origin.example.com",
+		SignatureSecretKey: "test-secret-key-for-base-url-tests",
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	for _, base := range []string{"https://eu.cdn.example.com", "https://us.cdn.example.com/"} {
+		raw, err := backend.GetDownloadURLWithBase(ctx, base, "docs/a.pdf", "a.pdf")
+		if err != nil {
+			t.Fatalf("download url: %v", err)
+		}
+		if !strings.HasPrefix(raw, strings.TrimSuffix(base, "/")+"/download/docs/a.pdf?") {
+			t.Fatalf("unexpected download url %s", raw)
+		}
+		u, _ := url.Parse(raw)
+		expires, _ := strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+		if err := backend.ValidateDownloadSignature("docs/a.pdf", u.Query().Get("signature"), expires, "a.pdf"); err != nil {
+			t.Fatalf("signature for %s did not validate: %v", base, err)
+		}
+
+		raw, err = backend.GetUploadURLWithBase(ctx, base, "docs/a.pdf")
+		if err != nil {
+			t.Fatalf("upload url: %v", err)
+		}
+		u, _ = url.Parse(raw)
+		expires, _ = strconv.ParseInt(u.Query().Get("expires"), 10, 64)
+		if u.Path != "/upload/docs/a.pdf" {
+			t.Fatalf("unexpected upload url %s", raw)
+		}
+		if err := backend.ValidateUploadSignature("docs/a.pdf", u.Query().Get("signature"), expires); err != nil {
+			t.Fatalf("upload signature for %s did not validate: %v", base, err)
+		}
+	}
+
+	if _, err := backend.GetDownloadURLWithBase(ctx, "", "docs/a.pdf", ""); err == nil {
+		t.Fatalf("expected empty base URL to be rejected")
+	}
+}
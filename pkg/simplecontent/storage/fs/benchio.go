@@ -0,0 +1,82 @@
+package fs
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// benchChunkSize is the write and read size BenchmarkIO uses
+const benchChunkSize = 1 << 20
+
+// BenchmarkIO measures the throughput of BaseDir by writing sizeBytes of
+// random data to a temporary file, syncing it to disk, and reading it back.
+// It returns bytes per second for both directions. The file lives under a
+// temporary name that is never listed and is removed before returning, so no
+// object is touched.
+//
+// The read is usually served from the page cache right after the write, so
+// readBPS reflects the cache more than the disk; compare samples over time
+// rather than against raw device figures.
+func (b *Backend) BenchmarkIO(ctx context.Context, sizeBytes int64) (writeBPS, readBPS float64, err error) {
+	defer wrapError(&err, "benchmark_io", "")
+
+	if err := b.checkWritable(); err != nil {
+		return 0, 0, err
+	}
+
+	if sizeBytes <= 0 {
+		return 0, 0, errors.New("benchmark size must be positive")
+	}
+
+	file, err := createTemp(filepath.Join(b.baseDir, ".benchmark-io"))
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to create file: %w", err)
+	}
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	buf := make([]byte, benchChunkSize)
+	_, _ = rand.Read(buf)
+
+	start := time.Now()
+	for written := int64(0); written < sizeBytes; {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		n, err := file.Write(buf[:min(int64(len(buf)), sizeBytes-written)])
+		if err != nil {
+			return 0, 0, fmt.Errorf("failed to write file: %w", err)
+		}
+		written += int64(n)
+	}
+	if err := file.Sync(); err != nil {
+		return 0, 0, fmt.Errorf("failed to sync file: %w", err)
+	}
+	writeBPS = float64(sizeBytes) / time.Since(start).Seconds()
+
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return 0, 0, fmt.Errorf("failed to seek file: %w", err)
+	}
+	start = time.Now()
+	for read := int64(0); read < sizeBytes; {
+		if err := ctx.Err(); err != nil {
+			return 0, 0, err
+		}
+		n, err := file.Read(buf)
+		read += int64(n)
+		if err == io.EOF && read < sizeBytes {
+			return 0, 0, io.ErrUnexpectedEOF
+		} else if err != nil && err != io.EOF {
+			return 0, 0, fmt.Errorf("failed to read file: %w", err)
+		}
+	}
+	readBPS = float64(sizeBytes) / time.Since(start).Seconds()
+
+	return writeBPS, readBPS, nil
+}
@@ -0,0 +1,34 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestFSBackend_BenchmarkIO(t *testing.T) {
+	dir := t.TempDir()
+	backend := newCompressedBackend(t, dir, "")
+	ctx := context.Background()
+
+	writeBPS, readBPS, err := backend.BenchmarkIO(ctx, 3<<20+123)
+	if err != nil {
+		t.Fatalf("benchmark io: %v", err)
+	}
+	if writeBPS <= 0 || readBPS <= 0 {
+		t.Fatalf("expected positive throughput, got write=%f read=%f", writeBPS, readBPS)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Fatalf("expected benchmark file removed, found %d entries", len(entries))
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := backend.BenchmarkIO(cancelled, 1<<20); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if _, _, err := backend.BenchmarkIO(ctx, 0); err == nil {
+		t.Fatalf("expected error for zero size")
+	}
+}
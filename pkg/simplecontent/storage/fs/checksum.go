@@ -0,0 +1,41 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+)
+
+// contentSHA256 returns the hex SHA-256 of an object's original content,
+// using the checksum recorded in its sidecar when there is one and hashing
+// the (decoded) file otherwise. A missing object returns os.ErrNotExist.
+func (b *Backend) contentSHA256(filePath string) (string, error) {
+	sc, err := b.loadSidecar(filePath)
+	if err != nil {
+		return "", err
+	}
+	if sc.SHA256 != "" {
+		if _, err := os.Stat(filePath); err != nil {
+			return "", err
+		}
+		return sc.SHA256, nil
+	}
+
+	file, err := openObject(filePath)
+	if err != nil {
+		return "", err
+	}
+	content, err := openDecoded(file, sc.Codec)
+	if err != nil {
+		return "", err
+	}
+	defer content.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, content); err != nil {
+		return "", fmt.Errorf("failed to read file: %w", err)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
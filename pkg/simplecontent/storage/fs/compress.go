@@ -0,0 +1,190 @@
+package fs
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// Built-in codec names accepted by Config.Compression
+const (
+	CodecNone = "none"
+	CodecGzip = "gzip"
+	CodecZstd = "zstd"
+)
+
+// Codec compresses objects at rest. Objects record the name of the codec
+// they were written with, so a codec must stay registered for as long as
+// objects encoded with it exist.
+type Codec interface {
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		CodecGzip: gzipCodec{},
+		CodecZstd: zstdCodec{},
+	}
+)
+
+// RegisterCodec makes a codec available under name for Config.Compression
+// and for decoding objects written with it. Registering an existing name
+// replaces it.
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = codec
+}
+
+// lookupCodec returns the codec registered under name. The empty name and
+// CodecNone mean no compression and return a nil codec. Names joined with
+// "+" apply each codec in turn, as for compressed and encrypted objects.
+func lookupCodec(name string) (Codec, error) {
+	if name == "" || name == CodecNone {
+		return nil, nil
+	}
+	if strings.Contains(name, "+") {
+		var chain chainCodec
+		for _, part := range strings.Split(name, "+") {
+			codec, err := lookupCodec(part)
+			if err != nil {
+				return nil, err
+			} else if codec == nil {
+				return nil, fmt.Errorf("unknown compression codec %q", name)
+			}
+			chain = append(chain, codec)
+		}
+		return chain, nil
+	}
+
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok && strings.HasPrefix(name, encryptionPrefix) {
+		return nil, fmt.Errorf("%w: object was encrypted with a key that is not configured", simplecontent.ErrDecryptionFailed)
+	} else if !ok {
+		return nil, fmt.Errorf("unknown compression codec %q", name)
+	}
+	return codec, nil
+}
+
+// openDecoded wraps an object file so reads return its original content.
+// The returned ReadCloser closes the file; on error the file is closed.
+func openDecoded(file io.ReadCloser, codecName string) (io.ReadCloser, error) {
+	codec, err := lookupCodec(codecName)
+	if err != nil {
+		file.Close()
+		return nil, err
+	} else if codec == nil {
+		return file, nil
+	}
+
+	dec, err := codec.NewReader(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to open %s stream: %w", codecName, err)
+	}
+	return &decodedReader{ReadCloser: dec, file: file}, nil
+}
+
+// decodedReader closes both the decoder and the file beneath it, once
+type decodedReader struct {
+	io.ReadCloser
+	file io.Closer
+	once sync.Once
+	err  error
+}
+
+func (r *decodedReader) Close() error {
+	r.once.Do(func() {
+		r.err = r.ReadCloser.Close()
+		if err := r.file.Close(); r.err == nil {
+			r.err = err
+		}
+	})
+	return r.err
+}
+
+// chainCodec encodes content with each codec in turn and decodes it in
+// reverse
+type chainCodec []Codec
+
+func (c chainCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	chain := &codecChain{}
+	for i := len(c) - 1; i >= 0; i-- {
+		enc, err := c[i].NewWriter(w)
+		if err != nil {
+			return nil, err
+		}
+		chain.closers = append(chain.closers, enc)
+		w = enc
+	}
+	chain.Writer = w
+	return chain, nil
+}
+
+func (c chainCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	chain := &codecChain{}
+	for i := len(c) - 1; i >= 0; i-- {
+		dec, err := c[i].NewReader(r)
+		if err != nil {
+			chain.Close()
+			return nil, err
+		}
+		chain.closers = append(chain.closers, dec)
+		r = dec
+	}
+	chain.Reader = r
+	return chain, nil
+}
+
+// codecChain writes to or reads from the outermost of a chain of streams
+// and closes them outermost first, so each flushes into the next
+type codecChain struct {
+	io.Writer
+	io.Reader
+	closers []io.Closer
+}
+
+func (c *codecChain) Close() error {
+	var err error
+	for i := len(c.closers) - 1; i >= 0; i-- {
+		if cerr := c.closers[i].Close(); err == nil {
+			err = cerr
+		}
+	}
+	c.closers = nil
+	return err
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type zstdCodec struct{}
+
+func (zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	dec, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return dec.IOReadCloser(), nil
+}
@@ -0,0 +1,267 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newCompressedBackend(t testing.TB, dir, codec string) *Backend {
+	t.Helper()
+	b, err := New(Config{BaseDir: dir, Compression: codec})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	return b.(*Backend)
+}
+
+func readObject(t *testing.T, b *Backend, key string) string {
+	t.Helper()
+	rc, err := b.Download(context.Background(), key)
+	if err != nil {
+		t.Fatalf("download %s: %v", key, err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("read %s: %v", key, err)
+	}
+	return string(data)
+}
+
+func TestFSBackend_CompressionRoundTrip(t *testing.T) {
+	content := strings.Repeat("the quick brown fox jumps over the lazy dog\n", 200)
+
+	for _, codec := range []string{CodecNone, CodecGzip, CodecZstd} {
+		t.Run(codec, func(t *testing.T) {
+			backend := newCompressedBackend(t, t.TempDir(), codec)
+			ctx := context.Background()
+
+			if err := backend.Upload(ctx, "docs/fox.txt", strings.NewReader(content)); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+			if got := readObject(t, backend, "docs/fox.txt"); got != content {
+				t.Fatalf("round trip mismatch: got %d bytes", len(got))
+			}
+
+			meta, err := backend.GetObjectMeta(ctx, "docs/fox.txt")
+			if err != nil {
+				t.Fatalf("get meta: %v", err)
+			}
+			if meta.Size != int64(len(content)) {
+				t.Fatalf("expected original size %d, got %d", len(content), meta.Size)
+			}
+			if !strings.HasPrefix(meta.ContentType, "text/plain") {
+				t.Fatalf("expected text/plain content type, got %q", meta.ContentType)
+			}
+
+			path, _ := backend.objectPath("docs/fox.txt")
+			info, err := os.Stat(path)
+			if err != nil {
+				t.Fatalf("stat: %v", err)
+			}
+			if codec != CodecNone && info.Size() >= int64(len(content)) {
+				t.Fatalf("expected stored object to be compressed, got %d bytes", info.Size())
+			}
+			if _, err := os.Stat(sidecarPath(path)); err != nil {
+				t.Fatalf("unexpected sidecar state for codec %s: %v", codec, err)
+			}
+		})
+	}
+}
+
+func TestFSBackend_CompressionCodecFollowsObject(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	gz := newCompressedBackend(t, dir, CodecGzip)
+	if err := gz.Upload(ctx, "a", strings.NewReader("written with gzip")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	// A backend with a different default still decodes existing objects
+	zs := newCompressedBackend(t, dir, CodecZstd)
+	if got := readObject(t, zs, "a"); got != "written with gzip" {
+		t.Fatalf("unexpected content %q", got)
+	}
+
+	// Rewriting without compression drops the codec from the sidecar
+	plain := newCompressedBackend(t, dir, "")
+	if err := plain.Upload(ctx, "a", strings.NewReader("plain")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if got := readObject(t, zs, "a"); got != "plain" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
+
+func TestFSBackend_CompressionEnabledLater(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	plain := newCompressedBackend(t, dir, "")
+	if err := plain.Upload(ctx, "logs/old.json", strings.NewReader(`{"written":"before"}`)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	// Objects stored before compression was enabled have no codec recorded
+	// and are read and sized as stored
+	gz := newCompressedBackend(t, dir, CodecGzip)
+	if got := readObject(t, gz, "logs/old.json"); got != `{"written":"before"}` {
+		t.Fatalf("unexpected content %q", got)
+	}
+	meta, err := gz.GetObjectMeta(ctx, "logs/old.json")
+	if err != nil || meta.Size != 20 {
+		t.Fatalf("expected stored size of uncompressed object, got %+v, %v", meta, err)
+	}
+}
+
+func TestFSBackend_CompressionCopyAndRange(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecZstd)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "src", strings.NewReader("0123456789")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Copy(ctx, "src", "copy"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if got := readObject(t, backend, "copy"); got != "0123456789" {
+		t.Fatalf("unexpected copy %q", got)
+	}
+	if err := backend.CopyRange(ctx, "src", 3, 4, "range"); err != nil {
+		t.Fatalf("copy range: %v", err)
+	}
+	if got := readObject(t, backend, "range"); got != "3456" {
+		t.Fatalf("unexpected range %q", got)
+	}
+
+	wt, size, err := backend.OpenWriterTo(ctx, "src")
+	if err != nil {
+		t.Fatalf("open writer to: %v", err)
+	}
+	var buf bytes.Buffer
+	if _, err := wt.WriteTo(&buf); err != nil {
+		t.Fatalf("write to: %v", err)
+	}
+	if size != 10 || buf.String() != "0123456789" {
+		t.Fatalf("unexpected writer to result %d %q", size, buf.String())
+	}
+
+	if err := backend.PatchRange(ctx, "src", 0, []byte("x")); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("expected ErrUnsupported patching compressed object, got %v", err)
+	}
+}
+
+type reverseCodec struct{}
+
+func (reverseCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return &reverseWriter{w: w}, nil
+}
+
+func (reverseCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(reverse(data))), nil
+}
+
+type reverseWriter struct {
+	w   io.Writer
+	buf bytes.Buffer
+}
+
+func (rw *reverseWriter) Write(p []byte) (int, error) { return rw.buf.Write(p) }
+
+func (rw *reverseWriter) Close() error {
+	_, err := rw.w.Write(reverse(rw.buf.Bytes()))
+	return err
+}
+
+func reverse(data []byte) []byte {
+	out := make([]byte, len(data))
+	for i, c := range data {
+		out[len(data)-1-i] = c
+	}
+	return out
+}
+
+func TestFSBackend_RegisterCodec(t *testing.T) {
+	if _, err := New(Config{BaseDir: t.TempDir(), Compression: "reverse-test"}); err == nil {
+		t.Fatalf("expected unknown codec to be rejected")
+	}
+
+	RegisterCodec("reverse-test", reverseCodec{})
+	backend := newCompressedBackend(t, t.TempDir(), "reverse-test")
+	if err := backend.Upload(context.Background(), "k", strings.NewReader("abc")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	path, _ := backend.objectPath("k")
+	if stored, _ := os.ReadFile(path); string(stored) != "cba" {
+		t.Fatalf("expected custom codec to encode object, got %q", stored)
+	}
+	if got := readObject(t, backend, "k"); got != "abc" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
+
+// benchmarkPayload approximates the JSON log and document text workloads the
+// compression option is aimed at
+func benchmarkPayload() []byte {
+	var buf bytes.Buffer
+	for i := 0; buf.Len() < 4<<20; i++ {
+		fmt.Fprintf(&buf, `{"ts":"2024-05-01T12:%02d:%02d Z","level":"info","request_id":"req-%08x","path":"/api/v1/contents/%d","status":%d,"latency_ms":%d,"msg":"served content download"}`+"\n",
+			i/60%60, i%60, i*2654435761, i%5000, 200+(i%7)*50, i%250)
+	}
+	return buf.Bytes()
+}
+
+func BenchmarkCodecs(b *testing.B) {
+	payload := benchmarkPayload()
+
+	for _, codec := range []string{CodecGzip, CodecZstd} {
+		b.Run(codec+"/upload", func(b *testing.B) {
+			backend := newCompressedBackend(b, b.TempDir(), codec)
+			ctx := context.Background()
+			b.SetBytes(int64(len(payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if err := backend.Upload(ctx, "bench", bytes.NewReader(payload)); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			path, _ := backend.objectPath("bench")
+			if info, err := os.Stat(path); err == nil {
+				b.ReportMetric(float64(len(payload))/float64(info.Size()), "ratio")
+			}
+		})
+
+		b.Run(codec+"/download", func(b *testing.B) {
+			backend := newCompressedBackend(b, b.TempDir(), codec)
+			ctx := context.Background()
+			if err := backend.Upload(ctx, "bench", bytes.NewReader(payload)); err != nil {
+				b.Fatal(err)
+			}
+			b.SetBytes(int64(len(payload)))
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				rc, err := backend.Download(ctx, "bench")
+				if err != nil {
+					b.Fatal(err)
+				}
+				if _, err := io.Copy(io.Discard, rc); err != nil {
+					b.Fatal(err)
+				}
+				rc.Close()
+			}
+		})
+	}
+}
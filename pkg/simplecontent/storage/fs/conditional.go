@@ -0,0 +1,65 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// DeleteIfMatch deletes the object at objectKey only if its current ETag, as
+// reported by GetObjectMeta, is etag (quoted or not). Otherwise it returns
+// simplecontent.ErrPreconditionFailed and leaves the object in place.
+//
+// The object is first moved aside by rename and its ETag checked again, so
+// a replacement committed between the check and the delete is restored
+// rather than lost.
+func (b *Backend) DeleteIfMatch(ctx context.Context, objectKey, etag string) (err error) {
+	defer wrapError(&err, "delete_if_match", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return err
+	}
+	etag = strings.Trim(etag, `"`)
+
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return simplecontent.ErrObjectNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	if fileETag(info) != etag {
+		return simplecontent.ErrPreconditionFailed
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	aside := tempName(filePath)
+	if err := os.Rename(filePath, aside); os.IsNotExist(err) {
+		return simplecontent.ErrPreconditionFailed
+	} else if err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	if moved, err := os.Stat(aside); err != nil || !os.SameFile(info, moved) || fileETag(moved) != etag {
+		// Replaced since the check: put the newer object back unless an
+		// even newer one has been committed meanwhile
+		if err := os.Link(aside, filePath); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to restore file: %w", err)
+		}
+		os.Remove(aside)
+		return simplecontent.ErrPreconditionFailed
+	}
+
+	if err := os.Remove(aside); err != nil {
+		return fmt.Errorf("failed to delete file: %w", err)
+	}
+	return b.removeCompanions(objectKey, filePath)
+}
@@ -0,0 +1,55 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_DeleteIfMatch(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "config/app.json", strings.NewReader(`{"v":1}`)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	meta, err := backend.GetObjectMeta(ctx, "config/app.json")
+	if err != nil {
+		t.Fatalf("meta: %v", err)
+	}
+
+	// A concurrent edit changes the ETag
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(mustObjectPath(t, backend, "config/app.json"), future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := backend.DeleteIfMatch(ctx, "config/app.json", meta.ETag); !errors.Is(err, simplecontent.ErrPreconditionFailed) {
+		t.Fatalf("expected ErrPreconditionFailed, got %v", err)
+	}
+	if got := readObject(t, backend, "config/app.json"); got != `{"v":1}` {
+		t.Fatalf("expected object kept, got %q", got)
+	}
+
+	meta, err = backend.GetObjectMeta(ctx, "config/app.json")
+	if err != nil {
+		t.Fatalf("meta: %v", err)
+	}
+	if err := backend.DeleteIfMatch(ctx, "config/app.json", `"`+meta.ETag+`"`); err != nil {
+		t.Fatalf("delete if match: %v", err)
+	}
+	if _, err := backend.GetObjectMeta(ctx, "config/app.json"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected object deleted, got %v", err)
+	}
+	if _, err := os.Stat(backend.baseDir + "/config"); !os.IsNotExist(err) {
+		t.Fatalf("expected empty directory removed")
+	}
+
+	if err := backend.DeleteIfMatch(ctx, "config/app.json", meta.ETag); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,192 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// Copy duplicates the object stored at srcKey to dstKey, replacing any
+// existing object at dstKey. An alias at srcKey copies the object it refers
+// to.
+//
+// When PreferHardlink is enabled and both keys live on the same filesystem,
+// the copy is made with os.Link so no bytes are rewritten. Both keys then
+// share a single inode: an in-place edit of one is visible through the
+// other, so callers must treat hardlinked copies as immutable and only
+// replace them through the atomic rewrite path (Upload). Copy falls back to
+// a byte copy across devices or when hardlinks are unsupported.
+//
+// Compressed objects are copied as stored and keep their codec. Packed
+// objects are decoded and written like an upload.
+func (b *Backend) Copy(ctx context.Context, srcKey, dstKey string) (err error) {
+	defer wrapError(&err, "copy", srcKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	srcPath, err := b.objectPath(srcKey)
+	if err != nil {
+		return err
+	}
+	dstPath, err := b.objectPath(dstKey)
+	if err != nil {
+		return err
+	}
+
+	// A source without a file or pack entry of its own may be an alias
+	key := srcKey
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		if entry, err := b.packed(srcPath); err != nil {
+			return err
+		} else if entry == nil {
+			if key, srcPath, err = b.resolveAlias(srcKey); err != nil {
+				return err
+			}
+		}
+	} else if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	if err := b.mkdirAll(filepath.Dir(dstPath)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	// Lock the source too, so the bytes copied and the sidecar recorded for
+	// them are from the same upload
+	defer b.keyLocks.lockPair(srcPath, dstPath)()
+
+	if _, err := os.Stat(srcPath); os.IsNotExist(err) {
+		return b.copyPacked(ctx, key, srcPath, dstKey, dstPath)
+	} else if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	sc, err := b.loadSidecar(srcPath)
+	if err != nil {
+		return err
+	}
+	if sc.reserved() {
+		return simplecontent.ErrObjectNotFound
+	}
+	copied := sidecar{SHA256: sc.SHA256, ContentType: sc.ContentType, Metadata: sc.Metadata, Codec: sc.Codec, Size: sc.Size}
+
+	if b.preferHardlink {
+		if tmp, err := linkTemp(srcPath, dstPath); err == nil {
+			return b.replaceCopy(tmp, dstPath, copied)
+		}
+		// Cross-device or unsupported: fall through to a byte copy
+	}
+
+	tmp, err := copyTemp(ctx, srcPath, dstPath, b.fileMode)
+	if err != nil {
+		return err
+	}
+	if b.syncOnWrite {
+		if err := syncPath(tmp); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return b.replaceCopy(tmp, dstPath, copied)
+}
+
+// replaceCopy renames tmp, a copy or link of the source, over dstPath and
+// records copied as its sidecar
+func (b *Backend) replaceCopy(tmp, dstPath string, copied sidecar) error {
+	err := b.recordWrite(dstPath, tmp, copied, func() error {
+		return replaceFile(tmp, dstPath, b.busyTimeout)
+	})
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
+
+// copyPacked writes the decoded content of the packed object srcKey to
+// dstPath with its content type and metadata. The caller holds the lock of
+// dstPath.
+func (b *Backend) copyPacked(ctx context.Context, srcKey, srcPath, dstKey, dstPath string) error {
+	obj, err := b.downloadPacked(ctx, srcKey, srcPath)
+	if err != nil {
+		return err
+	} else if obj == nil {
+		return simplecontent.ErrObjectNotFound
+	}
+	defer obj.rc.Close()
+
+	params := simplecontent.UploadParams{ObjectKey: dstKey, MimeType: obj.sc.ContentType}
+	staged, err := b.stageObject(dstPath, contextReader{ctx: ctx, r: obj.rc}, params, nil)
+	if err != nil {
+		return err
+	}
+	staged.written.Metadata = obj.sc.Metadata
+	return b.replaceCopy(staged.tmpPath, dstPath, staged.written)
+}
+
+// linkTemp hardlinks src to a temporary name next to dst, which can be
+// renamed into place to replace an existing dst atomically
+func linkTemp(src, dst string) (string, error) {
+	tmp := tempName(dst)
+	if err := os.Link(src, tmp); err != nil {
+		return "", err
+	}
+	return tmp, nil
+}
+
+// copyTemp copies src to a temporary file next to dst
+func copyTemp(ctx context.Context, src, dst string, mode os.FileMode) (string, error) {
+	in, err := openObject(src)
+	if err != nil {
+		return "", fmt.Errorf("failed to open file: %w", err)
+	}
+	defer in.Close()
+
+	return writeTemp(ctx, dst, in, mode)
+}
+
+// CopyRange writes length bytes of srcKey starting at offset into a new
+// object at dstKey. A negative length copies to the end of the source.
+// Ranges outside the source return simplecontent.ErrRangeNotSatisfiable.
+// The source is read like Download reads it, following aliases and packs.
+// The destination is written atomically with the source's content type and
+// compressed with the configured codec, regardless of how the source is
+// stored.
+func (b *Backend) CopyRange(ctx context.Context, srcKey string, offset, length int64, dstKey string) (err error) {
+	defer wrapError(&err, "copy_range", srcKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	dstPath, err := b.objectPath(dstKey)
+	if err != nil {
+		return err
+	}
+	src, err := b.openResolved(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	defer src.rc.Close()
+
+	if length < 0 {
+		length = src.size - offset
+	}
+	if offset < 0 || offset > src.size || length < 0 || offset+length > src.size {
+		return simplecontent.ErrRangeNotSatisfiable
+	}
+
+	// Compressed sources are not seekable: decode and skip to offset
+	if seeker, ok := src.rc.(io.Seeker); ok {
+		if _, err := seeker.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("failed to seek file: %w", err)
+		}
+	} else if _, err := io.CopyN(io.Discard, src.rc, offset); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	params := simplecontent.UploadParams{ObjectKey: dstKey, MimeType: src.sc.ContentType}
+	return b.writeObject(ctx, dstPath, io.LimitReader(src.rc, length), params, nil)
+}
@@ -0,0 +1,171 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Copy(t *testing.T) {
+	for _, hardlink := range []bool{false, true} {
+		tmp := t.TempDir()
+		b, err := New(Config{BaseDir: tmp, PreferHardlink: hardlink})
+		if err != nil {
+			t.Fatalf("new fs backend: %v", err)
+		}
+		backend := b.(*Backend)
+		ctx := context.Background()
+
+		if err := backend.Upload(ctx, "src/a.txt", bytes.NewReader([]byte("copy me"))); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		if err := backend.Upload(ctx, "dst/b.txt", bytes.NewReader([]byte("old"))); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		if err := backend.Copy(ctx, "src/a.txt", "dst/b.txt"); err != nil {
+			t.Fatalf("copy (hardlink=%v): %v", hardlink, err)
+		}
+
+		rc, err := backend.Download(ctx, "dst/b.txt")
+		if err != nil {
+			t.Fatalf("download: %v", err)
+		}
+		got, _ := io.ReadAll(rc)
+		_ = rc.Close()
+		if string(got) != "copy me" {
+			t.Fatalf("copy mismatch (hardlink=%v): %q", hardlink, string(got))
+		}
+
+		srcInfo, _ := os.Stat(filepath.Join(tmp, "src/a.txt"))
+		dstInfo, _ := os.Stat(filepath.Join(tmp, "dst/b.txt"))
+		if hardlink != os.SameFile(srcInfo, dstInfo) {
+			t.Fatalf("expected shared inode=%v", hardlink)
+		}
+	}
+}
+
+func TestFSBackend_CopyMissingSource(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	err = b.(*Backend).Copy(context.Background(), "missing", "dst")
+	if !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func TestFSBackend_CopyRange(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "video/full", bytes.NewReader([]byte("0123456789"))); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if err := backend.CopyRange(ctx, "video/full", 2, 5, "video/clip"); err != nil {
+		t.Fatalf("copy range: %v", err)
+	}
+	rc, err := backend.Download(ctx, "video/clip")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(got) != "23456" {
+		t.Fatalf("unexpected clip %q", string(got))
+	}
+
+	if err := backend.CopyRange(ctx, "video/full", 7, -1, "video/tail"); err != nil {
+		t.Fatalf("copy range to end: %v", err)
+	}
+
+	for _, r := range [][2]int64{{-1, 2}, {8, 5}, {11, 0}} {
+		err := backend.CopyRange(ctx, "video/full", r[0], r[1], "video/bad")
+		if !errors.Is(err, simplecontent.ErrRangeNotSatisfiable) {
+			t.Fatalf("range %v: expected ErrRangeNotSatisfiable, got %v", r, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(backend.baseDir, "video/bad")); !os.IsNotExist(err) {
+		t.Fatalf("expected no destination written for invalid range")
+	}
+}
+
+func TestFSBackend_CopyAliasedAndPacked(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	params := simplecontent.UploadParams{ObjectKey: "packed/a", MimeType: "text/csv", Metadata: map[string]string{"origin": "a"}}
+	if err := backend.UploadWithParams(ctx, bytes.NewReader([]byte("0123456789")), params); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	backend.packAge = 0
+	if err := backend.Compact(ctx, "packed/"); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if err := backend.CreateAlias(ctx, "alias", "packed/a"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+
+	for _, src := range []string{"packed/a", "alias"} {
+		dst := "copy-of-" + src[:1]
+		if err := backend.Copy(ctx, src, dst); err != nil {
+			t.Fatalf("copy %s: %v", src, err)
+		}
+		if got := readObject(t, backend, dst); got != "0123456789" {
+			t.Fatalf("copy %s: unexpected content %q", src, got)
+		}
+		meta, err := backend.GetObjectMeta(ctx, dst)
+		if err != nil {
+			t.Fatalf("get meta: %v", err)
+		}
+		if meta.ContentType != "text/csv" || meta.Metadata["origin"] != "a" {
+			t.Fatalf("copy %s: expected content type and metadata carried over, got %+v", src, meta)
+		}
+
+		if err := backend.CopyRange(ctx, src, 2, 5, "range-of-"+src[:1]); err != nil {
+			t.Fatalf("copy range %s: %v", src, err)
+		}
+		if got := readObject(t, backend, "range-of-"+src[:1]); got != "23456" {
+			t.Fatalf("copy range %s: unexpected content %q", src, got)
+		}
+	}
+}
+
+func TestFSBackend_CopyRangeContentType(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	params := simplecontent.UploadParams{ObjectKey: "log", MimeType: "text/csv"}
+	if err := backend.UploadWithParams(ctx, bytes.NewReader([]byte("a,b\n1,2\n")), params); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.CopyRange(ctx, "log", 0, 4, "head"); err != nil {
+		t.Fatalf("copy range: %v", err)
+	}
+	meta, err := backend.GetObjectMeta(ctx, "head")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+	if meta.ContentType != "text/csv" {
+		t.Fatalf("expected the source content type, got %q", meta.ContentType)
+	}
+}
@@ -0,0 +1,106 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// counterLockSuffix is appended to a counter's path to name the lock file
+// serialising its increments, which is apart from the lease lock so a
+// lease on the counter does not block them
+const counterLockSuffix = ".counter-lock"
+
+// counterLockTTL bounds how long a crashed incrementer can block a counter.
+// A live incrementer renews its lock well before then.
+const counterLockTTL = 10 * time.Second
+
+// IncrementCounter adds delta to the integer stored at objectKey and returns
+// the new value. A missing counter starts at zero. The object holds the value
+// as decimal text, so it can also be read with Download; writing anything
+// else to it makes IncrementCounter fail.
+//
+// Increments are serialised by a <key>.counter-lock file, so they are atomic
+// across goroutines and processes sharing BaseDir. A caller blocked by a
+// concurrent increment waits until the lock is free or ctx is done. Leases
+// taken with AcquireLease do not block increments.
+func (b *Backend) IncrementCounter(ctx context.Context, objectKey string, delta int64) (_ int64, err error) {
+	defer wrapError(&err, "increment_counter", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return 0, err
+	}
+	if err := b.mkdirAll(filepath.Dir(filePath)); err != nil {
+		return 0, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	lockPath := filePath + counterLockSuffix
+	defer b.keyLocks.lock(lockPath)()
+	leaseID, err := b.waitLock(ctx, lockPath)
+	if err != nil {
+		return 0, err
+	}
+	defer releaseLock(lockPath, leaseID)
+	defer renewLock(lockPath, leaseID, counterLockTTL)()
+
+	value, err := b.readCounter(ctx, objectKey)
+	if err != nil {
+		return 0, err
+	}
+	value += delta
+	if err := b.writeObject(ctx, filePath, strings.NewReader(strconv.FormatInt(value, 10)), simplecontent.UploadParams{ObjectKey: objectKey, MimeType: "text/plain"}, nil); err != nil {
+		return 0, err
+	}
+	return value, nil
+}
+
+// waitLock acquires lockPath, retrying while another holder has it
+func (b *Backend) waitLock(ctx context.Context, lockPath string) (string, error) {
+	delay := 5 * time.Millisecond
+	for {
+		id, err := acquireLock(ctx, lockPath, counterLockTTL)
+		if !errors.Is(err, simplecontent.ErrLeaseHeld) {
+			return id, err
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, 100*time.Millisecond)
+	}
+}
+
+// readCounter returns the value of a counter object, or zero if it does not
+// exist
+func (b *Backend) readCounter(ctx context.Context, objectKey string) (int64, error) {
+	rc, err := b.download(ctx, objectKey)
+	if errors.Is(err, simplecontent.ErrObjectNotFound) {
+		return 0, nil
+	} else if err != nil {
+		return 0, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, 32))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read counter: %w", err)
+	}
+	value, err := strconv.ParseInt(strings.TrimSpace(string(data)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("object is not a counter: %w", err)
+	}
+	return value, nil
+}
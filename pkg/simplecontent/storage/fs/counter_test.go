@@ -0,0 +1,112 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_IncrementCounter(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	// Two backends stand in for separate processes sharing the directory
+	backends := []*Backend{newCompressedBackend(t, dir, ""), newCompressedBackend(t, dir, CodecGzip)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(b *Backend) {
+			defer wg.Done()
+			if _, err := b.IncrementCounter(ctx, "views/item-1", 1); err != nil {
+				t.Errorf("increment: %v", err)
+			}
+		}(backends[i%2])
+	}
+	wg.Wait()
+
+	value, err := backends[0].IncrementCounter(ctx, "views/item-1", -5)
+	if err != nil || value != 15 {
+		t.Fatalf("expected 15 after concurrent increments, got %d: %v", value, err)
+	}
+	if got := readObject(t, backends[1], "views/item-1"); got != "15" {
+		t.Fatalf("expected counter readable as text, got %q", got)
+	}
+	if keys := listKeys(t, backends[0], ""); len(keys) != 1 {
+		t.Fatalf("expected lock files excluded from listing, got %v", keys)
+	}
+
+	if err := backends[0].Upload(ctx, "not-a-counter", strings.NewReader("hello")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if _, err := backends[0].IncrementCounter(ctx, "not-a-counter", 1); err == nil {
+		t.Fatalf("expected error incrementing non-numeric object")
+	}
+}
+
+func TestFSBackend_IncrementCounterWaitsForLock(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	lockPath := mustObjectPath(t, backend, "c") + counterLockSuffix
+	id, err := acquireLock(ctx, lockPath, time.Minute)
+	if err != nil {
+		t.Fatalf("acquire lock: %v", err)
+	}
+
+	timeout, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	if _, err := backend.IncrementCounter(timeout, "c", 1); err == nil {
+		t.Fatalf("expected increment to wait for the held lock")
+	}
+
+	releaseLock(lockPath, id)
+	if value, err := backend.IncrementCounter(ctx, "c", 1); err != nil || value != 1 {
+		t.Fatalf("expected 1 after lock released, got %d: %v", value, err)
+	}
+}
+
+func TestFSBackend_IncrementCounterIgnoresLeases(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if _, err := backend.IncrementCounter(ctx, "c", 1); err != nil {
+		t.Fatalf("increment: %v", err)
+	}
+	if _, err := backend.AcquireLease(ctx, "c", time.Minute); err != nil {
+		t.Fatalf("acquire lease: %v", err)
+	}
+	timeout, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	if value, err := backend.IncrementCounter(timeout, "c", 1); err != nil || value != 2 {
+		t.Fatalf("expected a lease not to block increments, got %d: %v", value, err)
+	}
+}
+
+func TestRenewLock(t *testing.T) {
+	ctx := context.Background()
+	lockPath := filepath.Join(t.TempDir(), "c"+counterLockSuffix)
+	ttl := 30 * time.Millisecond
+
+	id, err := acquireLock(ctx, lockPath, ttl)
+	if err != nil {
+		t.Fatalf("acquire lock: %v", err)
+	}
+	stop := renewLock(lockPath, id, ttl)
+	time.Sleep(4 * ttl)
+	if _, err := acquireLock(ctx, lockPath, ttl); !errors.Is(err, simplecontent.ErrLeaseHeld) {
+		t.Fatalf("expected a renewed lock to stay held, got %v", err)
+	}
+	stop()
+
+	time.Sleep(2 * ttl)
+	if _, err := acquireLock(ctx, lockPath, ttl); err != nil {
+		t.Fatalf("expected a lock no longer renewed to expire, got %v", err)
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !unix && !windows
+
+package fs
+
+// isCrossDevice reports that renames are never known to fail for crossing
+// filesystems on this platform
+func isCrossDevice(err error) bool {
+	return false
+}
@@ -0,0 +1,14 @@
+//go:build unix
+
+package fs
+
+import (
+	"errors"
+	"syscall"
+)
+
+// isCrossDevice reports whether err is a rename failing because source and
+// destination are on different filesystems
+func isCrossDevice(err error) bool {
+	return errors.Is(err, syscall.EXDEV)
+}
@@ -0,0 +1,21 @@
+//go:build unix
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestIsCrossDevice(t *testing.T) {
+	if !isCrossDevice(&os.LinkError{Op: "rename", Old: "a", New: "b", Err: syscall.EXDEV}) {
+		t.Fatal("expected EXDEV from a rename to be cross-device")
+	}
+	for _, err := range []error{nil, os.ErrNotExist, errors.New("other")} {
+		if isCrossDevice(err) {
+			t.Fatalf("expected %v not to be cross-device", err)
+		}
+	}
+}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package fs
+
+import (
+	"errors"
+	"syscall"
+)
+
+// errorNotSameDevice is ERROR_NOT_SAME_DEVICE, which a rename to another
+// volume fails with
+const errorNotSameDevice syscall.Errno = 17
+
+// isCrossDevice reports whether err is a rename failing because source and
+// destination are on different volumes
+func isCrossDevice(err error) bool {
+	return errors.Is(err, errorNotSameDevice)
+}
@@ -0,0 +1,13 @@
+//go:build !linux && !darwin && !freebsd && !netbsd
+
+package fs
+
+import (
+	"os"
+	"time"
+)
+
+// changeTime is unavailable on this platform; callers fall back to mtime
+func changeTime(info os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
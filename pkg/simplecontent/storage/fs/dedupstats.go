@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"context"
+	"os"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// DedupStats reports how much space sharing stored files saves for the
+// objects whose key starts with prefix. Objects copied with PreferHardlink
+// share one file; such a file is a single blob referenced by several keys.
+//
+// logicalBytes sums the size of every object and physicalBytes the stored
+// size of every distinct blob, so their difference is the space saved.
+// uniqueBlobs counts the distinct blobs and totalRefs the objects. Sharing is
+// detected from file identity (device and inode), without reading content;
+// on platforms where that is unavailable every object counts as its own blob.
+func (b *Backend) DedupStats(ctx context.Context, prefix string) (logicalBytes, physicalBytes int64, uniqueBlobs, totalRefs int, err error) {
+	defer wrapError(&err, "dedup_stats", prefix)
+
+	seen := make(map[fileID]bool)
+	err = b.walkObjects(ctx, prefix, func(filePath string, meta simplecontent.ObjectMeta, _ *sidecar) error {
+		info, err := os.Stat(filePath)
+		if os.IsNotExist(err) {
+			// Deleted while walking
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		totalRefs++
+		logicalBytes += meta.Size
+		if id, ok := fileIDOf(info); ok {
+			if seen[id] {
+				return nil
+			}
+			seen[id] = true
+		}
+		uniqueBlobs++
+		physicalBytes += info.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	return logicalBytes, physicalBytes, uniqueBlobs, totalRefs, nil
+}
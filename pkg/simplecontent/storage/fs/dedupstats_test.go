@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"context"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_DedupStats(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file identity is not available on windows")
+	}
+	b, err := New(Config{BaseDir: t.TempDir(), PreferHardlink: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "lib/shared.bin", strings.NewReader(strings.Repeat("s", 1000))); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Upload(ctx, "lib/unique.bin", strings.NewReader(strings.Repeat("u", 300))); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	for _, dst := range []string{"lib/copy1.bin", "lib/copy2.bin", "elsewhere/copy3.bin"} {
+		if err := backend.Copy(ctx, "lib/shared.bin", dst); err != nil {
+			t.Fatalf("copy: %v", err)
+		}
+	}
+
+	logical, physical, blobs, refs, err := backend.DedupStats(ctx, "")
+	if err != nil {
+		t.Fatalf("dedup stats: %v", err)
+	}
+	if logical != 4300 || physical != 1300 || blobs != 2 || refs != 5 {
+		t.Fatalf("unexpected stats logical=%d physical=%d blobs=%d refs=%d", logical, physical, blobs, refs)
+	}
+
+	logical, physical, blobs, refs, err = backend.DedupStats(ctx, "lib/")
+	if err != nil {
+		t.Fatalf("dedup stats: %v", err)
+	}
+	if logical != 3300 || physical != 1300 || blobs != 2 || refs != 4 {
+		t.Fatalf("unexpected prefix stats logical=%d physical=%d blobs=%d refs=%d", logical, physical, blobs, refs)
+	}
+}
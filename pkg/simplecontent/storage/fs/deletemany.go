@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// DeleteMany deletes every key like Delete, carrying on past keys that
+// fail. The returned map holds the error of each key that was not deleted,
+// e.g. simplecontent.ErrObjectNotFound or a permission error. Directories
+// left empty are removed once, after all deletes. The error is only non-nil
+// when ctx is cancelled; keys not attempted by then are recorded with the
+// context's error.
+func (b *Backend) DeleteMany(ctx context.Context, keys []string) (_ map[string]error, err error) {
+	defer b.observe(ctx, "delete_many", "", time.Now(), &err)
+
+	if err := b.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	failed := make(map[string]error)
+	dirs := make(map[string]struct{})
+	for i, key := range keys {
+		if err := ctx.Err(); err != nil {
+			for _, key := range keys[i:] {
+				failed[key] = err
+			}
+			return failed, err
+		}
+		dir, err := b.deleteOne(key)
+		if err != nil {
+			wrapError(&err, "delete", key)
+			failed[key] = err
+			continue
+		}
+		if dir != "" {
+			dirs[dir] = struct{}{}
+		}
+	}
+
+	// Clean up the deepest directories first, so their parents are seen
+	// empty once they are gone
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	slices.SortFunc(sorted, func(a, b string) int { return len(b) - len(a) })
+	for _, dir := range sorted {
+		b.cleanupEmptyDirectories(dir)
+	}
+	return failed, nil
+}
+
+// deleteOne deletes an object, or the alias at objectKey, without cleaning
+// up directories, and returns the directory the object was removed from
+func (b *Backend) deleteOne(objectKey string) (string, error) {
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return "", err
+	}
+	defer b.keyLocks.lock(filePath)()
+
+	if err := os.Remove(filePath); os.IsNotExist(err) {
+		if removed, err := b.removeAlias(objectKey); err != nil || removed {
+			return "", err
+		}
+		return "", simplecontent.ErrObjectNotFound
+	} else if err != nil {
+		return "", fmt.Errorf("failed to delete file: %w", err)
+	}
+	if err := b.removeAttached(objectKey, filePath); err != nil {
+		return "", err
+	}
+	return filepath.Dir(filePath), nil
+}
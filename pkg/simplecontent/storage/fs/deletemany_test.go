@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_DeleteMany(t *testing.T) {
+	dir := t.TempDir()
+	backend := newCompressedBackend(t, dir, "")
+	ctx := context.Background()
+
+	keys := []string{"old/2023/a", "old/2023/b", "old/2024/c", "keep/d"}
+	for _, key := range keys {
+		if err := backend.Upload(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+
+	failed, err := backend.DeleteMany(ctx, []string{"old/2023/a", "old/missing", "old/2023/b", "../escape", "old/2024/c"})
+	if err != nil {
+		t.Fatalf("delete many: %v", err)
+	}
+	if len(failed) != 2 {
+		t.Fatalf("expected 2 failures, got %v", failed)
+	}
+	if !errors.Is(failed["old/missing"], simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound for a missing key, got %v", failed["old/missing"])
+	}
+	if !errors.Is(failed["../escape"], simplecontent.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", failed["../escape"])
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "old")); !os.IsNotExist(err) {
+		t.Fatalf("expected emptied directories to be removed, got %v", err)
+	}
+	if got := readObject(t, backend, "keep/d"); got != "keep/d" {
+		t.Fatalf("unexpected content of kept object: %q", got)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	failed, err = backend.DeleteMany(cancelled, []string{"keep/d"})
+	if !errors.Is(err, context.Canceled) || !errors.Is(failed["keep/d"], context.Canceled) {
+		t.Fatalf("expected cancellation to be reported, got %v, %v", failed, err)
+	}
+}
@@ -0,0 +1,119 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// UploadWithDerived stores an original object together with variants derived
+// from it (such as thumbnails) as a unit.
+//
+// The original is staged first; derive then reads it back from the staged
+// file and returns the variants keyed by object key. Every variant is staged
+// as well, and only once all of them are complete are they committed by
+// rename: variants first and the original last, so the original is never
+// visible without its variants. If staging or derive fails, nothing is
+// published. A failure while committing is reported after the remaining
+// staged files are discarded; variants already committed are left in place.
+// OnKeyCollision applies to the original and each variant as it does to
+// Upload; the returned metadata carries the key the original was committed
+// under.
+func (b *Backend) UploadWithDerived(ctx context.Context, key string, reader io.Reader, derive func(orig io.Reader) (map[string]io.Reader, error)) (_ *simplecontent.ObjectMeta, err error) {
+	defer wrapError(&err, "upload_with_derived", key)
+
+	if err := b.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	filePath, err := b.prepareWrite(key, b.onCollision)
+	if err != nil {
+		return nil, err
+	}
+	original, err := b.stageObject(filePath, reader, simplecontent.UploadParams{ObjectKey: key}, nil)
+	if err != nil {
+		return nil, err
+	}
+	staged := []*stagedObject{original}
+	keys := []string{key}
+	discardAll := func() {
+		for _, s := range staged {
+			s.discard()
+		}
+	}
+
+	variants, err := b.deriveStaged(original, derive)
+	if err != nil {
+		discardAll()
+		return nil, err
+	}
+
+	// Stage in key order so failures are deterministic
+	variantKeys := make([]string, 0, len(variants))
+	for k := range variants {
+		variantKeys = append(variantKeys, k)
+	}
+	sort.Strings(variantKeys)
+	for _, k := range variantKeys {
+		variantPath, err := b.prepareWrite(k, b.onCollision)
+		if err == nil && variantPath == filePath {
+			err = fmt.Errorf("derived key %q is the original key", k)
+		}
+		if err != nil {
+			discardAll()
+			return nil, err
+		}
+		s, err := b.stageObject(variantPath, variants[k], simplecontent.UploadParams{ObjectKey: k}, nil)
+		if c, ok := variants[k].(io.Closer); ok {
+			c.Close()
+		}
+		if err != nil {
+			discardAll()
+			return nil, fmt.Errorf("failed to stage derived object %s: %w", k, err)
+		}
+		staged = append(staged, s)
+		keys = append(keys, k)
+	}
+
+	if err := ctx.Err(); err != nil {
+		discardAll()
+		return nil, err
+	}
+
+	// Publish the variants, then the original
+	for i := len(staged) - 1; i >= 0; i-- {
+		committed, err := b.commitKey(staged[i], keys[i], b.onCollision)
+		if err != nil {
+			for _, s := range staged[:i] {
+				s.discard()
+			}
+			return nil, err
+		}
+		keys[i] = committed
+	}
+
+	return b.GetObjectMeta(ctx, keys[0])
+}
+
+// deriveStaged runs derive over the decoded content of a staged original
+func (b *Backend) deriveStaged(original *stagedObject, derive func(orig io.Reader) (map[string]io.Reader, error)) (map[string]io.Reader, error) {
+	file, err := os.Open(original.tmpPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open staged file: %w", err)
+	}
+	content, err := openDecoded(file, original.written.Codec)
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	variants, err := derive(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive variants: %w", err)
+	}
+	return variants, nil
+}
@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_UploadWithDerived(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	derive := func(orig io.Reader) (map[string]io.Reader, error) {
+		data, err := io.ReadAll(orig)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]io.Reader{
+			"images/photo_small.txt": strings.NewReader(strings.ToUpper(string(data))),
+			"images/photo_len.txt":   strings.NewReader(string(rune('0' + len(data)))),
+		}, nil
+	}
+
+	meta, err := backend.UploadWithDerived(ctx, "images/photo.txt", strings.NewReader("pixels"), derive)
+	if err != nil {
+		t.Fatalf("upload with derived: %v", err)
+	}
+	if meta.Key != "images/photo.txt" || meta.Size != 6 {
+		t.Fatalf("unexpected meta %+v", meta)
+	}
+	if got := readObject(t, backend, "images/photo_small.txt"); got != "PIXELS" {
+		t.Fatalf("unexpected derived content %q", got)
+	}
+	if got := readObject(t, backend, "images/photo_len.txt"); got != "6" {
+		t.Fatalf("unexpected derived content %q", got)
+	}
+}
+
+func TestFSBackend_UploadWithDerivedFailurePublishesNothing(t *testing.T) {
+	dir := t.TempDir()
+	backend := newCompressedBackend(t, dir, "")
+	ctx := context.Background()
+
+	failing := func(orig io.Reader) (map[string]io.Reader, error) {
+		return nil, errors.New("decoder crashed")
+	}
+	if _, err := backend.UploadWithDerived(ctx, "images/a.txt", strings.NewReader("a"), failing); err == nil {
+		t.Fatalf("expected derive error")
+	}
+
+	badKey := func(orig io.Reader) (map[string]io.Reader, error) {
+		return map[string]io.Reader{
+			"images/ok.txt":  strings.NewReader("ok"),
+			"../outside.txt": strings.NewReader("x"),
+		}, nil
+	}
+	if _, err := backend.UploadWithDerived(ctx, "images/b.txt", strings.NewReader("b"), badKey); err == nil {
+		t.Fatalf("expected invalid derived key error")
+	}
+
+	var files []string
+	filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if len(files) != 0 {
+		t.Fatalf("expected nothing published or left staged, found %v", files)
+	}
+}
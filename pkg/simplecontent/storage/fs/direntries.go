@@ -0,0 +1,90 @@
+package fs
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// dirCountTTL is how long a directory's entry count is trusted before it is
+// read again. In between, commits of new keys adjust the cached count.
+const dirCountTTL = time.Minute
+
+// dirCount is the cached number of entries in a directory
+type dirCount struct {
+	n       int
+	checked time.Time
+}
+
+// checkDirEntries enforces MaxDirEntries before a new object is written to
+// filePath. Objects and subdirectories count as entries; sidecars and other
+// internal files do not. Replacing an existing object never fails. To avoid
+// reading the directory on every upload, counts are cached for dirCountTTL
+// and are approximate in between.
+func (b *Backend) checkDirEntries(filePath string) error {
+	if b.maxDirEntries <= 0 {
+		return nil
+	}
+	if _, err := os.Lstat(filePath); err == nil {
+		return nil
+	}
+
+	dir := filepath.Dir(filePath)
+	b.dirMu.Lock()
+	defer b.dirMu.Unlock()
+
+	c := b.dirCounts[dir]
+	if c == nil || time.Since(c.checked) > dirCountTTL {
+		n, err := countDirEntries(dir)
+		if err != nil {
+			return err
+		}
+		c = &dirCount{n: n, checked: time.Now()}
+		b.dirCounts[dir] = c
+	}
+
+	if c.n >= b.maxDirEntries {
+		if !b.warnOnDirFull {
+			return fmt.Errorf("%w: %s has %d entries (limit %d); shard keys across more directories", simplecontent.ErrDirectoryFull, dir, c.n, b.maxDirEntries)
+		}
+		if b.logger != nil {
+			b.logger.Warn("fs directory over MaxDirEntries; shard keys across more directories",
+				slog.String("dir", dir), slog.Int("entries", c.n), slog.Int("limit", b.maxDirEntries))
+		}
+	}
+	return nil
+}
+
+// addDirEntry counts an object committed to filePath as a new entry in the
+// cached count of its directory
+func (b *Backend) addDirEntry(filePath string) {
+	if b.maxDirEntries <= 0 {
+		return
+	}
+	b.dirMu.Lock()
+	defer b.dirMu.Unlock()
+	if c := b.dirCounts[filepath.Dir(filePath)]; c != nil {
+		c.n++
+	}
+}
+
+// countDirEntries counts the objects and subdirectories in dir
+func countDirEntries(dir string) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	} else if err != nil {
+		return 0, fmt.Errorf("failed to read directory: %w", err)
+	}
+	n := 0
+	for _, entry := range entries {
+		if !isInternalFile(entry.Name()) {
+			n++
+		}
+	}
+	return n, nil
+}
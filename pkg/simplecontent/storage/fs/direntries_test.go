@@ -0,0 +1,99 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_MaxDirEntries(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), MaxDirEntries: 3})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if err := b.Upload(ctx, fmt.Sprintf("flat/%d", i), strings.NewReader("x")); err != nil {
+			t.Fatalf("upload %d: %v", i, err)
+		}
+	}
+	if err := b.Upload(ctx, "flat/3", strings.NewReader("x")); !errors.Is(err, simplecontent.ErrDirectoryFull) {
+		t.Fatalf("expected ErrDirectoryFull, got %v", err)
+	}
+
+	// Overwrites and other directories are unaffected
+	if err := b.Upload(ctx, "flat/0", strings.NewReader("y")); err != nil {
+		t.Fatalf("overwrite in full directory: %v", err)
+	}
+	if err := b.Upload(ctx, "sharded/00/3", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload to other directory: %v", err)
+	}
+}
+
+func TestFSBackend_MaxDirEntriesWarnOnly(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), MaxDirEntries: 1, WarnOnDirFull: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := b.Upload(context.Background(), fmt.Sprintf("flat/%d", i), strings.NewReader("x")); err != nil {
+			t.Fatalf("expected warning only, got %v", err)
+		}
+	}
+}
+
+func TestFSBackend_MaxDirEntriesCountsCommits(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), MaxDirEntries: 2})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	ctx := context.Background()
+
+	// Failed uploads and replacements leave the count alone
+	for i := 0; i < 3; i++ {
+		params := simplecontent.UploadParams{ObjectKey: "flat/a", SHA256: strings.Repeat("0", 64)}
+		if err := b.UploadWithParams(ctx, strings.NewReader("x"), params); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+			t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+		}
+	}
+	if err := b.Upload(ctx, "flat/a", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := b.Upload(ctx, "flat/a", strings.NewReader("y")); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if err := b.Upload(ctx, "flat/b", strings.NewReader("x")); err != nil {
+		t.Fatalf("expected room for a second entry, got %v", err)
+	}
+	if err := b.Upload(ctx, "flat/c", strings.NewReader("x")); !errors.Is(err, simplecontent.ErrDirectoryFull) {
+		t.Fatalf("expected ErrDirectoryFull, got %v", err)
+	}
+}
+
+func TestFSBackend_MaxDirEntriesWarnsToLogger(t *testing.T) {
+	var logs bytes.Buffer
+	b, err := New(Config{
+		BaseDir:       t.TempDir(),
+		MaxDirEntries: 1,
+		WarnOnDirFull: true,
+		Logger:        slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelWarn})),
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := b.Upload(context.Background(), fmt.Sprintf("flat/%d", i), strings.NewReader("x")); err != nil {
+			t.Fatalf("expected warning only, got %v", err)
+		}
+	}
+	if out := logs.String(); !strings.Contains(out, "level=WARN") || !strings.Contains(out, "entries=1") || !strings.Contains(out, "limit=1") {
+		t.Fatalf("expected a structured warning, got %q", out)
+	}
+}
@@ -0,0 +1,102 @@
+package fs
+
+import (
+	"compress/gzip"
+	"context"
+	"io"
+	"mime"
+	"strings"
+)
+
+// DownloadCompressed opens an object like Download, gzip-compressing it on
+// the fly when encoding is "gzip" and the object's content type is
+// compressible (text, JSON, XML, JavaScript and the like). It returns the
+// stream and the Content-Encoding to serve it with: "gzip" when compressed,
+// or "" for the plain stream, which is returned for other encodings and for
+// content that is already compressed, such as images, video and archives.
+//
+// Objects stored with gzip at-rest compression are returned as stored,
+// without decoding and re-encoding them.
+func (b *Backend) DownloadCompressed(ctx context.Context, objectKey string, encoding string) (_ io.ReadCloser, _ string, err error) {
+	defer wrapError(&err, "download_compressed", objectKey)
+
+	if !strings.EqualFold(encoding, "gzip") {
+		rc, err := b.Download(ctx, objectKey)
+		return rc, "", err
+	}
+
+	meta, err := b.GetObjectMeta(ctx, objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+	if !isCompressible(meta.ContentType) {
+		rc, err := b.Download(ctx, objectKey)
+		return rc, "", err
+	}
+
+	if b.extensionCodec(objectKey) == "" {
+		if rc, ok, err := b.openStoredGzip(ctx, objectKey); err != nil {
+			return nil, "", err
+		} else if ok {
+			return rc, "gzip", nil
+		}
+	}
+
+	rc, err := b.Download(ctx, objectKey)
+	if err != nil {
+		return nil, "", err
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		defer rc.Close()
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, rc)
+		if cerr := zw.Close(); err == nil {
+			err = cerr
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, "gzip", nil
+}
+
+// openStoredGzip opens the stored file of an object kept with gzip at-rest
+// compression, reporting false for objects stored any other way
+func (b *Backend) openStoredGzip(ctx context.Context, objectKey string) (io.ReadCloser, bool, error) {
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return nil, false, err
+	}
+	sc, err := b.loadSidecar(filePath)
+	if err != nil || sc.Codec != CodecGzip {
+		return nil, false, err
+	}
+	file, err := openObject(filePath)
+	if err != nil {
+		// Replaced or removed since its metadata was read: compress the
+		// current content instead
+		return nil, false, nil
+	}
+	return &objectFile{File: file, ctx: ctx}, true, nil
+}
+
+// isCompressible reports whether content of a type is worth compressing in
+// transit, i.e. it is text-like rather than an already-compressed format
+func isCompressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	major, minor, _ := strings.Cut(mediaType, "/")
+	switch {
+	case major == "text":
+		return true
+	case strings.HasSuffix(minor, "+json"), strings.HasSuffix(minor, "+xml"):
+		return true
+	case major == "application":
+		switch minor {
+		case "json", "xml", "javascript", "x-javascript", "ecmascript", "x-ndjson", "x-yaml", "yaml", "wasm":
+			return true
+		}
+	}
+	return false
+}
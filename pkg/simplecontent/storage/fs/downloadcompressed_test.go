@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_DownloadCompressed(t *testing.T) {
+	text := strings.Repeat("compress me on the fly\n", 100)
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 64)
+
+	for _, codec := range []string{CodecNone, CodecGzip, CodecZstd} {
+		t.Run(codec, func(t *testing.T) {
+			backend := newCompressedBackend(t, t.TempDir(), codec)
+			ctx := context.Background()
+
+			if err := backend.Upload(ctx, "notes.txt", strings.NewReader(text)); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+			if err := backend.UploadWithParams(ctx, strings.NewReader(png), simplecontent.UploadParams{ObjectKey: "logo.png", MimeType: "image/png"}); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+
+			rc, enc, err := backend.DownloadCompressed(ctx, "notes.txt", "gzip")
+			if err != nil || enc != "gzip" {
+				t.Fatalf("expected gzip encoding, got %q: %v", enc, err)
+			}
+			compressed, _ := io.ReadAll(rc)
+			rc.Close()
+			if len(compressed) >= len(text) {
+				t.Fatalf("expected compressed stream, got %d bytes", len(compressed))
+			}
+			zr, err := gzip.NewReader(bytes.NewReader(compressed))
+			if err != nil {
+				t.Fatalf("gzip reader: %v", err)
+			}
+			if got, _ := io.ReadAll(zr); string(got) != text {
+				t.Fatalf("decompressed content mismatch")
+			}
+
+			for _, tc := range []struct{ key, encoding, want string }{
+				{"logo.png", "gzip", png},
+				{"notes.txt", "br", text},
+				{"notes.txt", "", text},
+			} {
+				rc, enc, err := backend.DownloadCompressed(ctx, tc.key, tc.encoding)
+				if err != nil || enc != "" {
+					t.Fatalf("expected plain stream for %s/%q, got %q: %v", tc.key, tc.encoding, enc, err)
+				}
+				got, _ := io.ReadAll(rc)
+				rc.Close()
+				if string(got) != tc.want {
+					t.Fatalf("unexpected plain content for %s", tc.key)
+				}
+			}
+		})
+	}
+}
+
+func TestFSBackend_DownloadCompressedAbandoned(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "big.json", strings.NewReader(`{"rows":[`+strings.Repeat(`"row",`, 1<<16)+`""]}`)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	rc, _, err := backend.DownloadCompressed(ctx, "big.json", "GZIP")
+	if err != nil {
+		t.Fatalf("download compressed: %v", err)
+	}
+	// Closing before reading to the end stops the compressor
+	buf := make([]byte, 16)
+	if _, err := rc.Read(buf); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if err := rc.Close(); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package fs
+
+import (
+	"context"
+	"io"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// DownloadRange opens length bytes of an object starting at offset, or the
+// rest of it when length is negative; lengths past the end are clipped. The
+// range is of the content Download returns, so compressed objects are
+// decoded and skipped through rather than seeked. Offsets outside the object
+// return simplecontent.ErrRangeNotSatisfiable.
+//
+// The range is clamped against the size of the object opened, as StatObject
+// reports it, so replacing the object concurrently cannot skew it. Keys
+// TransparentDecompress decodes are measured by decoding them separately.
+func (b *Backend) DownloadRange(ctx context.Context, objectKey string, offset, length int64) (_ *simplecontent.ObjectRange, err error) {
+	defer wrapError(&err, "download_range", objectKey)
+
+	rc, size, err := b.downloadSized(ctx, objectKey)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		meta, err := b.GetObjectMeta(ctx, objectKey)
+		if err != nil {
+			rc.Close()
+			return nil, err
+		}
+		size = meta.Size
+	}
+	length, err = simplecontent.ClampRange(offset, length, size)
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	if s, ok := rc.(io.Seeker); ok {
+		_, err = s.Seek(offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(io.Discard, rc, offset)
+	}
+	if err != nil {
+		rc.Close()
+		return nil, err
+	}
+
+	return &simplecontent.ObjectRange{
+		ReadCloser: struct {
+			io.Reader
+			io.Closer
+		}{io.LimitReader(rc, length), rc},
+		Offset: offset,
+		Length: length,
+		Size:   size,
+	}, nil
+}
@@ -0,0 +1,98 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_DownloadRange(t *testing.T) {
+	for _, codec := range []string{"", CodecGzip} {
+		t.Run("codec="+codec, func(t *testing.T) {
+			backend := newCompressedBackend(t, t.TempDir(), codec)
+			ctx := context.Background()
+			if err := backend.Upload(ctx, "doc.txt", bytes.NewReader([]byte("0123456789"))); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+
+			tests := []struct {
+				offset, length int64
+				want           string
+				contentRange   string
+			}{
+				{2, 3, "234", "bytes 2-4/10"},
+				{7, -1, "789", "bytes 7-9/10"},
+				{8, 100, "89", "bytes 8-9/10"},
+				{0, 10, "0123456789", "bytes 0-9/10"},
+			}
+			for _, tt := range tests {
+				rng, err := backend.DownloadRange(ctx, "doc.txt", tt.offset, tt.length)
+				if err != nil {
+					t.Fatalf("range %d+%d: %v", tt.offset, tt.length, err)
+				}
+				got, err := io.ReadAll(rng)
+				_ = rng.Close()
+				if err != nil {
+					t.Fatalf("read range %d+%d: %v", tt.offset, tt.length, err)
+				}
+				if string(got) != tt.want || rng.Size != 10 || rng.ContentRange() != tt.contentRange {
+					t.Fatalf("range %d+%d: got %q (%s), want %q (%s)", tt.offset, tt.length, got, rng.ContentRange(), tt.want, tt.contentRange)
+				}
+			}
+
+			for _, offset := range []int64{-1, 10, 50} {
+				_, err := backend.DownloadRange(ctx, "doc.txt", offset, 1)
+				if !errors.Is(err, simplecontent.ErrRangeNotSatisfiable) {
+					t.Fatalf("offset %d: expected ErrRangeNotSatisfiable, got %v", offset, err)
+				}
+			}
+			if _, err := backend.DownloadRange(ctx, "missing", 0, 1); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+				t.Fatalf("expected ErrObjectNotFound, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFSBackend_DownloadRangeAliasedAndPacked(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), Compression: CodecGzip, PackAge: time.Hour})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+	content := "0123456789"
+
+	for _, key := range []string{"live/a", "archive/b"} {
+		if err := backend.Upload(ctx, key, strings.NewReader(content)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if err := backend.CreateAlias(ctx, "current", "live/a"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	age(t, backend, "archive/b", 2*time.Hour)
+	if err := backend.Compact(ctx, "archive/"); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	for _, key := range []string{"live/a", "current", "archive/b"} {
+		r, err := backend.DownloadRange(ctx, key, 4, -1)
+		if err != nil {
+			t.Fatalf("download range %s: %v", key, err)
+		}
+		got, _ := io.ReadAll(r)
+		r.Close()
+		if string(got) != content[4:] || r.Size != int64(len(content)) || r.Length != 6 {
+			t.Fatalf("%s: unexpected range %q of %d bytes, size %d", key, got, r.Length, r.Size)
+		}
+	}
+	if _, err := backend.DownloadRange(ctx, "archive/b", 11, 1); !errors.Is(err, simplecontent.ErrRangeNotSatisfiable) {
+		t.Fatalf("expected ErrRangeNotSatisfiable, got %v", err)
+	}
+}
@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"context"
+	"io"
+)
+
+// DownloadTo copies the object stored at objectKey to w and returns the
+// number of bytes written. The object is opened and closed within the call,
+// so no file descriptor can outlive it, even when the copy fails.
+// Uncompressed objects are copied with sendfile where w supports it (see
+// OpenWriterTo). Like Download, it honours TransparentDecompress.
+func (b *Backend) DownloadTo(ctx context.Context, objectKey string, w io.Writer) (_ int64, err error) {
+	defer wrapError(&err, "download_to", objectKey)
+
+	if b.extensionCodec(objectKey) != "" {
+		rc, err := b.Download(ctx, objectKey)
+		if err != nil {
+			return 0, err
+		}
+		defer rc.Close()
+		n, err := io.Copy(w, rc)
+		if err != nil {
+			return n, err
+		}
+		return n, ctx.Err()
+	}
+
+	wt, _, err := b.OpenWriterTo(ctx, objectKey)
+	if err != nil {
+		return 0, err
+	}
+	n, err := wt.WriteTo(w)
+	if err != nil {
+		return n, err
+	}
+	return n, ctx.Err()
+}
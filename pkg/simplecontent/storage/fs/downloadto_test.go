@@ -0,0 +1,70 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_DownloadCloseTwice(t *testing.T) {
+	for _, codec := range []string{CodecNone, CodecGzip} {
+		t.Run(codec, func(t *testing.T) {
+			backend := newCompressedBackend(t, t.TempDir(), codec)
+			ctx := context.Background()
+			if err := backend.Upload(ctx, "k", strings.NewReader("content")); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+
+			rc, err := backend.Download(ctx, "k")
+			if err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			if _, err := io.ReadAll(rc); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			if err := rc.Close(); err != nil {
+				t.Fatalf("first close: %v", err)
+			}
+			if err := rc.Close(); err != nil {
+				t.Fatalf("second close: %v", err)
+			}
+		})
+	}
+
+	// Uncompressed readers still support seeking for range serving
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	if err := backend.Upload(context.Background(), "k", strings.NewReader("content")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	rc, err := backend.Download(context.Background(), "k")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer rc.Close()
+	if _, ok := rc.(io.ReadSeeker); !ok {
+		t.Fatalf("expected uncompressed download to implement io.ReadSeeker")
+	}
+}
+
+func TestFSBackend_DownloadTo(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "k", strings.NewReader("content")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := backend.DownloadTo(ctx, "k", &buf)
+	if err != nil || n != 7 || buf.String() != "content" {
+		t.Fatalf("unexpected DownloadTo result n=%d %q err=%v", n, buf.String(), err)
+	}
+
+	if _, err := backend.DownloadTo(ctx, "missing", &buf); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func init() {
+	simplecontent.RegisterScheme("fs", openDSN)
+}
+
+// openDSN creates a backend from an fs DSN. The path is the base directory,
+// absolute as in fs:///var/data or relative as in fs:data. Options:
+//
+//	url_prefix       URLPrefix
+//	sign_key         SignatureSecretKey
+//	presign_expires  PresignExpires, as a duration such as 1h
+//	compression      Compression
+//	key_prefix       KeyPrefix
+func openDSN(dsn *url.URL) (simplecontent.BlobStore, error) {
+	config := Config{BaseDir: dsn.Opaque}
+	if config.BaseDir == "" {
+		config.BaseDir = dsn.Host + dsn.Path
+	}
+	if config.BaseDir == "" {
+		return nil, fmt.Errorf("base directory is required")
+	}
+
+	for name, values := range dsn.Query() {
+		value := values[len(values)-1]
+		switch name {
+		case "url_prefix":
+			config.URLPrefix = value
+		case "sign_key":
+			config.SignatureSecretKey = value
+		case "presign_expires":
+			d, err := time.ParseDuration(value)
+			if err != nil || d <= 0 {
+				return nil, fmt.Errorf("invalid presign_expires %q: expected a positive duration such as 1h", value)
+			}
+			config.PresignExpires = d
+		case "compression":
+			config.Compression = value
+		case "key_prefix":
+			config.KeyPrefix = value
+		default:
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+	}
+	return New(config)
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"context"
+	"os"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// FindDuplicatesOf returns the keys under prefix, other than objectKey
+// itself, whose content is identical to objectKey's, in key order. Only
+// objects of the same size are checksummed, using the checksum recorded at
+// upload where there is one; hardlinked copies match without being read.
+func (b *Backend) FindDuplicatesOf(ctx context.Context, objectKey string, prefix string) (_ []string, err error) {
+	defer wrapError(&err, "find_duplicates_of", objectKey)
+
+	info, sc, err := b.statObject(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	size := b.fileMeta(objectKey, info, sc).Size
+
+	// The target is hashed lazily so a prefix without same-size objects
+	// never reads it
+	var sum string
+	var keys []string
+	err = b.walkObjects(ctx, prefix, func(filePath string, meta simplecontent.ObjectMeta, _ *sidecar) error {
+		if meta.Key == objectKey || meta.Size != size {
+			return nil
+		}
+		if candidate, err := os.Stat(filePath); err == nil && os.SameFile(info, candidate) {
+			keys = append(keys, meta.Key)
+			return nil
+		}
+
+		if sum == "" {
+			targetPath, err := b.objectPath(objectKey)
+			if err != nil {
+				return err
+			}
+			if sum, err = b.contentSHA256(targetPath); os.IsNotExist(err) {
+				return simplecontent.ErrObjectNotFound
+			} else if err != nil {
+				return err
+			}
+		}
+		candidateSum, err := b.contentSHA256(filePath)
+		if os.IsNotExist(err) {
+			// Deleted while walking
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if candidateSum == sum {
+			keys = append(keys, meta.Key)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
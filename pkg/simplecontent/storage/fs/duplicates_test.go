@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_FindDuplicatesOf(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), PreferHardlink: true})
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	for key, content := range map[string]string{
+		"photos/a.jpg":   "same bytes",
+		"photos/b.jpg":   "same bytes",
+		"photos/c.jpg":   "diff bytes",
+		"photos/d.jpg":   "other size",
+		"archive/a.jpg":  "same bytes",
+		"photos/x/e.jpg": "same bytes!",
+	} {
+		if err := backend.Upload(ctx, key, strings.NewReader(content)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if err := backend.Copy(ctx, "photos/a.jpg", "photos/x/link.jpg"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	got, err := backend.FindDuplicatesOf(ctx, "photos/a.jpg", "photos/")
+	if err != nil {
+		t.Fatalf("find duplicates: %v", err)
+	}
+	if want := []string{"photos/b.jpg", "photos/x/link.jpg"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	got, err = backend.FindDuplicatesOf(ctx, "photos/a.jpg", "")
+	if err != nil || len(got) != 3 || got[0] != "archive/a.jpg" {
+		t.Fatalf("expected duplicates across the store, got %v err=%v", got, err)
+	}
+
+	if _, err := backend.FindDuplicatesOf(ctx, "photos/missing.jpg", ""); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,117 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// trackUnsynced records a newly written object and its sidecar for the next
+// FlushAll when DeferSync is enabled
+func (b *Backend) trackUnsynced(filePath string) {
+	if !b.deferSync {
+		return
+	}
+	b.syncMu.Lock()
+	defer b.syncMu.Unlock()
+	b.unsynced[filePath] = struct{}{}
+}
+
+// syncWrite makes the sidecar of a newly written object and the directory
+// entries of both durable when SyncOnWrite is enabled. The object's content
+// is synced by the write itself. Metadata batched by SidecarIndex is left to
+// FlushMetadata.
+func (b *Backend) syncWrite(filePath string) error {
+	if !b.syncOnWrite {
+		return nil
+	}
+	if b.sidecarIndex == nil {
+		if err := syncPath(sidecarPath(filePath)); err != nil {
+			return err
+		}
+	}
+	return syncPath(filepath.Dir(filePath))
+}
+
+// FlushAll makes every object written since the previous FlushAll durable:
+// each file, its sidecar and their parent directories are fsynced. It is a
+// barrier for batch jobs that must not discard their source until the data
+// is safely on disk.
+//
+// With SidecarIndex it first writes out pending metadata (see FlushMetadata)
+// and syncs the index of each directory written to. Writes are only tracked
+// when DeferSync is enabled; otherwise FlushAll returns after writing the
+// metadata and durability is left to the filesystem. Objects deleted since
+// they were written are skipped. Paths that fail to sync are kept for the
+// next call.
+func (b *Backend) FlushAll(ctx context.Context) (err error) {
+	defer wrapError(&err, "flush_all", "")
+
+	if err := b.FlushMetadata(ctx); err != nil {
+		return err
+	}
+	if !b.deferSync {
+		return nil
+	}
+
+	b.syncMu.Lock()
+	pending := b.unsynced
+	b.unsynced = make(map[string]struct{})
+	b.syncMu.Unlock()
+
+	var errs []error
+	failed := make(map[string]struct{})
+	dirs := make(map[string]struct{})
+	for filePath := range pending {
+		if err := ctx.Err(); err != nil {
+			failed[filePath] = struct{}{}
+			continue
+		}
+		for _, path := range []string{filePath, sidecarPath(filePath)} {
+			if err := syncPath(path); err != nil {
+				errs = append(errs, err)
+				failed[filePath] = struct{}{}
+			}
+		}
+		dirs[filepath.Dir(filePath)] = struct{}{}
+	}
+	for dir := range dirs {
+		if b.sidecarIndex != nil {
+			if err := syncPath(filepath.Join(dir, sidecarIndexName)); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := syncPath(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(failed) > 0 {
+		b.syncMu.Lock()
+		for filePath := range failed {
+			b.unsynced[filePath] = struct{}{}
+		}
+		b.syncMu.Unlock()
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return errors.Join(errs...)
+}
+
+// syncPath fsyncs a file or directory, ignoring paths that no longer exist
+func syncPath(path string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open %s for sync: %w", path, err)
+	}
+	defer f.Close()
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("failed to sync %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_FlushAll(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), DeferSync: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	for _, key := range []string{"batch/a", "batch/b", "other/c"} {
+		if err := backend.Upload(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if err := backend.Copy(ctx, "batch/a", "copies/a"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	if err := backend.Delete(ctx, "batch/b"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if n := len(backend.unsynced); n != 4 {
+		t.Fatalf("expected 4 tracked writes, got %d", n)
+	}
+
+	// Deleted objects are skipped rather than failing the flush
+	if err := backend.FlushAll(ctx); err != nil {
+		t.Fatalf("flush all: %v", err)
+	}
+	if n := len(backend.unsynced); n != 0 {
+		t.Fatalf("expected pending set cleared, got %d", n)
+	}
+
+	if err := backend.Upload(ctx, "late", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := backend.FlushAll(cancelled); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if n := len(backend.unsynced); n != 1 {
+		t.Fatalf("expected unflushed write to stay pending, got %d", n)
+	}
+}
+
+func TestFSBackend_FlushAllWithoutDeferSync(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "k", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if n := len(backend.unsynced); n != 0 {
+		t.Fatalf("expected writes untracked, got %d", n)
+	}
+	if err := backend.FlushAll(ctx); err != nil {
+		t.Fatalf("flush all: %v", err)
+	}
+}
+
+func TestFSBackend_SyncOnWrite(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), SyncOnWrite: true, Compression: CodecGzip})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "wal/0001", strings.NewReader("entry")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Copy(ctx, "wal/0001", "wal/0002"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+	for _, key := range []string{"wal/0001", "wal/0002"} {
+		if got := readObject(t, backend, key); got != "entry" {
+			t.Fatalf("unexpected content of %s: %q", key, got)
+		}
+	}
+	if n := len(backend.unsynced); n != 0 {
+		t.Fatalf("expected nothing tracked without DeferSync, got %d", n)
+	}
+}
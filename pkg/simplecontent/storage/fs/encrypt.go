@@ -0,0 +1,203 @@
+package fs
+
+import (
+	"bufio"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// encryptionPrefix starts the names of the codecs New registers for
+// EncryptionKey; the rest of the name identifies the key
+const encryptionPrefix = "aes-256-gcm:"
+
+// encryptChunkSize is the plaintext size of each sealed chunk but the last
+const encryptChunkSize = 64 << 10
+
+// Additional data sealed with each chunk, marking whether it is the last
+var (
+	chunkMore  = []byte{0}
+	chunkFinal = []byte{1}
+)
+
+// encryptionCodec encrypts objects with AES-256-GCM in chunks of
+// encryptChunkSize, so neither direction buffers whole objects. A stream is
+// a random nonce followed by the sealed chunks. Each chunk is sealed with
+// the stream nonce XORed with its index, and the last one is marked final,
+// so reordered, dropped or truncated chunks fail authentication.
+type encryptionCodec struct {
+	aead cipher.AEAD
+}
+
+// newEncryptionCodec returns the codec for an AES-256 key and the name it
+// is registered under. The name carries a key ID, so objects record which
+// key they were encrypted with. Like other codecs, the key stays registered
+// for the process, so objects encrypted with a previous key remain readable
+// while a backend with that key has been created.
+func newEncryptionCodec(key []byte) (string, Codec, error) {
+	if len(key) != 32 {
+		return "", nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", nil, err
+	}
+	id := sha256.Sum256(append([]byte("simple-content fs key id\x00"), key...))
+	return encryptionPrefix + hex.EncodeToString(id[:8]), encryptionCodec{aead: aead}, nil
+}
+
+// chunkNonce returns the nonce of chunk index of a stream
+func chunkNonce(dst, nonce []byte, index uint64) []byte {
+	dst = append(dst[:0], nonce...)
+	for i := range 8 {
+		dst[len(dst)-1-i] ^= byte(index >> (8 * i))
+	}
+	return dst
+}
+
+func (c encryptionCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(nonce); err != nil {
+		return nil, err
+	}
+	return &encryptWriter{aead: c.aead, w: w, nonce: nonce, buf: make([]byte, 0, encryptChunkSize)}, nil
+}
+
+func (c encryptionCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(r, nonce); errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("%w: missing nonce", simplecontent.ErrDecryptionFailed)
+	} else if err != nil {
+		return nil, err
+	}
+	return &decryptReader{aead: c.aead, r: bufio.NewReader(r), nonce: nonce}, nil
+}
+
+// encryptWriter seals content written to it a chunk at a time. A full chunk
+// is only sealed once more content follows, so Close always seals the final
+// chunk, empty if need be.
+type encryptWriter struct {
+	aead    cipher.AEAD
+	w       io.Writer
+	nonce   []byte
+	index   uint64
+	buf     []byte // Plaintext of the current chunk
+	out     []byte // Sealed chunk, reused
+	scratch []byte // Chunk nonce, reused
+	closed  bool
+}
+
+func (e *encryptWriter) Write(p []byte) (int, error) {
+	n := 0
+	for len(p) > 0 {
+		if len(e.buf) == encryptChunkSize {
+			if err := e.seal(chunkMore); err != nil {
+				return n, err
+			}
+		}
+		k := copy(e.buf[len(e.buf):encryptChunkSize], p)
+		e.buf = e.buf[:len(e.buf)+k]
+		p = p[k:]
+		n += k
+	}
+	return n, nil
+}
+
+func (e *encryptWriter) seal(additional []byte) error {
+	e.scratch = chunkNonce(e.scratch, e.nonce, e.index)
+	e.out = e.aead.Seal(e.out[:0], e.scratch, e.buf, additional)
+	e.index++
+	e.buf = e.buf[:0]
+	_, err := e.w.Write(e.out)
+	return err
+}
+
+func (e *encryptWriter) Close() error {
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+	return e.seal(chunkFinal)
+}
+
+// decryptReader opens the chunks of a stream as they are read. A chunk is
+// the last when the stream ends after it.
+type decryptReader struct {
+	aead    cipher.AEAD
+	r       *bufio.Reader
+	nonce   []byte
+	index   uint64
+	sealed  []byte // Current chunk as stored, reused
+	opened  []byte // Current chunk's plaintext, reused
+	plain   []byte // Unread part of opened
+	scratch []byte // Chunk nonce, reused
+	done    bool   // The final chunk has been opened
+	err     error
+}
+
+func (d *decryptReader) Read(p []byte) (int, error) {
+	for len(d.plain) == 0 {
+		if d.err != nil {
+			return 0, d.err
+		}
+		if d.done {
+			d.err = io.EOF
+			continue
+		}
+		d.err = d.open()
+	}
+	n := copy(p, d.plain)
+	d.plain = d.plain[n:]
+	return n, nil
+}
+
+// open reads and authenticates the next chunk
+func (d *decryptReader) open() error {
+	if d.sealed == nil {
+		d.sealed = make([]byte, encryptChunkSize+d.aead.Overhead())
+	}
+	n, err := io.ReadFull(d.r, d.sealed)
+	final := false
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		final = true
+	} else if err != nil {
+		return err
+	} else if _, err := d.r.Peek(1); errors.Is(err, io.EOF) {
+		final = true
+	} else if err != nil {
+		return err
+	}
+
+	additional := chunkMore
+	if final {
+		additional = chunkFinal
+	}
+	d.scratch = chunkNonce(d.scratch, d.nonce, d.index)
+	opened, err := d.aead.Open(d.opened[:0], d.scratch, d.sealed[:n], additional)
+	if err != nil {
+		return fmt.Errorf("%w: chunk %d failed authentication", simplecontent.ErrDecryptionFailed, d.index)
+	}
+	d.opened, d.plain = opened, opened
+	d.index++
+	d.done = final
+	return nil
+}
+
+// Close does nothing; openDecoded closes the file beneath
+func (d *decryptReader) Close() error {
+	return nil
+}
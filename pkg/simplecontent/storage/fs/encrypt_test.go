@@ -0,0 +1,129 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func newEncryptedBackend(t *testing.T, dir, codec string, key []byte) *Backend {
+	t.Helper()
+	b, err := New(Config{BaseDir: dir, Compression: codec, EncryptionKey: key})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	return b.(*Backend)
+}
+
+func TestFSBackend_EncryptionRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	ctx := context.Background()
+
+	sizes := map[string]int{
+		"empty":          0,
+		"one chunk":      100,
+		"chunk multiple": 2 * encryptChunkSize,
+		"partial chunk":  3*encryptChunkSize + 5,
+	}
+	for name, size := range sizes {
+		for _, codec := range []string{"", CodecGzip} {
+			t.Run(name+"/"+codec, func(t *testing.T) {
+				b := newEncryptedBackend(t, t.TempDir(), codec, key)
+				content := strings.Repeat("secret-", size/7+1)[:size]
+				if err := b.Upload(ctx, "docs/a.txt", strings.NewReader(content)); err != nil {
+					t.Fatalf("upload: %v", err)
+				}
+				if got := readObject(t, b, "docs/a.txt"); got != content {
+					t.Fatalf("round trip mismatch: got %d bytes, want %d", len(got), len(content))
+				}
+				meta, err := b.GetObjectMeta(ctx, "docs/a.txt")
+				if err != nil || meta.Size != int64(size) {
+					t.Fatalf("expected plaintext size %d, got %+v, %v", size, meta, err)
+				}
+
+				stored, err := os.ReadFile(mustObjectPath(t, b, "docs/a.txt"))
+				if err != nil {
+					t.Fatalf("read stored file: %v", err)
+				}
+				if size > 0 && bytes.Contains(stored, []byte("secret-")) {
+					t.Fatal("expected stored bytes to be encrypted")
+				}
+			})
+		}
+	}
+}
+
+func TestFSBackend_EncryptionFailures(t *testing.T) {
+	ctx := context.Background()
+	key := bytes.Repeat([]byte{7}, 32)
+	content := strings.Repeat("x", 2*encryptChunkSize+10)
+
+	if _, err := New(Config{BaseDir: t.TempDir(), EncryptionKey: []byte("short")}); err == nil {
+		t.Fatal("expected error for a key that is not 32 bytes")
+	}
+
+	dir := t.TempDir()
+	b := newEncryptedBackend(t, dir, "", key)
+	if err := b.Upload(ctx, "a.txt", strings.NewReader(content)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	filePath := mustObjectPath(t, b, "a.txt")
+	stored, err := os.ReadFile(filePath)
+	if err != nil {
+		t.Fatalf("read stored file: %v", err)
+	}
+
+	// Objects name their key, which only needs configuring on some backend
+	// in the process; drop it to read as a process holding another key
+	other := newEncryptedBackend(t, dir, "", bytes.Repeat([]byte{8}, 32))
+	name, _, _ := newEncryptionCodec(key)
+	codecsMu.Lock()
+	delete(codecs, name)
+	codecsMu.Unlock()
+	if _, err := other.Download(ctx, "a.txt"); !errors.Is(err, simplecontent.ErrDecryptionFailed) {
+		t.Fatalf("expected ErrDecryptionFailed without the key, got %v", err)
+	}
+	b = newEncryptedBackend(t, dir, "", key)
+
+	overhead := 16
+	damaged := map[string][]byte{
+		"tampered":            append(bytes.Clone(stored[:100]), append([]byte{stored[100] ^ 1}, stored[101:]...)...),
+		"truncated":           stored[:len(stored)-20],
+		"final chunk dropped": stored[:12+2*(encryptChunkSize+overhead)],
+	}
+	for name, data := range damaged {
+		t.Run(name, func(t *testing.T) {
+			if err := os.WriteFile(filePath, data, 0644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			rc, err := b.Download(ctx, "a.txt")
+			if err == nil {
+				_, err = io.ReadAll(rc)
+				rc.Close()
+			}
+			if !errors.Is(err, simplecontent.ErrDecryptionFailed) {
+				t.Fatalf("expected ErrDecryptionFailed, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFSBackend_EncryptionEnabledLater(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	plain := newCompressedBackend(t, dir, "")
+	if err := plain.Upload(ctx, "old.txt", strings.NewReader("before")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	b := newEncryptedBackend(t, dir, "", bytes.Repeat([]byte{7}, 32))
+	if got := readObject(t, b, "old.txt"); got != "before" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
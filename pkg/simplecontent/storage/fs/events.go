@@ -0,0 +1,55 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// Event describes a completed upload, download or delete. A download
+// completes once the object is opened.
+type Event struct {
+	Op       string        // Operation, as reported in StorageError.Op
+	Key      string        // Object key the operation was called with
+	Err      error         // Error returned to the caller, or nil
+	Duration time.Duration // Time the operation took
+	Fields   []slog.Attr   // Request-scoped fields from LogFieldsFromContext
+}
+
+// EventHook observes completed operations. Hooks run synchronously on the
+// calling goroutine, so they should return quickly.
+type EventHook func(ctx context.Context, event Event)
+
+// observe reports an operation started at start and failing with *err, if
+// at all, to the Logger and EventHooks. Request fields are only extracted
+// when one of them is configured.
+func (b *Backend) observe(ctx context.Context, op, key string, start time.Time, err *error) {
+	if b.logger == nil && len(b.hooks) == 0 {
+		return
+	}
+	event := Event{Op: op, Key: key, Err: *err, Duration: time.Since(start)}
+	if b.logFields != nil {
+		event.Fields = b.logFields(ctx)
+	}
+
+	if b.logger != nil {
+		attrs := make([]slog.Attr, 0, 4+len(event.Fields))
+		attrs = append(attrs, slog.String("op", op), slog.String("key", key), slog.Duration("duration", event.Duration))
+		attrs = append(attrs, event.Fields...)
+		switch {
+		case event.Err == nil:
+			b.logger.LogAttrs(ctx, slog.LevelDebug, "fs operation", attrs...)
+		case errors.Is(event.Err, simplecontent.ErrObjectNotFound):
+			// Expected by callers probing for objects
+			b.logger.LogAttrs(ctx, slog.LevelDebug, "fs operation", append(attrs, slog.Any("error", event.Err))...)
+		default:
+			b.logger.LogAttrs(ctx, slog.LevelError, "fs operation failed", append(attrs, slog.Any("error", event.Err))...)
+		}
+	}
+	for _, hook := range b.hooks {
+		hook(ctx, event)
+	}
+}
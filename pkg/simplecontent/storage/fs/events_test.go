@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+type requestIDKey struct{}
+
+func TestFSBackend_LogFieldsFromContext(t *testing.T) {
+	var logs bytes.Buffer
+	var events []Event
+	b, err := New(Config{
+		BaseDir: t.TempDir(),
+		Logger:  slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		EventHooks: []EventHook{func(ctx context.Context, event Event) {
+			events = append(events, event)
+		}},
+		LogFieldsFromContext: func(ctx context.Context) []slog.Attr {
+			if id, ok := ctx.Value(requestIDKey{}).(string); ok {
+				return []slog.Attr{slog.String("request_id", id)}
+			}
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	ctx := context.WithValue(context.Background(), requestIDKey{}, "req-42")
+
+	if err := b.Upload(ctx, "a.txt", strings.NewReader("a")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if _, err := b.Download(ctx, "missing.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %+v", events)
+	}
+	if events[0].Op != "upload" || events[0].Key != "a.txt" || events[0].Err != nil {
+		t.Fatalf("unexpected upload event: %+v", events[0])
+	}
+	if events[1].Op != "download" || !errors.Is(events[1].Err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("unexpected download event: %+v", events[1])
+	}
+	for _, event := range events {
+		if len(event.Fields) != 1 || event.Fields[0].Value.String() != "req-42" {
+			t.Fatalf("expected request fields on %s event, got %v", event.Op, event.Fields)
+		}
+	}
+	if got := strings.Count(logs.String(), "request_id=req-42"); got != 2 {
+		t.Fatalf("expected request ID in both log records, got:\n%s", logs.String())
+	}
+}
+
+func TestFSBackend_LogFieldsUnset(t *testing.T) {
+	called := false
+	b, err := New(Config{
+		BaseDir: t.TempDir(),
+		LogFieldsFromContext: func(ctx context.Context) []slog.Attr {
+			called = true
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	if err := b.Upload(context.Background(), "a.txt", strings.NewReader("a")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if called {
+		t.Fatal("expected fields not to be extracted without a logger or hook")
+	}
+}
@@ -0,0 +1,166 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// defaultExpiryInterval is how often StartExpiry sweeps without
+// ExpiryInterval
+const defaultExpiryInterval = time.Minute
+
+// ExpireObjects deletes every object last written more than ObjectTTL ago,
+// with its sidecar and preview, removes the directories that leaves empty,
+// and returns how many objects were deleted. An object is checked again
+// under its lock before it is removed, so one rewritten since the walk
+// found it is kept. Objects another backend holds a lease on are kept while
+// EnforceLeases is set.
+func (b *Backend) ExpireObjects(ctx context.Context) (_ int, err error) {
+	defer b.observe(ctx, "expire", "", time.Now(), &err)
+	defer wrapError(&err, "expire", "")
+
+	if err := b.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if b.objectTTL <= 0 {
+		return 0, errors.New("object ttl is not configured")
+	}
+	cutoff := time.Now().Add(-b.objectTTL)
+
+	var expired []string
+	err = b.walkObjects(ctx, "", func(filePath string, meta simplecontent.ObjectMeta, _ *sidecar) error {
+		if meta.UpdatedAt.Before(cutoff) {
+			expired = append(expired, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	deleted := 0
+	dirs := make(map[string]bool)
+	var errs []error
+	for _, filePath := range expired {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		removed, err := b.expireObject(filePath, cutoff)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		if removed {
+			deleted++
+			dirs[filepath.Dir(filePath)] = true
+		}
+	}
+
+	// Clean up the deepest directories first, so their parents are seen
+	// empty once they are gone
+	sorted := make([]string, 0, len(dirs))
+	for dir := range dirs {
+		sorted = append(sorted, dir)
+	}
+	slices.SortFunc(sorted, func(a, b string) int { return len(b) - len(a) })
+	for _, dir := range sorted {
+		b.cleanupEmptyDirectories(dir)
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// expireObject removes the object at filePath and its companions if it was
+// still last written before cutoff, reporting whether it did
+func (b *Backend) expireObject(filePath string, cutoff time.Time) (bool, error) {
+	defer b.keyLocks.lock(filePath)()
+
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get file info: %w", err)
+	}
+	if !info.ModTime().Before(cutoff) {
+		return false, nil
+	}
+	if err := b.checkLease(filePath); errors.Is(err, simplecontent.ErrObjectBusy) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	objectKey, err := b.objectKey(filePath)
+	if err != nil {
+		return false, err
+	}
+
+	if err := os.Remove(filePath); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to delete file: %w", err)
+	}
+	return true, b.removeAttached(objectKey, filePath)
+}
+
+// StartExpiry runs ExpireObjects in the background, once straight away and
+// then every ExpiryInterval, until ctx is done or StopExpiry is called.
+// Failed sweeps are reported to the Logger and EventHooks as "expire"
+// operations and retried at the next interval. It fails if ObjectTTL is not
+// set or expiry is already running.
+func (b *Backend) StartExpiry(ctx context.Context) error {
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if b.objectTTL <= 0 {
+		return errors.New("object ttl is not configured")
+	}
+
+	b.expiryMu.Lock()
+	defer b.expiryMu.Unlock()
+	if b.expiryDone != nil {
+		select {
+		case <-b.expiryDone:
+		default:
+			return errors.New("expiry is already running")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	b.expiryStop, b.expiryDone = cancel, done
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(b.expiryInterval)
+		defer ticker.Stop()
+		for {
+			_, _ = b.ExpireObjects(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// StopExpiry stops the sweeps started by StartExpiry and waits for one in
+// progress to finish. Calling it when expiry is not running does nothing.
+func (b *Backend) StopExpiry() {
+	b.expiryMu.Lock()
+	stop, done := b.expiryStop, b.expiryDone
+	b.expiryStop, b.expiryDone = nil, nil
+	b.expiryMu.Unlock()
+
+	if stop != nil {
+		stop()
+		<-done
+	}
+}
@@ -0,0 +1,127 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newExpiringBackend(t *testing.T, dir string, ttl time.Duration) *Backend {
+	t.Helper()
+	b, err := New(Config{BaseDir: dir, ObjectTTL: ttl, ExpiryInterval: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	return b.(*Backend)
+}
+
+// age sets the modification time of an object to d ago
+func age(t *testing.T, b *Backend, key string, d time.Duration) {
+	t.Helper()
+	when := time.Now().Add(-d)
+	if err := os.Chtimes(mustObjectPath(t, b, key), when, when); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+}
+
+func TestFSBackend_ExpireObjects(t *testing.T) {
+	dir := t.TempDir()
+	b := newExpiringBackend(t, dir, time.Hour)
+	ctx := context.Background()
+
+	for _, key := range []string{"derived/a/thumb.png", "derived/b.png", "keep/c.png"} {
+		if err := b.Upload(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	age(t, b, "derived/a/thumb.png", 2*time.Hour)
+	age(t, b, "derived/b.png", 2*time.Hour)
+	if err := b.Reserve(ctx, "derived/pending.png", time.Minute); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+
+	deleted, err := b.ExpireObjects(ctx)
+	if err != nil || deleted != 2 {
+		t.Fatalf("expected 2 objects expired, got %d, %v", deleted, err)
+	}
+	if keys := listKeys(t, b, ""); len(keys) != 1 || keys[0] != "keep/c.png" {
+		t.Fatalf("expected only the fresh object left, got %v", keys)
+	}
+	if _, err := os.Stat(sidecarPath(filepath.Join(dir, "derived", "b.png"))); !os.IsNotExist(err) {
+		t.Fatalf("expected sidecar removed with its object")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "derived", "a")); !os.IsNotExist(err) {
+		t.Fatalf("expected emptied directory removed")
+	}
+	if _, err := os.Stat(mustObjectPath(t, b, "derived/pending.png")); err != nil {
+		t.Fatalf("expected reservation kept: %v", err)
+	}
+
+	if _, err := newCompressedBackend(t, dir, "").ExpireObjects(ctx); err == nil {
+		t.Fatal("expected error without ObjectTTL")
+	}
+}
+
+func TestFSBackend_StartExpiry(t *testing.T) {
+	b := newExpiringBackend(t, t.TempDir(), time.Hour)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := b.Upload(ctx, "old.txt", strings.NewReader("old")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	age(t, b, "old.txt", 2*time.Hour)
+
+	if err := b.StartExpiry(ctx); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if err := b.StartExpiry(ctx); err == nil {
+		t.Fatal("expected error starting expiry twice")
+	}
+	waitFor(t, func() bool {
+		ok, err := b.Exists(ctx, "old.txt")
+		return err == nil && !ok
+	})
+
+	// Sweeps keep running until stopped
+	if err := b.Upload(ctx, "later.txt", strings.NewReader("later")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	age(t, b, "later.txt", 2*time.Hour)
+	waitFor(t, func() bool {
+		ok, err := b.Exists(ctx, "later.txt")
+		return err == nil && !ok
+	})
+
+	b.StopExpiry()
+	if err := b.Upload(ctx, "kept.txt", strings.NewReader("kept")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	age(t, b, "kept.txt", 2*time.Hour)
+	time.Sleep(50 * time.Millisecond)
+	if ok, err := b.Exists(ctx, "kept.txt"); err != nil || !ok {
+		t.Fatalf("expected no sweeps after StopExpiry, got %v, %v", ok, err)
+	}
+
+	// Cancelling the context also stops the loop, and expiry can restart
+	if err := b.StartExpiry(ctx); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	cancel()
+	b.StopExpiry()
+}
+
+// waitFor polls cond until it holds or a second passes
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
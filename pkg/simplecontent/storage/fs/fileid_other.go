@@ -0,0 +1,15 @@
+//go:build !unix
+
+package fs
+
+import "os"
+
+// fileID identifies a file independently of the paths linking to it
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileIDOf reports that file identity is unavailable on this platform
+func fileIDOf(info os.FileInfo) (fileID, bool) {
+	return fileID{}, false
+}
@@ -0,0 +1,22 @@
+//go:build unix
+
+package fs
+
+import (
+	"os"
+	"syscall"
+)
+
+// fileID identifies a file independently of the paths linking to it
+type fileID struct {
+	dev, ino uint64
+}
+
+// fileIDOf returns the identity of the file described by info
+func fileIDOf(info os.FileInfo) (fileID, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fileID{}, false
+	}
+	return fileID{dev: uint64(st.Dev), ino: uint64(st.Ino)}, true
+}
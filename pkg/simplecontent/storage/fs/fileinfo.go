@@ -0,0 +1,30 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// FileInfo returns the os.Stat result for the file backing an object, for
+// callers that need filesystem details ObjectMeta does not carry, such as the
+// device and inode in Sys() to detect hardlinked copies. Size and mode
+// describe the stored file, which differ from the object for compressed
+// objects.
+func (b *Backend) FileInfo(objectKey string) (_ os.FileInfo, err error) {
+	defer wrapError(&err, "file_info", objectKey)
+
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return nil, simplecontent.ErrObjectNotFound
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	return info, nil
+}
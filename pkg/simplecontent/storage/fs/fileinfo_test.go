@@ -0,0 +1,49 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_FileInfo(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), PreferHardlink: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "orig", strings.NewReader("shared bytes")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Copy(ctx, "orig", "linked"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	orig, err := backend.FileInfo("orig")
+	if err != nil {
+		t.Fatalf("file info: %v", err)
+	}
+	linked, err := backend.FileInfo("linked")
+	if err != nil {
+		t.Fatalf("file info: %v", err)
+	}
+	if orig.Size() != 12 || orig.IsDir() {
+		t.Fatalf("unexpected file info %v", orig)
+	}
+	if !os.SameFile(orig, linked) {
+		t.Fatalf("expected hardlinked copy to share the original's file")
+	}
+
+	if _, err := backend.FileInfo("missing"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+	if _, err := backend.FileInfo("../escape"); !errors.Is(err, simplecontent.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}
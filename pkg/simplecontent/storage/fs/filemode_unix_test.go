@@ -0,0 +1,82 @@
+//go:build unix
+
+package fs
+
+import (
+	"context"
+	"os"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestFSBackend_FileModeIgnoresUmask(t *testing.T) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	b, err := New(Config{BaseDir: t.TempDir(), FileMode: 0644})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "shared/report.txt", strings.NewReader("report")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Copy(ctx, "shared/report.txt", "shared/copy.txt"); err != nil {
+		t.Fatalf("copy: %v", err)
+	}
+
+	for _, key := range []string{"shared/report.txt", "shared/copy.txt"} {
+		path, _ := backend.objectPath(key)
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatalf("stat %s: %v", key, err)
+		}
+		if info.Mode().Perm() != 0644 {
+			t.Fatalf("expected mode 0644 for %s under umask 0077, got %o", key, info.Mode().Perm())
+		}
+	}
+
+	// Without FileMode the umask applies as before
+	b, err = New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend = b.(*Backend)
+	if err := backend.Upload(ctx, "private", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	path, _ := backend.objectPath("private")
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+		t.Fatalf("expected umask-derived mode 0600, got %o", info.Mode().Perm())
+	}
+}
+
+func TestFSBackend_DirModeIgnoresUmask(t *testing.T) {
+	old := syscall.Umask(0077)
+	defer syscall.Umask(old)
+
+	dir := t.TempDir()
+	b, err := New(Config{BaseDir: dir, DirMode: 0750})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	if err := b.Upload(context.Background(), "team/reports/q1.txt", strings.NewReader("q1")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	for _, sub := range []string{"team", "team/reports"} {
+		info, err := os.Stat(dir + "/" + sub)
+		if err != nil {
+			t.Fatalf("stat %s: %v", sub, err)
+		}
+		if info.Mode().Perm() != 0750 {
+			t.Fatalf("expected mode 0750 for %s under umask 0077, got %o", sub, info.Mode().Perm())
+		}
+	}
+
+	if _, err := New(Config{BaseDir: t.TempDir(), DirMode: 0600}); err == nil {
+		t.Fatalf("expected an error for a dir mode without owner search permission")
+	}
+}
@@ -0,0 +1,62 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// FinalizeKeyFunc chooses the key an upload is committed under once its
+// content is staged. It receives the key the upload was made to and the
+// staged object's metadata (key, size and content type, detected when not
+// declared) and returns the final key, which may be stagedKey itself. An
+// error aborts the upload.
+type FinalizeKeyFunc func(stagedKey string, meta simplecontent.ObjectMeta) (finalKey string, err error)
+
+// UploadFinalized uploads like UploadWithParams and returns the key the
+// object was committed under. Without FinalizeKey configured this is always
+// params.ObjectKey; with it, it is the key FinalizeKey chose after seeing
+// the content, e.g. to route uploads into images/ or docs/ by content type.
+// Upload and UploadWithParams apply FinalizeKey too but do not report the
+// key.
+func (b *Backend) UploadFinalized(ctx context.Context, reader io.Reader, params simplecontent.UploadParams) (_ string, err error) {
+	defer wrapError(&err, "upload_finalized", params.ObjectKey)
+
+	result, err := b.upload(ctx, reader, params)
+	return result.Key, err
+}
+
+// finalize asks FinalizeKey for the final key of a staged upload and points
+// the staged object at it
+func (b *Backend) finalize(staged *stagedObject, stagedKey string) (string, error) {
+	contentType := staged.written.ContentType
+	if contentType == "" {
+		contentType = sniffContentType(staged.tmpPath, staged.written.Codec)
+	}
+	meta := simplecontent.ObjectMeta{
+		Key:         stagedKey,
+		Size:        staged.written.Size,
+		ContentType: contentType,
+	}
+
+	finalKey, err := b.finalizeKey(stagedKey, meta)
+	if err != nil {
+		return "", fmt.Errorf("finalize key: %w", err)
+	}
+	if finalKey == stagedKey {
+		return finalKey, nil
+	}
+
+	finalPath, err := b.objectPath(finalKey)
+	if err != nil {
+		return "", err
+	}
+	if err := b.mkdirAll(filepath.Dir(finalPath)); err != nil {
+		return "", fmt.Errorf("failed to create directory: %w", err)
+	}
+	staged.filePath = finalPath
+	return finalKey, nil
+}
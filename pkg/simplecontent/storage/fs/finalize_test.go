@@ -0,0 +1,84 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_FinalizeKey(t *testing.T) {
+	var seen simplecontent.ObjectMeta
+	b, err := New(Config{
+		BaseDir: t.TempDir(),
+		FinalizeKey: func(stagedKey string, meta simplecontent.ObjectMeta) (string, error) {
+			seen = meta
+			switch {
+			case strings.HasPrefix(meta.ContentType, "image/"):
+				return "images/" + path.Base(stagedKey), nil
+			case strings.HasPrefix(meta.ContentType, "text/"):
+				return "docs/" + path.Base(stagedKey), nil
+			case meta.Size == 0:
+				return "", errors.New("empty upload")
+			}
+			return stagedKey, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	key, err := backend.UploadFinalized(ctx, strings.NewReader("hello"), simplecontent.UploadParams{ObjectKey: "incoming/a"})
+	if err != nil || key != "docs/a" {
+		t.Fatalf("expected docs/a, got %q: %v", key, err)
+	}
+	if seen.Key != "incoming/a" || seen.Size != 5 {
+		t.Fatalf("unexpected staged metadata %+v", seen)
+	}
+	if got := readObject(t, backend, "docs/a"); got != "hello" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if _, err := os.Stat(mustObjectPath(t, backend, "incoming")); !os.IsNotExist(err) {
+		t.Fatalf("expected staging directory cleaned up")
+	}
+
+	// Declared types are passed through, and plain Upload relocates too
+	key, err = backend.UploadFinalized(ctx, strings.NewReader("x"), simplecontent.UploadParams{ObjectKey: "incoming/b.png", MimeType: "image/png"})
+	if err != nil || key != "images/b.png" {
+		t.Fatalf("expected images/b.png, got %q: %v", key, err)
+	}
+	if err := backend.Upload(ctx, "incoming/c", strings.NewReader("more text")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if got := readObject(t, backend, "docs/c"); got != "more text" {
+		t.Fatalf("unexpected content %q", got)
+	}
+
+	if _, err := backend.UploadFinalized(ctx, strings.NewReader(""), simplecontent.UploadParams{ObjectKey: "incoming/empty"}); err == nil || !strings.Contains(err.Error(), "empty upload") {
+		t.Fatalf("expected finalize error, got %v", err)
+	}
+	if keys := listKeys(t, backend, ""); len(keys) != 3 {
+		t.Fatalf("expected aborted upload to commit nothing, got %v", keys)
+	}
+}
+
+func TestFSBackend_FinalizeKeyInvalid(t *testing.T) {
+	b, err := New(Config{
+		BaseDir: t.TempDir(),
+		FinalizeKey: func(string, simplecontent.ObjectMeta) (string, error) {
+			return "../escape", nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	if err := b.Upload(context.Background(), "k", strings.NewReader("x")); !errors.Is(err, simplecontent.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}
@@ -1,36 +1,172 @@
 package fs
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
-	"net/http"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/url"
 	"os"
+	"path"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/mimetype"
 	"github.com/tendant/simple-content/pkg/simplecontent/presigned"
 )
 
 // Backend is a filesystem implementation of the simplecontent.BlobStore interface
 type Backend struct {
-	mu             sync.RWMutex
-	baseDir        string
-	urlPrefix      string
-	signer         *presigned.Signer // For authenticated presigned upload URLs
-	downloadSigner *presigned.Signer // For authenticated presigned download/preview URLs
-	presignExpires time.Duration     // Default expiration for presigned URLs
+	keyLocks        keyLocks // Serialise writes to the same object
+	baseDir         string
+	urlPrefix       string
+	signer          *presigned.Signer // For authenticated presigned upload URLs
+	downloadSigner  *presigned.Signer // For authenticated presigned download/preview URLs
+	presignExpires  time.Duration     // Default expiration for presigned URLs
+	preferHardlink  bool              // Copy via os.Link when possible
+	keySeparator    string            // Logical key hierarchy separator
+	shardDepth      int               // Levels of hash directories objects are nested under (0 = none)
+	allowPatchGrow  bool              // PatchRange may extend objects
+	checkSize       bool              // Reject uploads that differ from UploadParams.Size
+	timestampSource TimestampSource   // Source of ObjectMeta.CreatedAt
+	codec           string            // Compression codec applied to new uploads
+	decompressExt   bool              // Decode keys by compression extension
+	typeFromExt     bool              // Detect undeclared content types by key extension
+	fileMode        os.FileMode       // Permission bits applied to objects (0 = umask default)
+	dirMode         os.FileMode       // Permission bits applied to created directories (0 = umask default)
+	deferSync       bool              // Track writes for FlushAll
+	syncOnWrite     bool              // fsync each write before it returns
+	syncMu          sync.Mutex
+	unsynced        map[string]struct{} // Object paths written since the last FlushAll
+	quarantineBad   bool                // Quarantine objects failing verification
+	includeHidden   bool                // Enumerate dot-prefixed entries
+	leaseMu         sync.Mutex
+	leases          map[string]string // Lock file paths of leases held by this backend, by lease ID
+	enforceLeases   bool              // Reject uploads to keys leased by other backends
+	readOnly        bool              // Reject every write with ErrReadOnly
+	busyTimeout     time.Duration     // Retry window for replacing objects held open
+	maxDirEntries   int               // Entry limit per directory (0 = unlimited)
+	warnOnDirFull   bool              // Log instead of failing at the limit
+	dirMu           sync.Mutex
+	dirCounts       map[string]*dirCount // Cached entry counts by directory
+	stagingDir      string               // Directory holding staged uploads ("" = next to each object)
+	stagingCopy     bool                 // stagingDir is on another filesystem; staged files are copied next to their object
+	statsTTL        time.Duration        // How long Stats results are reused (0 = not cached)
+	statsMu         sync.Mutex
+	statsCache      map[string]cachedStats // Recent Stats results by prefix
+	objectTTL       time.Duration          // Age at which ExpireObjects deletes objects (0 = never)
+	expiryInterval  time.Duration          // Time between StartExpiry sweeps
+	expiryMu        sync.Mutex
+	expiryStop      context.CancelFunc // Stops the running expiry loop
+	expiryDone      chan struct{}      // Closed once the expiry loop exits
+
+	previews     map[string]PreviewFunc // Preview generators by content type
+	previewType  string                 // Content type recorded for generated previews ("" = detected)
+	cacheControl map[string]string      // Cache-Control policies by content type
+	finalizeKey  FinalizeKeyFunc        // Chooses the committed key of uploads
+	maxSizes     map[string]int64       // Upload size limits by content type
+	maxSize      int64                  // Upload size limit for other types (0 = unlimited)
+	sidecarIndex *sidecarIndex          // Batched metadata indexes (nil = per-object sidecars)
+	packAge      time.Duration          // Minimum age of the objects Compact packs
+	packs        packSet                // Index of packed objects
+	onCollision  KeyCollisionPolicy     // Uploads to keys holding an object
+	logger       *slog.Logger           // Operation log (nil = none)
+	hooks        []EventHook            // Observers of completed operations
+	observer     Observer               // Metrics of transfers (nil = none)
+
+	logFields func(context.Context) []slog.Attr // Request-scoped fields for logs and events (nil = none)
 }
 
 // Config options for the filesystem backend
 type Config struct {
-	BaseDir            string        // Base directory for storing files
-	URLPrefix          string        // Optional URL prefix for download/upload URLs
-	SignatureSecretKey string        // Secret key for signing presigned URLs (optional, enables auth)
-	PresignExpires     time.Duration // Default expiration for presigned URLs (default: 1 hour)
+	BaseDir                    string          // Base directory for storing files
+	URLPrefix                  string          // Optional URL prefix for download/upload URLs
+	SignatureSecretKey         string          // Secret key for signing presigned URLs (optional, enables auth)
+	PresignExpires             time.Duration   // Default expiration for presigned URLs (default: 1 hour)
+	PreviousSignatureSecretKey string          // Previous secret key still accepted after a key rotation (optional)
+	PreviousKeyGracePeriod     time.Duration   // How long after startup the previous key is accepted (default: PresignExpires)
+	PreferHardlink             bool            // Copy identical content via hardlinks on the same filesystem (copies share an inode)
+	KeySeparator               string          // Logical key hierarchy separator mapped to directories (default: "/")
+	ShardDepth                 int             // Levels of directories, named by a hash of the key, objects are nested under so no directory grows huge, e.g. 2 stores "docs/a.txt" at "6b/7b/docs/a.txt"; set it on an empty store (0 = off, at most 4)
+	AllowPatchGrow             bool            // Allow PatchRange to write past the current end of an object
+	DisableSizeCheck           bool            // Accept uploads whose length differs from UploadParams.Size
+	TimestampSource            TimestampSource // Source of ObjectMeta.CreatedAt: mtime (default), ctime or sidecar
+	SkipWriteProbe             bool            // Don't verify at startup that BaseDir is writable
+	Compression                string          // Codec used to compress new uploads: none (default), gzip, zstd or a registered codec
+	EncryptionKey              []byte          // 32-byte key; new uploads are encrypted with AES-256-GCM, after compression (default: no encryption)
+	TransparentDecompress      bool            // Decompress keys ending in .gz or .zst on Download and describe them decompressed
+	FileMode                   os.FileMode     // Exact permission bits for stored objects, regardless of umask (default: 0666 less umask)
+	DirMode                    os.FileMode     // Exact permission bits for directories created for objects, regardless of umask; must include 0700 (default: 0755 less umask)
+	DeferSync                  bool            // Track written objects so FlushAll can fsync them as a batch
+	SyncOnWrite                bool            // fsync each written object, its sidecar and its directory before the write returns (default: false, durability is left to the filesystem)
+	QuarantineOnCorruption     bool            // Move objects failing checksum verification under .quarantine
+	IncludeHidden              bool            // List dot-prefixed files and directories (internal directories are always skipped)
+	KeyPrefix                  string          // Key prefix prepended to every key on storage and stripped from listings (e.g. "app-v2/")
+	BusyTimeout                time.Duration   // How long writes retry replacing an object another process holds open before ErrObjectBusy (Windows only)
+	EnforceLeases              bool            // Fail uploads to a key another backend holds a live lease on with ErrObjectBusy (default: leases are advisory)
+	ReadOnly                   bool            // Fail uploads, deletes and every other write with ErrReadOnly; BaseDir must already exist and is never modified
+	MaxDirEntries              int             // Entries allowed per directory before uploads of new keys fail with ErrDirectoryFull (0 = unlimited)
+	WarnOnDirFull              bool            // Log a warning to Logger instead of failing when MaxDirEntries is exceeded
+	StagingDir                 string          // Directory for in-progress uploads, relative to BaseDir or absolute; on another filesystem, such as a tmpfs, completed uploads are copied next to their object to be renamed into place (default: next to each object)
+	StatsCacheTTL              time.Duration   // How long Stats reuses the result of a walk for the same prefix (0 = walk on every call)
+	ObjectTTL                  time.Duration   // Time since an object was last written after which ExpireObjects and StartExpiry delete it (0 = never)
+	ExpiryInterval             time.Duration   // Time between the sweeps of StartExpiry (default: 1 minute)
+	MaxObjectSize              int64           // Largest upload accepted, in bytes, when MaxSizeByContentType has no match (0 = unlimited)
+	PreviewContentType         string          // Content type of previews generated by Previews, e.g. "image/webp" (default: detected from the preview)
+	SidecarIndex               bool            // Batch object metadata into one index file per directory instead of a sidecar per object (see FlushMetadata)
+	SidecarIndexBatch          int             // Pending metadata entries that trigger a write of the indexes (default: 256)
+	PackAge                    time.Duration   // Time since an object was last written before Compact packs it; objects must be write-once (see Compact)
+	Logger                     *slog.Logger    // Logs uploads, downloads and deletes at debug level, their failures at error level and WarnOnDirFull warnings (default: no logging)
+	EventHooks                 []EventHook     // Called after each upload, download and delete
+	Observer                   Observer        // Receives the bytes and latency of each upload, download and delete, e.g. for metrics (default: none)
+
+	// Previews maps content types ("image/png") or wildcards ("image/*") to
+	// the generators GeneratePreview uses
+	Previews map[string]PreviewFunc
+
+	// CacheControlByContentType maps content types ("application/json") or
+	// wildcards ("image/*") to the Cache-Control policy GetObjectMeta reports
+	// for matching objects, e.g. "public, max-age=31536000, immutable"
+	CacheControlByContentType map[string]string
+
+	// FinalizeKey, when set, chooses the key each upload is committed under
+	// after its content has been staged (see UploadFinalized)
+	FinalizeKey FinalizeKeyFunc
+
+	// MaxSizeByContentType maps content types ("application/pdf") or
+	// wildcards ("image/*") to the largest upload accepted for them, in
+	// bytes. Uploads are matched by declared type, or by the type detected
+	// from their leading bytes. The limits apply to every method that writes
+	// an object; the parts of a multipart upload are held to the largest.
+	MaxSizeByContentType map[string]int64
+
+	// LogFieldsFromContext extracts request-scoped fields, such as a
+	// request ID or user, that are added to Logger records and passed to
+	// EventHooks as Event.Fields. It is only called when a Logger or hook
+	// is configured.
+	LogFieldsFromContext func(ctx context.Context) []slog.Attr
+
+	// OnKeyCollision selects what uploads to a key that already holds an
+	// object do: replace it (default), fail with ErrObjectExists, or commit
+	// under a suffixed key. UploadWithResult and UploadFinalized report the
+	// key used.
+	OnKeyCollision KeyCollisionPolicy
+
+	// DetectContentTypeFromExtension reports the content type of objects
+	// uploaded without one from their key's extension (".json", ".png"),
+	// opening them to sniff their content only when the extension is
+	// unknown
+	DetectContentTypeFromExtension bool
 }
 
 // New creates a new filesystem storage backend
@@ -40,159 +176,856 @@ func New(config Config) (simplecontent.BlobStore, error) {
 		return nil, errors.New("base directory is required")
 	}
 
-	if err := os.MkdirAll(config.BaseDir, 0755); err != nil {
+	if config.ReadOnly {
+		if err := checkBaseDir(config.BaseDir); err != nil {
+			return nil, err
+		}
+	} else if err := os.MkdirAll(config.BaseDir, 0755); err != nil {
 		return nil, fmt.Errorf("failed to create base directory: %w", err)
 	}
 
+	// Surface permission problems and read-only mounts at startup rather
+	// than on the first upload
+	if !config.SkipWriteProbe && !config.ReadOnly {
+		if err := probeWritable(config.BaseDir); err != nil {
+			return nil, err
+		}
+	}
+
 	// Set default presign expiration
 	presignExpires := config.PresignExpires
 	if presignExpires == 0 {
 		presignExpires = 1 * time.Hour // Default: 1 hour
 	}
 
+	timestampSource := config.TimestampSource
+	if timestampSource == "" {
+		timestampSource = TimestampMtime
+	}
+	if err := timestampSource.validate(); err != nil {
+		return nil, err
+	}
+
+	codec := config.Compression
+	if codec == "" {
+		codec = CodecNone
+	}
+	if _, err := lookupCodec(codec); err != nil {
+		return nil, err
+	}
+	if len(config.EncryptionKey) > 0 {
+		name, encryption, err := newEncryptionCodec(config.EncryptionKey)
+		if err != nil {
+			return nil, err
+		}
+		RegisterCodec(name, encryption)
+		if codec == CodecNone {
+			codec = name
+		} else {
+			codec += "+" + name
+		}
+	}
+
+	if config.ExpiryInterval < 0 {
+		return nil, errors.New("expiry interval must not be negative")
+	}
+
+	onCollision := config.OnKeyCollision
+	if onCollision == "" {
+		onCollision = KeyCollisionReplace
+	}
+	if err := onCollision.validate(); err != nil {
+		return nil, err
+	}
+	if err := validateShardDepth(config.ShardDepth); err != nil {
+		return nil, err
+	}
+
+	if mode := config.DirMode.Perm(); mode != 0 && mode&0700 != 0700 {
+		return nil, fmt.Errorf("dir mode %o must grant the owner read, write and search permission", mode)
+	}
+
+	keySeparator := config.KeySeparator
+	if keySeparator == "" {
+		keySeparator = "/"
+	}
+
 	backend := &Backend{
-		baseDir:        config.BaseDir,
-		urlPrefix:      config.URLPrefix,
-		presignExpires: presignExpires,
+		baseDir:         filepath.Clean(config.BaseDir),
+		urlPrefix:       config.URLPrefix,
+		presignExpires:  presignExpires,
+		preferHardlink:  config.PreferHardlink,
+		keySeparator:    keySeparator,
+		allowPatchGrow:  config.AllowPatchGrow,
+		checkSize:       !config.DisableSizeCheck,
+		timestampSource: timestampSource,
+		codec:           codec,
+		decompressExt:   config.TransparentDecompress,
+		typeFromExt:     config.DetectContentTypeFromExtension,
+		fileMode:        config.FileMode.Perm(),
+		dirMode:         config.DirMode.Perm(),
+		deferSync:       config.DeferSync,
+		syncOnWrite:     config.SyncOnWrite,
+		unsynced:        make(map[string]struct{}),
+		quarantineBad:   config.QuarantineOnCorruption,
+		includeHidden:   config.IncludeHidden,
+		leases:          make(map[string]string),
+		enforceLeases:   config.EnforceLeases,
+		readOnly:        config.ReadOnly,
+		busyTimeout:     config.BusyTimeout,
+		maxDirEntries:   config.MaxDirEntries,
+		warnOnDirFull:   config.WarnOnDirFull,
+		dirCounts:       make(map[string]*dirCount),
+		statsTTL:        config.StatsCacheTTL,
+		statsCache:      make(map[string]cachedStats),
+		objectTTL:       config.ObjectTTL,
+		expiryInterval:  cmp.Or(config.ExpiryInterval, defaultExpiryInterval),
+		previews:        config.Previews,
+		previewType:     config.PreviewContentType,
+		cacheControl:    config.CacheControlByContentType,
+		finalizeKey:     config.FinalizeKey,
+		maxSizes:        config.MaxSizeByContentType,
+		maxSize:         config.MaxObjectSize,
+		packAge:         config.PackAge,
+		onCollision:     onCollision,
+		logger:          config.Logger,
+		hooks:           config.EventHooks,
+		observer:        config.Observer,
+		logFields:       config.LogFieldsFromContext,
+	}
+	if config.SidecarIndex {
+		backend.sidecarIndex = newSidecarIndex(config.SidecarIndexBatch)
 	}
 
+	// Nothing is staged without writes
+	if config.StagingDir != "" && !config.ReadOnly {
+		stagingDir := config.StagingDir
+		if !filepath.IsAbs(stagingDir) {
+			stagingDir = filepath.Join(backend.baseDir, stagingDir)
+		}
+		sameDevice, err := checkStagingDir(stagingDir, backend.baseDir)
+		if err != nil {
+			return nil, err
+		}
+		backend.stagingDir = filepath.Clean(stagingDir)
+		backend.stagingCopy = !sameDevice
+	}
+
+	// Namespace every key by rooting the backend at the prefix directory, so
+	// keys are stored under it and listed relative to it, and cannot
+	// traverse out of it
+	if prefix := strings.Trim(config.KeyPrefix, keySeparator); prefix != "" {
+		dir, err := backend.dirPath(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key prefix %q: %w", config.KeyPrefix, err)
+		}
+		if backend.readOnly {
+			if err := checkBaseDir(dir); err != nil {
+				return nil, err
+			}
+		} else if err := backend.mkdirAll(dir); err != nil {
+			return nil, fmt.Errorf("failed to create key prefix directory: %w", err)
+		}
+		backend.baseDir = dir
+	}
+	// Set after rooting, so the prefix directory itself is not sharded
+	backend.shardDepth = config.ShardDepth
+
 	// Initialize presigned signers if secret key is provided
 	if config.SignatureSecretKey != "" {
+		var rotation []presigned.Option
+		if config.PreviousSignatureSecretKey != "" {
+			grace := config.PreviousKeyGracePeriod
+			if grace == 0 {
+				grace = presignExpires
+			}
+			rotation = append(rotation, presigned.WithGraceKey(config.PreviousSignatureSecretKey, time.Now().Add(grace)))
+		}
+
 		// Upload signer (PUT method)
-		backend.signer = presigned.New(
+		backend.signer = presigned.New(append([]presigned.Option{
 			presigned.WithSecretKey(config.SignatureSecretKey),
 			presigned.WithDefaultExpiration(presignExpires),
 			presigned.WithURLPattern("/upload/{key}"),
-		)
+		}, rotation...)...)
 
-		// Download signer (GET method)
-		backend.downloadSigner = presigned.New(
+		// Download and preview signer (GET method)
+		backend.downloadSigner = presigned.New(append([]presigned.Option{
 			presigned.WithSecretKey(config.SignatureSecretKey),
 			presigned.WithDefaultExpiration(presignExpires),
-			presigned.WithURLPattern("/download/{key}"),
-		)
+			presigned.WithURLPatterns("/download/{key}", "/preview/{key}"),
+		}, rotation...)...)
 	}
 
 	return backend, nil
 }
 
-// GetObjectMeta retrieves metadata for an object in the filesystem
-func (b *Backend) GetObjectMeta(ctx context.Context, objectKey string) (*simplecontent.ObjectMeta, error) {
-	b.mu.RLock()
-	defer b.mu.RUnlock()
+// Exists reports whether an object is stored, using a single stat. A
+// missing file reports false; only failures such as permission or I/O errors
+// are returned.
+func (b *Backend) Exists(ctx context.Context, objectKey string) (_ bool, err error) {
+	defer wrapError(&err, "exists", objectKey)
 
-	filePath := filepath.Join(b.baseDir, objectKey)
+	filePath, err := b.readPath(objectKey)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		entry, err := b.packed(filePath)
+		return entry != nil, err
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get file info: %w", err)
+	}
+	return info.Mode().IsRegular(), nil
+}
+
+// GetObjectMeta retrieves metadata for an object in the filesystem,
+// including the user metadata supplied with UploadParams.Metadata and, in
+// Metadata["sha256"], the checksum recorded at upload.
+// For an alias (see CreateAlias) it describes the target object, whose key
+// it reports.
+func (b *Backend) GetObjectMeta(ctx context.Context, objectKey string) (_ *simplecontent.ObjectMeta, err error) {
+	defer wrapError(&err, "get_object_meta", objectKey)
+
+	return b.objectMeta(objectKey, true)
+}
+
+// StatObject is a cheaper GetObjectMeta for existence and size checks: it
+// reads only the file's attributes and sidecar, never opening the object.
+// ContentType is therefore the declared type, left empty rather than
+// detected, and objects served decompressed (see DecompressExtensions)
+// report their stored size. Metadata holds only the user metadata.
+func (b *Backend) StatObject(ctx context.Context, objectKey string) (_ *simplecontent.ObjectMeta, err error) {
+	defer wrapError(&err, "stat_object", objectKey)
+
+	return b.objectMeta(objectKey, false)
+}
 
-	// Check if file exists
+// objectMeta implements GetObjectMeta and, without describe, StatObject
+func (b *Backend) objectMeta(objectKey string, describe bool) (*simplecontent.ObjectMeta, error) {
+	filePath, err := b.readPath(objectKey)
+	if err != nil {
+		return nil, err
+	}
+	defer b.keyLocks.rlock(filePath)()
+
+	// Check if file exists, or else look in the packs and follow an alias
 	info, err := os.Stat(filePath)
 	if os.IsNotExist(err) {
-		return nil, errors.New("object not found")
+		if meta, ok, err := b.packedMeta(objectKey, filePath, describe); ok || err != nil {
+			return meta, err
+		}
+		if objectKey, filePath, err = b.resolveAlias(objectKey); err != nil {
+			return nil, err
+		}
+		info, err = os.Stat(filePath)
+	}
+	if os.IsNotExist(err) {
+		if meta, ok, err := b.packedMeta(objectKey, filePath, describe); ok || err != nil {
+			return meta, err
+		}
+		return nil, simplecontent.ErrObjectNotFound
 	} else if err != nil {
 		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	// Detect content type
-	contentType := "application/octet-stream"
-	if file, err := os.Open(filePath); err == nil {
-		defer file.Close()
-		buffer := make([]byte, 512)
-		if n, err := file.Read(buffer); err == nil {
-			contentType = http.DetectContentType(buffer[:n])
+	sc, err := b.loadSidecar(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if sc.reserved() {
+		return nil, simplecontent.ErrObjectNotFound
+	}
+
+	meta := b.fileMeta(objectKey, info, sc)
+	if !describe {
+		return &meta, nil
+	}
+	if err := b.describeObject(&meta, filePath, sc); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+// describeObject completes metadata built by fileMeta with what
+// GetObjectMeta reports beyond it. The object is only opened to detect its
+// content type when none was declared (or, with
+// DetectContentTypeFromExtension, none is known for the key's extension),
+// or to describe it decompressed.
+func (b *Backend) describeObject(meta *simplecontent.ObjectMeta, filePath string, sc *sidecar) error {
+	// Use the declared content type, or detect it from the key's extension
+	// or the original (decoded) content
+	meta.ContentType = sc.ContentType
+	if meta.ContentType == "" && b.typeFromExt {
+		meta.ContentType = mime.TypeByExtension(path.Ext(meta.Key))
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = sniffContentType(filePath, sc.Codec)
+	}
+	if codec := b.extensionCodec(meta.Key); codec != "" {
+		if err := b.describeDecompressed(meta, filePath, sc.Codec, codec); err != nil {
+			return err
 		}
 	}
+	if meta.Metadata == nil {
+		meta.Metadata = make(map[string]string)
+	}
+	meta.Metadata["content_type"] = meta.ContentType
+	if sc.SHA256 != "" {
+		meta.Metadata["sha256"] = sc.SHA256
+	}
+	meta.CacheControl = matchContentType(b.cacheControl, meta.ContentType)
+	return nil
+}
+
+// sniffContentType detects the content type of an object from its leading
+// (decoded) bytes
+func sniffContentType(filePath, codec string) string {
+	if head := readHead(filePath, codec); len(head) > 0 {
+		return mimetype.Detect(head)
+	}
+	return "application/octet-stream"
+}
+
+// readHead returns up to mimetype.SniffLen leading bytes of an object's
+// decoded content, or nil when it cannot be read
+func readHead(filePath, codec string) []byte {
+	file, err := openObject(filePath)
+	if err != nil {
+		return nil
+	}
+	content, err := openDecoded(file, codec)
+	if err != nil {
+		return nil
+	}
+	defer content.Close()
+
+	buffer := make([]byte, mimetype.SniffLen)
+	n, err := io.ReadFull(content, buffer)
+	if err != nil && err != io.ErrUnexpectedEOF {
+		return nil
+	}
+	return buffer[:n]
+}
+
+// contentSize returns the size of an object's decoded content: the stored
+// size, or the size recorded before at-rest compression
+func contentSize(info os.FileInfo, sc *sidecar) int64 {
+	if sc.Codec != "" {
+		return sc.Size
+	}
+	return info.Size()
+}
 
-	meta := &simplecontent.ObjectMeta{
+// fileMeta builds the metadata of an object from its file info and sidecar,
+// without opening the object itself. ContentType is only set when it was
+// declared at upload.
+func (b *Backend) fileMeta(objectKey string, info os.FileInfo, sc *sidecar) simplecontent.ObjectMeta {
+	return simplecontent.ObjectMeta{
 		Key:         objectKey,
-		Size:        info.Size(),
-		ContentType: contentType,
+		Size:        contentSize(info, sc),
+		ContentType: sc.ContentType,
+		CreatedAt:   b.createdAt(info, sc),
 		UpdatedAt:   info.ModTime(),
-		Metadata:    map[string]string{"content_type": contentType},
+		ETag:        fileETag(info),
+		Metadata:    maps.Clone(sc.Metadata),
 	}
-
-	return meta, nil
 }
 
 // GetUploadURL returns a URL for uploading content
 // When urlPrefix is configured, returns a URL that can be used for presigned-style uploads
 // This allows testing presigned upload workflows locally with filesystem storage
 // If SignatureSecretKey is configured, the URL will be signed with HMAC for security
-func (b *Backend) GetUploadURL(ctx context.Context, objectKey string) (string, error) {
+func (b *Backend) GetUploadURL(ctx context.Context, objectKey string) (_ string, err error) {
+	defer wrapError(&err, "get_upload_url", objectKey)
+
 	if b.urlPrefix == "" {
 		return "", errors.New("direct upload required for filesystem backend")
 	}
 
+	return b.uploadURL(b.urlPrefix, objectKey)
+}
+
+// GetUploadURLWithBase is GetUploadURL with baseURL in place of the configured
+// URLPrefix, for serving the same objects through several hostnames.
+// Signatures cover only the path, so the URL is valid on any of them.
+func (b *Backend) GetUploadURLWithBase(ctx context.Context, baseURL, objectKey string) (_ string, err error) {
+	defer wrapError(&err, "get_upload_url", objectKey)
+
+	if baseURL == "" {
+		return "", errors.New("base URL is required")
+	}
+	return b.uploadURL(strings.TrimSuffix(baseURL, "/"), objectKey)
+}
+
+func (b *Backend) uploadURL(baseURL, objectKey string) (string, error) {
 	path := "/upload/" + objectKey
 
 	// If signer is configured, generate signed URL
 	if b.signer != nil {
-		return b.signer.SignURLWithBase(b.urlPrefix, "PUT", path, b.presignExpires)
+		return b.signer.SignURLWithBase(baseURL, "PUT", path, b.presignExpires)
 	}
 
 	// Otherwise, return unsigned URL (for backward compatibility)
-	return b.urlPrefix + path, nil
+	return baseURL + path, nil
 }
 
 // Upload uploads content directly to the filesystem
-func (b *Backend) Upload(ctx context.Context, objectKey string, reader io.Reader) error {
-	filePath := filepath.Join(b.baseDir, objectKey)
+func (b *Backend) Upload(ctx context.Context, objectKey string, reader io.Reader) (err error) {
+	var result UploadResult
+	defer b.observe(ctx, "upload", objectKey, time.Now(), &err)
+	defer b.observeUpload(objectKey, time.Now(), &result.Size, &err)
+	defer wrapError(&err, "upload", objectKey)
+
+	result, err = b.upload(ctx, reader, simplecontent.UploadParams{ObjectKey: objectKey})
+	return err
+}
+
+// UploadWithParams uploads content with additional parameters
+// params.MimeType, when set, is recorded and reported by GetObjectMeta instead
+// of the detected type.
+// If reader fails partway, nothing is committed and the error wraps
+// simplecontent.ErrSourceRead, distinguishing a bad or disconnected client
+// from a storage failure.
+// When params.Size is set, the upload fails with simplecontent.ErrSizeMismatch
+// if the reader delivers a different number of bytes (unless DisableSizeCheck).
+// When params.SHA256 is set, the upload fails with
+// simplecontent.ErrChecksumMismatch if the content hashes differently.
+// Uploads over MaxSizeByContentType or MaxObjectSize fail with
+// simplecontent.ErrObjectTooLarge.
+// With params.IfNotExists, uploads to a key holding an object fail with
+// simplecontent.ErrObjectExists whatever OnKeyCollision is. The key is
+// claimed atomically when committed, so of racing uploads one wins.
+func (b *Backend) UploadWithParams(ctx context.Context, reader io.Reader, params simplecontent.UploadParams) (err error) {
+	var result UploadResult
+	defer b.observe(ctx, "upload_with_params", params.ObjectKey, time.Now(), &err)
+	defer b.observeUpload(params.ObjectKey, time.Now(), &result.Size, &err)
+	defer wrapError(&err, "upload_with_params", params.ObjectKey)
+
+	result, err = b.upload(ctx, reader, params)
+	return err
+}
+
+// upload streams reader into a temporary file next to the object and renames
+// it into place once all bytes have been written and checked. The result
+// holds the key the object was committed under, which FinalizeKey may have
+// changed, and the size and SHA-256 of the content.
+func (b *Backend) upload(ctx context.Context, reader io.Reader, params simplecontent.UploadParams) (UploadResult, error) {
+	return b.uploadIf(ctx, reader, params, false)
+}
+
+// uploadIf uploads like upload. With ifChanged, content identical to the
+// object already stored where the upload would be committed is discarded
+// instead, and errUnchanged is returned.
+func (b *Backend) uploadIf(ctx context.Context, reader io.Reader, params simplecontent.UploadParams, ifChanged bool) (UploadResult, error) {
+	if err := b.checkWritable(); err != nil {
+		return UploadResult{}, err
+	}
+	policy := b.collisionPolicy(params)
+	filePath, err := b.prepareWrite(params.ObjectKey, policy)
+	if err != nil {
+		return UploadResult{}, err
+	}
+
+	// Read at most one byte past the declared size so over-long uploads
+	// are detected without consuming the rest of the stream
+	var verify func(written int64, sum string) error
+	if b.checkSize && params.Size > 0 {
+		reader = io.LimitReader(reader, params.Size+1)
+		verify = func(written int64, _ string) error {
+			if written > params.Size {
+				return fmt.Errorf("%w: expected %d bytes, got more", simplecontent.ErrSizeMismatch, params.Size)
+			} else if written != params.Size {
+				return fmt.Errorf("%w: expected %d bytes, got %d", simplecontent.ErrSizeMismatch, params.Size, written)
+			}
+			return nil
+		}
+	}
+
+	// Check the expected checksum, then record what was written
+	result := UploadResult{Key: params.ObjectKey}
+	next := verify
+	verify = func(written int64, sum string) error {
+		if next != nil {
+			if err := next(written, sum); err != nil {
+				return err
+			}
+		}
+		if params.SHA256 != "" && !strings.EqualFold(sum, params.SHA256) {
+			return fmt.Errorf("%w: expected sha256 %s, got %s", simplecontent.ErrChecksumMismatch, params.SHA256, sum)
+		}
+		result.Size, result.SHA256 = written, sum
+		return nil
+	}
+
+	staged, err := b.stageObject(filePath, contextReader{ctx: ctx, r: reader}, params, verify)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return UploadResult{}, ctxErr
+	} else if err != nil {
+		return UploadResult{}, err
+	}
+	staged.written.Metadata = params.Metadata
+	if b.finalizeKey != nil {
+		if result.Key, err = b.finalize(staged, params.ObjectKey); err != nil {
+			staged.discard()
+			return UploadResult{}, err
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		staged.discard()
+		return UploadResult{}, err
+	}
+	if ifChanged {
+		if err := b.checkChanged(staged); err != nil {
+			staged.discard()
+			return UploadResult{}, err
+		}
+	}
+	if result.Key, err = b.commitKey(staged, result.Key, policy); err != nil {
+		return UploadResult{}, err
+	}
+	if result.Key != params.ObjectKey {
+		b.cleanupEmptyDirectories(filepath.Dir(filePath))
+	}
+	return result, nil
+}
+
+// prepareWrite returns the path of objectKey, first refusing a write that
+// could not be committed: when its directory is full, another backend holds
+// a lease on the key or, with collisions as errors under policy, an object
+// already holds it. Every method writing an object calls it before staging
+// any bytes.
+func (b *Backend) prepareWrite(objectKey string, policy KeyCollisionPolicy) (string, error) {
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return "", err
+	}
+	if err := b.checkDirEntries(filePath); err != nil {
+		return "", err
+	}
+	if err := b.checkLease(filePath); err != nil {
+		return "", err
+	}
+	if err := b.checkCollision(objectKey, filePath, policy); err != nil {
+		return "", err
+	}
+	return filePath, nil
+}
+
+// writeObject encodes reader with the configured codec into a temporary file
+// next to filePath and renames it into place, recording the SHA-256 of the
+// original content and the declared params.MimeType, if any, in the sidecar.
+// verify, when set, is called with the number of bytes read and their hex
+// SHA-256 before the object is committed; an error from verify discards the
+// staged file. Cancelling ctx stops the copy, discards the staged file and
+// returns the context's error.
+func (b *Backend) writeObject(ctx context.Context, filePath string, reader io.Reader, params simplecontent.UploadParams, verify func(written int64, sum string) error) error {
+	staged, err := b.stageObject(filePath, contextReader{ctx: ctx, r: reader}, params, verify)
+	if ctxErr := ctx.Err(); err != nil && ctxErr != nil {
+		return ctxErr
+	} else if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		staged.discard()
+		return err
+	}
+	return b.commitObject(staged)
+}
+
+// stagedObject is content written to a temporary file next to its object,
+// waiting to be committed
+type stagedObject struct {
+	filePath string
+	tmpPath  string
+	written  sidecar // Checksum, content type, codec and size of the content
+}
+
+// discard removes the staged file
+func (s *stagedObject) discard() {
+	os.Remove(s.tmpPath)
+}
+
+// stageObject writes reader, encoded with the configured codec, to a
+// temporary file next to filePath, or in StagingDir when configured. See
+// writeObject for params and verify.
+// Every write is staged here, so this is where MaxObjectSize and
+// MaxSizeByContentType are enforced: content over the limit for the key
+// and params.MimeType fails with simplecontent.ErrObjectTooLarge.
+// A StagingDir on another filesystem only holds the file while it is
+// written; the staged object is then a copy next to filePath.
+func (b *Backend) stageObject(filePath string, reader io.Reader, params simplecontent.UploadParams, verify func(written int64, sum string) error) (*stagedObject, error) {
+	codec, err := lookupCodec(b.codec)
+	if err != nil {
+		return nil, err
+	}
+	if reader, verify, err = b.limitSize(reader, params, verify); err != nil {
+		return nil, err
+	}
 
 	// Create directory structure if it doesn't exist
 	dir := filepath.Dir(filePath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %w", err)
+	if err := b.mkdirAll(dir); err != nil {
+		return nil, fmt.Errorf("failed to create directory: %w", err)
 	}
 
-	// Create file
-	file, err := os.Create(filePath)
+	// Create temporary file
+	file, err := createTemp(b.stagingPath(filePath))
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	tmpPath := file.Name()
+	fail := func(err error) (*stagedObject, error) {
+		file.Close()
+		os.Remove(tmpPath)
+		return nil, err
 	}
-	defer file.Close()
 
-	// Copy data from reader to file
-	_, err = io.Copy(file, reader)
-	if err != nil {
-		return fmt.Errorf("failed to write file: %w", err)
+	var dst io.Writer = file
+	var enc io.WriteCloser
+	if codec != nil {
+		if enc, err = codec.NewWriter(file); err != nil {
+			return fail(fmt.Errorf("failed to create %s stream: %w", b.codec, err))
+		}
+		dst = enc
 	}
 
+	// Copy data from reader to file, hashing it in the same pass
+	hash := sha256.New()
+	src := &sourceReader{r: reader}
+	written, err := io.Copy(io.MultiWriter(dst, hash), src)
+	if src.err != nil {
+		return fail(fmt.Errorf("%w: %w", simplecontent.ErrSourceRead, src.err))
+	} else if err != nil {
+		return fail(fmt.Errorf("failed to write file: %w", err))
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if verify != nil {
+		if err := verify(written, sum); err != nil {
+			return fail(err)
+		}
+	}
+	if enc != nil {
+		if err := enc.Close(); err != nil {
+			return fail(fmt.Errorf("failed to write file: %w", err))
+		}
+	}
+
+	if b.syncOnWrite {
+		if err := file.Sync(); err != nil {
+			return fail(fmt.Errorf("failed to sync file: %w", err))
+		}
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, fmt.Errorf("failed to write file: %w", err)
+	}
+	if b.stagingCopy {
+		if tmpPath, err = copyStaged(tmpPath, filePath, b.syncOnWrite); err != nil {
+			return nil, err
+		}
+	}
+	if err := chmodTemp(tmpPath, b.fileMode); err != nil {
+		return nil, err
+	}
+
+	return &stagedObject{
+		filePath: filePath,
+		tmpPath:  tmpPath,
+		written:  sidecar{SHA256: sum, ContentType: params.MimeType, Codec: b.codec, Size: written},
+	}, nil
+}
+
+// sourceReader records the error of the reader supplying an upload, so it
+// can be told apart from a failure writing the staged file. Errors from an
+// UploadTee sink are not the source's and are not recorded.
+type sourceReader struct {
+	r   io.Reader
+	err error
+}
+
+func (s *sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	var sinkErr *sinkError
+	if err != nil && err != io.EOF && !errors.As(err, &sinkErr) {
+		s.err = err
+	}
+	return n, err
+}
+
+// commitObject renames a staged file into place and records its sidecar
+func (b *Backend) commitObject(staged *stagedObject) error {
+	defer b.keyLocks.lock(staged.filePath)()
+
+	var created bool
+	if b.maxDirEntries > 0 {
+		_, err := os.Lstat(staged.filePath)
+		created = os.IsNotExist(err)
+	}
+	err := b.recordWrite(staged.filePath, staged.tmpPath, staged.written, func() error {
+		return replaceFile(staged.tmpPath, staged.filePath, b.busyTimeout)
+	})
+	if err != nil {
+		staged.discard()
+		return err
+	}
+	if created {
+		b.addDirEntry(staged.filePath)
+	}
 	return nil
 }
 
-// UploadWithParams uploads content with additional parameters
-func (b *Backend) UploadWithParams(ctx context.Context, reader io.Reader, params simplecontent.UploadParams) error {
-	// For filesystem, we don't store MIME type separately, it's detected on read
-	return b.Upload(ctx, params.ObjectKey, reader)
+// recordWrite runs place, which renames or links dataPath, the new file of
+// the object at filePath, into place, and updates the object's sidecar with
+// the checksum, content type, user metadata, codec and original size
+// described by written, marking a reservation of the key as filled. With
+// TimestampSidecar it also records the creation time, keeping the time
+// recorded when the key was first written. See placeObject for how the
+// sidecar is kept in step with the data.
+func (b *Backend) recordWrite(filePath, dataPath string, written sidecar, place func() error) error {
+	sc, err := b.loadSidecar(filePath)
+	if err != nil {
+		return err
+	}
+
+	next := *sc
+	next.SHA256, next.ContentType = written.SHA256, written.ContentType
+	next.Metadata = written.Metadata
+	next.Codec, next.Size = "", 0
+	next.ReservedUntil = time.Time{}
+	if written.Codec != "" && written.Codec != CodecNone {
+		next.Codec, next.Size = written.Codec, written.Size
+	}
+	if b.timestampSource == TimestampSidecar && next.CreatedAt.IsZero() {
+		next.CreatedAt = time.Now().UTC()
+	}
+	b.trackUnsynced(filePath)
+	return b.placeObject(filePath, dataPath, &next, !reflect.DeepEqual(next, *sc), place)
+}
+
+// placeObject runs place to move dataPath to filePath and records sc as the
+// object's sidecar when changed. A sidecar file is staged before the data
+// is moved and committed after it (see stageSidecar), so a crash in between
+// leaves metadata that describes the object's content. Metadata batched by
+// SidecarIndex is stored once the data is in place. With SyncOnWrite the
+// sidecar and the directory entries are made durable before it returns.
+func (b *Backend) placeObject(filePath, dataPath string, sc *sidecar, changed bool, place func() error) error {
+	if b.sidecarIndex != nil || !changed && !hasStagedSidecar(filePath) {
+		if err := place(); err != nil {
+			return err
+		}
+		if changed {
+			if err := b.storeSidecar(filePath, sc); err != nil {
+				return err
+			}
+		}
+		return b.syncWrite(filePath)
+	}
+
+	if err := settleStagedSidecar(filePath); err != nil {
+		return err
+	}
+	if err := stageSidecar(filePath, dataPath, sc, b.syncOnWrite); err != nil {
+		return err
+	}
+	if err := place(); err != nil {
+		_ = removeStagedSidecar(filePath)
+		return err
+	}
+	if err := commitSidecar(filePath, sc); err != nil {
+		return err
+	}
+	return b.syncWrite(filePath)
 }
 
 // GetDownloadURL returns a URL for downloading content
-func (b *Backend) GetDownloadURL(ctx context.Context, objectKey string, downloadFilename string) (string, error) {
+func (b *Backend) GetDownloadURL(ctx context.Context, objectKey string, downloadFilename string) (_ string, err error) {
+	defer wrapError(&err, "get_download_url", objectKey)
+
 	if b.urlPrefix == "" {
 		return "", errors.New("direct download required for filesystem backend")
 	}
 
-	path := "/download/" + objectKey
+	return b.downloadURL(b.urlPrefix, objectKey, DownloadOptions{Filename: downloadFilename})
+}
+
+// DownloadOptions controls the response served for a download URL
+type DownloadOptions struct {
+	Filename    string // Name offered in Content-Disposition, if any
+	ContentType string // Served as Content-Type in place of the stored type, if set
+	Inline      bool   // Serve with an inline rather than attachment disposition
+}
 
-	// Add filename to path if provided (will be included in signature)
-	if downloadFilename != "" {
-		path = path + "?filename=" + downloadFilename
+// GetDownloadURLWithOptions is GetDownloadURL with control over the
+// response headers, for correcting the type of objects stored with a generic
+// one without uploading them again. The options are covered by the URL's
+// signature.
+func (b *Backend) GetDownloadURLWithOptions(ctx context.Context, objectKey string, opts DownloadOptions) (_ string, err error) {
+	defer wrapError(&err, "get_download_url", objectKey)
+
+	if b.urlPrefix == "" {
+		return "", errors.New("direct download required for filesystem backend")
+	}
+	if opts.ContentType != "" {
+		if _, _, err := mime.ParseMediaType(opts.ContentType); err != nil {
+			return "", fmt.Errorf("invalid download content type %q: %w", opts.ContentType, err)
+		}
+	}
+	return b.downloadURL(b.urlPrefix, objectKey, opts)
+}
+
+// GetDownloadURLWithBase is GetDownloadURL with baseURL in place of the
+// configured URLPrefix, for serving the same objects through several
+// hostnames. Signatures cover only the path, so the URL is valid on any of
+// them.
+func (b *Backend) GetDownloadURLWithBase(ctx context.Context, baseURL, objectKey, downloadFilename string) (_ string, err error) {
+	defer wrapError(&err, "get_download_url", objectKey)
+
+	if baseURL == "" {
+		return "", errors.New("base URL is required")
 	}
+	return b.downloadURL(strings.TrimSuffix(baseURL, "/"), objectKey, DownloadOptions{Filename: downloadFilename})
+}
+
+func (b *Backend) downloadURL(baseURL, objectKey string, opts DownloadOptions) (string, error) {
+	// Point URLs for aliases at their target
+	if filePath, err := b.objectPath(objectKey); err != nil {
+		return "", err
+	} else if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		target, _, err := b.resolveAlias(objectKey)
+		if err == nil {
+			objectKey = target
+		} else if !errors.Is(err, simplecontent.ErrObjectNotFound) {
+			return "", err
+		}
+	}
+
+	// Options are added to the path, so are included in the signature
+	path := downloadURLPath(objectKey, opts)
 
 	// If signer is configured, generate signed URL
 	if b.downloadSigner != nil {
-		return b.downloadSigner.SignURLWithBase(b.urlPrefix, "GET", path, b.presignExpires)
+		return b.downloadSigner.SignURLWithBase(baseURL, "GET", path, b.presignExpires)
 	}
 
 	// Otherwise, return unsigned URL (backward compatibility)
-	return b.urlPrefix + path, nil
+	return baseURL + path, nil
 }
 
 // GetPreviewURL returns a URL for previewing content
-func (b *Backend) GetPreviewURL(ctx context.Context, objectKey string) (string, error) {
+func (b *Backend) GetPreviewURL(ctx context.Context, objectKey string) (_ string, err error) {
+	defer wrapError(&err, "get_preview_url", objectKey)
+
 	if b.urlPrefix == "" {
 		return "", errors.New("direct preview required for filesystem backend")
 	}
+	return b.previewURL(objectKey, "")
+}
 
-	path := "/preview/" + objectKey
+// previewURL builds the preview URL of objectKey, forcing contentType when set
+func (b *Backend) previewURL(objectKey, contentType string) (string, error) {
+	path := previewURLPath(objectKey, contentType)
 
 	// If signer is configured, generate signed URL
 	if b.downloadSigner != nil {
@@ -203,34 +1036,213 @@ func (b *Backend) GetPreviewURL(ctx context.Context, objectKey string) (string,
 	return b.urlPrefix + path, nil
 }
 
-// Download downloads content directly from the filesystem
-func (b *Backend) Download(ctx context.Context, objectKey string) (io.ReadCloser, error) {
-	filePath := filepath.Join(b.baseDir, objectKey)
+// Download downloads content directly from the filesystem, following
+// aliases (see CreateAlias).
+// Compressed objects are decoded with the codec recorded when they were
+// written. For uncompressed objects the returned reader wraps the underlying
+// *os.File, so it also implements io.WriterTo and io.Seeker for efficient
+// proxying and range serving.
+//
+// Reading fails with the context's error once ctx is done, so an abandoned
+// download stops promptly. The caller must Close the reader; closing it more
+// than once is safe and returns nil after the first call. DownloadTo manages the reader itself for
+// callers that only need to copy the object.
+//
+// With TransparentDecompress, keys ending in a compression extension are
+// decompressed as well; DownloadRaw returns their compressed bytes.
+func (b *Backend) Download(ctx context.Context, objectKey string) (rc io.ReadCloser, err error) {
+	rc, _, err = b.downloadSized(ctx, objectKey)
+	return rc, err
+}
+
+// downloadSized is Download also returning the size of the content, or -1
+// for keys TransparentDecompress decodes, whose size is only known by
+// reading them
+func (b *Backend) downloadSized(ctx context.Context, objectKey string) (rc io.ReadCloser, size int64, err error) {
+	defer b.observe(ctx, "download", objectKey, time.Now(), &err)
+	defer b.observeDownload(objectKey, time.Now(), &rc, &err)
+	defer wrapError(&err, "download", objectKey)
 
-	// Check if file exists and open it
-	file, err := os.Open(filePath)
+	opened, size, err := b.openSized(ctx, objectKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if b.extensionCodec(objectKey) != "" {
+		size = -1
+	}
+	rc, err = b.decodeExtension(opened, objectKey)
+	return rc, size, err
+}
+
+// download opens an object and decodes its at-rest compression. Reads fail
+// with the context's error once ctx is done.
+func (b *Backend) download(ctx context.Context, objectKey string) (io.ReadCloser, error) {
+	rc, _, err := b.openSized(ctx, objectKey)
+	return rc, err
+}
+
+// openSized is download also returning the size of the decoded content,
+// taken from the file and sidecar opened
+func (b *Backend) openSized(ctx context.Context, objectKey string) (io.ReadCloser, int64, error) {
+	obj, err := b.openResolved(ctx, objectKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	return obj.rc, obj.size, nil
+}
+
+// openedObject is an object opened for reading with the sidecar recorded
+// for the content opened
+type openedObject struct {
+	rc   io.ReadCloser // Decoded content
+	key  string        // Key of the object, the target's when opened through an alias
+	size int64         // Size of the decoded content
+	sc   *sidecar
+}
+
+// openResolved opens the object at objectKey, or else its packed copy or the
+// object an alias at objectKey refers to, decoding its at-rest compression
+func (b *Backend) openResolved(ctx context.Context, objectKey string) (*openedObject, error) {
+	filePath, err := b.readPath(objectKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check if file exists and open it, or else look in the packs and follow
+	// an alias
+	key := objectKey
+	file, sc, err := b.openWithSidecar(filePath)
+	if os.IsNotExist(err) {
+		if obj, err := b.downloadPacked(ctx, key, filePath); obj != nil || err != nil {
+			return obj, err
+		}
+		if key, filePath, err = b.resolveAlias(objectKey); err != nil {
+			return nil, err
+		}
+		file, sc, err = b.openWithSidecar(filePath)
+	}
 	if os.IsNotExist(err) {
-		return nil, errors.New("object not found")
+		if obj, err := b.downloadPacked(ctx, key, filePath); obj != nil || err != nil {
+			return obj, err
+		}
+		return nil, simplecontent.ErrObjectNotFound
 	} else if err != nil {
-		return nil, fmt.Errorf("failed to open file: %w", err)
+		return nil, err
+	}
+	if sc.reserved() {
+		file.Close()
+		return nil, simplecontent.ErrObjectNotFound
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to get file info: %w", err)
 	}
 
-	return file, nil
+	rc, err := openDecoded(&objectFile{File: file, ctx: ctx}, sc.Codec)
+	if err != nil {
+		return nil, err
+	}
+	return &openedObject{rc: rc, key: key, size: contentSize(info, sc), sc: sc}, nil
+}
+
+// openWithSidecar opens the object file at filePath and loads its sidecar
+// under the object's read lock, so the sidecar describes the content opened
+// even while the object is being replaced. A missing object returns an
+// error satisfying os.IsNotExist.
+func (b *Backend) openWithSidecar(filePath string) (*os.File, *sidecar, error) {
+	defer b.keyLocks.rlock(filePath)()
+
+	file, err := openObject(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil, err
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file: %w", err)
+	}
+	sc, err := b.loadSidecar(filePath)
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+	return file, sc, nil
+}
+
+// objectFile is an *os.File whose Close may be called more than once and
+// whose reads stop with the context's error once ctx is done
+type objectFile struct {
+	*os.File
+	ctx  context.Context
+	once sync.Once
+	err  error
 }
 
-// Delete deletes content from the filesystem
-func (b *Backend) Delete(ctx context.Context, objectKey string) error {
-	filePath := filepath.Join(b.baseDir, objectKey)
+func (f *objectFile) Read(p []byte) (int, error) {
+	if err := f.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return f.File.Read(p)
+}
 
-	// Check if file exists
+// WriteTo keeps the *os.File fast path (sendfile) and closes the file if ctx
+// is done mid-copy to stop it
+func (f *objectFile) WriteTo(w io.Writer) (int64, error) {
+	if err := f.ctx.Err(); err != nil {
+		return 0, err
+	}
+	stop := context.AfterFunc(f.ctx, func() { f.Close() })
+	n, err := f.File.WriteTo(w)
+	stop()
+	if ctxErr := f.ctx.Err(); err != nil && ctxErr != nil {
+		return n, ctxErr
+	}
+	return n, err
+}
+
+func (f *objectFile) Close() error {
+	f.once.Do(func() { f.err = f.File.Close() })
+	return f.err
+}
+
+// Delete deletes content from the filesystem. Deleting an alias removes
+// only the alias.
+func (b *Backend) Delete(ctx context.Context, objectKey string) (err error) {
+	defer b.observe(ctx, "delete", objectKey, time.Now(), &err)
+	defer b.observeDelete(objectKey, time.Now(), &err)
+	defer wrapError(&err, "delete", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return err
+	}
+
+	defer b.keyLocks.lock(filePath)()
+
+	// Check if file exists; a missing object may be an alias, which is
+	// removed without touching its target
 	if _, err := os.Stat(filePath); os.IsNotExist(err) {
-		return errors.New("object not found")
+		if removed, err := b.removeAlias(objectKey); err != nil || removed {
+			return err
+		}
+		return simplecontent.ErrObjectNotFound
 	}
 
 	// Delete file
 	if err := os.Remove(filePath); err != nil {
 		return fmt.Errorf("failed to delete file: %w", err)
 	}
+	return b.removeCompanions(objectKey, filePath)
+}
+
+// removeCompanions removes the sidecar and preview of a deleted object and
+// any directories its removal left empty
+func (b *Backend) removeCompanions(objectKey, filePath string) error {
+	if err := b.removeAttached(objectKey, filePath); err != nil {
+		return err
+	}
 
 	// Clean up empty directories
 	b.cleanupEmptyDirectories(filepath.Dir(filePath))
@@ -238,6 +1250,192 @@ func (b *Backend) Delete(ctx context.Context, objectKey string) error {
 	return nil
 }
 
+// removeAttached removes the sidecar and preview of a deleted object
+func (b *Backend) removeAttached(objectKey, filePath string) error {
+	if err := b.removeSidecar(filePath); err != nil {
+		return err
+	}
+	return b.removePreview(objectKey)
+}
+
+// objectPath maps a logical object key to its path under baseDir.
+// The configured key separator is translated into path separators first, the
+// key is checked by sanitizeKey, and the resulting path must stay inside
+// baseDir. Keys under the internal directories or named like the companion
+// files kept next to objects are rejected, so callers cannot read or forge
+// the backend's own records; see reservedPath.
+func (b *Backend) objectPath(objectKey string) (string, error) {
+	if err := b.checkFileName(objectKey); err != nil {
+		return "", err
+	}
+	return b.dirPath(objectKey)
+}
+
+// checkFileName rejects keys whose file would be taken for a companion file
+func (b *Backend) checkFileName(objectKey string) error {
+	if name := path.Base(b.slashKey(objectKey)); isInternalFile(name) {
+		return fmt.Errorf("%w: %q is named like an internal file", simplecontent.ErrInvalidKey, objectKey)
+	}
+	return nil
+}
+
+// dirPath is objectPath for a key prefix naming a directory, whose name may
+// be anything an object's parent directories may be called
+func (b *Backend) dirPath(prefix string) (string, error) {
+	if dir := b.reservedDir(prefix); dir != "" {
+		return "", fmt.Errorf("%w: %q is under the reserved %s directory", simplecontent.ErrInvalidKey, prefix, dir)
+	}
+	return b.reservedPath(prefix)
+}
+
+// readPath is objectPath also accepting the keys of generated previews,
+// which GeneratePreview returns to be read like any object
+func (b *Backend) readPath(objectKey string) (string, error) {
+	if b.reservedDir(objectKey) != previewDir {
+		return b.objectPath(objectKey)
+	}
+	if err := b.checkFileName(objectKey); err != nil {
+		return "", err
+	}
+	return b.reservedPath(objectKey)
+}
+
+// slashKey returns a key slash-separated and cleaned, as it names a path
+func (b *Backend) slashKey(objectKey string) string {
+	key := objectKey
+	if b.keySeparator != "/" {
+		key = strings.ReplaceAll(key, b.keySeparator, "/")
+	}
+	return path.Clean(filepath.ToSlash(key))
+}
+
+// reservedDir returns the internal directory a key falls under, or ""
+func (b *Backend) reservedDir(objectKey string) string {
+	first, _, _ := strings.Cut(b.slashKey(objectKey), "/")
+	if internalDirs[first] {
+		return first
+	}
+	return ""
+}
+
+// reservedPath is objectPath for the keys the backend builds under its
+// internal directories, such as alias records and previews
+func (b *Backend) reservedPath(objectKey string) (string, error) {
+	key := objectKey
+	if b.keySeparator != "/" {
+		// A slash would nest the key in a directory it is not listed under
+		if strings.Contains(key, "/") {
+			return "", fmt.Errorf("%w: %q contains \"/\" but keys are separated by %q", simplecontent.ErrInvalidKey, objectKey, b.keySeparator)
+		}
+		key = strings.ReplaceAll(key, b.keySeparator, "/")
+	}
+	if err := sanitizeKey(key); err != nil {
+		return "", err
+	}
+
+	filePath := filepath.Join(b.baseDir, filepath.FromSlash(b.shard(key)))
+	rel, err := filepath.Rel(b.baseDir, filePath)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", simplecontent.ErrInvalidKey
+	}
+	return filePath, nil
+}
+
+// sanitizeKey rejects slash-separated keys that are absolute, contain ".."
+// segments or NUL bytes, even where they would resolve inside baseDir, so
+// keys taken from user input never name a path other than the one they
+// spell out.
+func sanitizeKey(key string) error {
+	if strings.HasPrefix(key, "/") || filepath.IsAbs(filepath.FromSlash(key)) || filepath.VolumeName(filepath.FromSlash(key)) != "" {
+		return fmt.Errorf("%w: %q is absolute", simplecontent.ErrInvalidKey, key)
+	}
+	if strings.ContainsRune(key, 0) {
+		return fmt.Errorf("%w: %q contains a NUL byte", simplecontent.ErrInvalidKey, key)
+	}
+	isSeparator := func(r rune) bool { return r == '/' || r == filepath.Separator }
+	for _, segment := range strings.FieldsFunc(key, isSeparator) {
+		if segment == ".." {
+			return fmt.Errorf("%w: %q contains a \"..\" segment", simplecontent.ErrInvalidKey, key)
+		}
+	}
+	return nil
+}
+
+// objectKey maps a path under baseDir back to its logical object key,
+// reversing objectPath
+func (b *Backend) objectKey(filePath string) (string, error) {
+	rel, err := filepath.Rel(b.baseDir, filePath)
+	if err != nil {
+		return "", err
+	}
+	key, err := b.unshard(filepath.ToSlash(rel))
+	if err != nil {
+		return "", err
+	}
+	if b.keySeparator != "/" {
+		key = strings.ReplaceAll(key, "/", b.keySeparator)
+	}
+	return key, nil
+}
+
+// probeWritable creates and removes a temporary file in dir
+func probeWritable(dir string) error {
+	file, err := createTemp(filepath.Join(dir, ".write-probe"))
+	if err != nil {
+		return fmt.Errorf("base directory %s is not writable: %w", dir, err)
+	}
+	file.Close()
+	if err := os.Remove(file.Name()); err != nil {
+		return fmt.Errorf("base directory %s is not writable: %w", dir, err)
+	}
+	return nil
+}
+
+// wrapError annotates a non-nil *err with the failing operation and key as a
+// *simplecontent.StorageError. Errors that already carry that context are
+// left unchanged.
+func wrapError(err *error, op, key string) {
+	if *err == nil {
+		return
+	}
+	var se *simplecontent.StorageError
+	if errors.As(*err, &se) {
+		return
+	}
+	*err = &simplecontent.StorageError{Backend: "fs", Key: key, Op: op, Err: *err}
+}
+
+// fileETag derives an entity tag from a file's modification time and size,
+// which changes whenever the object is replaced
+func fileETag(info os.FileInfo) string {
+	return fmt.Sprintf("%x-%x", info.ModTime().UnixNano(), info.Size())
+}
+
+// mkdirAll creates dir and any missing parents. With DirMode, the
+// directories it creates get exactly those permission bits, regardless of
+// umask.
+func (b *Backend) mkdirAll(dir string) error {
+	if b.dirMode == 0 {
+		return os.MkdirAll(dir, 0755)
+	}
+	if _, err := os.Stat(dir); err == nil {
+		return nil
+	}
+	if parent := filepath.Dir(dir); parent != dir {
+		if err := b.mkdirAll(parent); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(dir, b.dirMode); err != nil {
+		if os.IsExist(err) {
+			// Created concurrently
+			return nil
+		}
+		return err
+	}
+	return os.Chmod(dir, b.dirMode)
+}
+
 // cleanupEmptyDirectories recursively removes empty directories up to baseDir
 func (b *Backend) cleanupEmptyDirectories(dir string) {
 	// Don't remove the base directory
@@ -245,8 +1443,14 @@ func (b *Backend) cleanupEmptyDirectories(dir string) {
 		return
 	}
 
-	// Check if directory is empty
-	if entries, err := os.ReadDir(dir); err == nil && len(entries) == 0 {
+	// Check if directory is empty, but for the metadata index of its
+	// deleted objects
+	entries, err := os.ReadDir(dir)
+	if err == nil && len(entries) == 1 && entries[0].Name() == sidecarIndexName && b.sidecarIndex != nil {
+		b.dropIndex(dir)
+		entries = nil
+	}
+	if err == nil && len(entries) == 0 {
 		// Remove empty directory
 		if os.Remove(dir) == nil {
 			// Recursively clean parent directory
@@ -288,11 +1492,40 @@ func (b *Backend) ValidateDownloadSignature(objectKey, signature string, expires
 		return nil
 	}
 
+	return b.downloadSigner.Validate("GET", downloadURLPath(objectKey, DownloadOptions{Filename: filename}), signature, expiresAt)
+}
+
+// ValidateDownloadOptionsSignature validates a presigned download URL
+// signature covering a forced content type or inline disposition, as built
+// by GetDownloadURLWithOptions.
+// Returns nil if signature is valid, error otherwise
+func (b *Backend) ValidateDownloadOptionsSignature(objectKey, filename, contentType string, inline bool, signature string, expiresAt int64) error {
+	if b.downloadSigner == nil {
+		return nil
+	}
+	opts := DownloadOptions{Filename: filename, ContentType: contentType, Inline: inline}
+	return b.downloadSigner.Validate("GET", downloadURLPath(objectKey, opts), signature, expiresAt)
+}
+
+// downloadURLPath returns the signed path of a download URL. The filename
+// is added unescaped, as URLs have always carried it.
+func downloadURLPath(objectKey string, opts DownloadOptions) string {
+	var query []string
+	if opts.Filename != "" {
+		query = append(query, "filename="+opts.Filename)
+	}
+	if opts.ContentType != "" {
+		query = append(query, "content_type="+url.QueryEscape(opts.ContentType))
+	}
+	if opts.Inline {
+		query = append(query, "disposition=inline")
+	}
+
 	path := "/download/" + objectKey
-	if filename != "" {
-		path = path + "?filename=" + filename
+	if len(query) > 0 {
+		path += "?" + strings.Join(query, "&")
 	}
-	return b.downloadSigner.Validate("GET", path, signature, expiresAt)
+	return path
 }
 
 // ValidatePreviewSignature validates a presigned preview URL signature
@@ -3,10 +3,14 @@ package fs
 import (
     "bytes"
     "context"
+    "errors"
     "io"
     "os"
     "path/filepath"
+    "strings"
     "testing"
+
+    "github.com/tendant/simple-content/pkg/simplecontent"
 )
 
 func TestFSBackend_BasicOps(t *testing.T) {
@@ -75,3 +79,543 @@ func TestFSBackend_URLMethods_NoPrefix(t *testing.T) {
     }
 }
 
+
+func TestFSBackend_KeySeparator(t *testing.T) {
+    tmp := t.TempDir()
+    b, err := New(Config{BaseDir: tmp, KeySeparator: ":"})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    ctx := context.Background()
+
+    if err := b.Upload(ctx, "tenant:docs:file.txt", bytes.NewReader([]byte("legacy"))); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+    if _, err := os.Stat(filepath.Join(tmp, "tenant", "docs", "file.txt")); err != nil {
+        t.Fatalf("expected key mapped to nested directories: %v", err)
+    }
+
+    // Traversal protection applies after translation
+    if err := b.Upload(ctx, "..:..:etc:passwd", bytes.NewReader([]byte("x"))); !errors.Is(err, simplecontent.ErrInvalidKey) {
+        t.Fatalf("expected ErrInvalidKey, got %v", err)
+    }
+    if _, err := b.Download(ctx, "tenant:..:..:escape"); !errors.Is(err, simplecontent.ErrInvalidKey) {
+        t.Fatalf("expected ErrInvalidKey, got %v", err)
+    }
+}
+
+func TestFSBackend_UploadSizeMismatch(t *testing.T) {
+    tmp := t.TempDir()
+    b, err := New(Config{BaseDir: tmp})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    ctx := context.Background()
+
+    if err := b.Upload(ctx, "obj", bytes.NewReader([]byte("original"))); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+
+    for _, body := range []string{"short", "much too long"} {
+        params := simplecontent.UploadParams{ObjectKey: "obj", Size: 8}
+        err := b.UploadWithParams(ctx, bytes.NewReader([]byte(body)), params)
+        if !errors.Is(err, simplecontent.ErrSizeMismatch) {
+            t.Fatalf("expected ErrSizeMismatch for %q, got %v", body, err)
+        }
+    }
+
+    // The previous object is untouched and no temp files remain
+    rc, err := b.Download(ctx, "obj")
+    if err != nil {
+        t.Fatalf("download: %v", err)
+    }
+    got, _ := io.ReadAll(rc)
+    _ = rc.Close()
+    if string(got) != "original" {
+        t.Fatalf("expected original content, got %q", string(got))
+    }
+    entries, _ := os.ReadDir(tmp)
+    for _, e := range entries {
+        if isTempName(e.Name()) {
+            t.Fatalf("expected no temp files in base dir, found %s", e.Name())
+        }
+    }
+
+    // Matching size succeeds
+    if err := b.UploadWithParams(ctx, bytes.NewReader([]byte("replaced")), simplecontent.UploadParams{ObjectKey: "obj", Size: 8}); err != nil {
+        t.Fatalf("upload with matching size: %v", err)
+    }
+}
+
+func TestFSBackend_UploadSizeCheckDisabled(t *testing.T) {
+    b, err := New(Config{BaseDir: t.TempDir(), DisableSizeCheck: true})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    params := simplecontent.UploadParams{ObjectKey: "stream", Size: 100}
+    if err := b.UploadWithParams(context.Background(), bytes.NewReader([]byte("unknown length")), params); err != nil {
+        t.Fatalf("expected upload to succeed with size check disabled: %v", err)
+    }
+}
+
+type disconnectingReader struct {
+    r io.Reader
+}
+
+func (d *disconnectingReader) Read(p []byte) (int, error) {
+    n, err := d.r.Read(p)
+    if err == io.EOF {
+        return n, io.ErrUnexpectedEOF
+    }
+    return n, err
+}
+
+func TestFSBackend_UploadSourceError(t *testing.T) {
+    tmp := t.TempDir()
+    b, err := New(Config{BaseDir: tmp})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    ctx := context.Background()
+
+    reader := &disconnectingReader{r: bytes.NewReader(bytes.Repeat([]byte("x"), 64<<10))}
+    err = b.Upload(ctx, "partial", reader)
+    if !errors.Is(err, simplecontent.ErrSourceRead) || !errors.Is(err, io.ErrUnexpectedEOF) {
+        t.Fatalf("expected ErrSourceRead wrapping the reader error, got %v", err)
+    }
+    if _, err := b.GetObjectMeta(ctx, "partial"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+        t.Fatalf("expected no committed object, got %v", err)
+    }
+    entries, _ := os.ReadDir(tmp)
+    for _, e := range entries {
+        if isTempName(e.Name()) {
+            t.Fatalf("expected staged file removed, found %s", e.Name())
+        }
+    }
+
+    params := simplecontent.UploadParams{ObjectKey: "short", Size: 8}
+    if err := b.UploadWithParams(ctx, bytes.NewReader([]byte("short")), params); errors.Is(err, simplecontent.ErrSourceRead) {
+        t.Fatalf("expected size mismatch not to be reported as a source error: %v", err)
+    }
+}
+
+// cancellingReader cancels its context after the first read and keeps
+// supplying data
+type cancellingReader struct {
+    cancel context.CancelFunc
+    reads  int
+}
+
+func (c *cancellingReader) Read(p []byte) (int, error) {
+    c.reads++
+    if c.reads == 1 {
+        c.cancel()
+    }
+    return len(p), nil
+}
+
+func TestFSBackend_UploadCancelled(t *testing.T) {
+    tmp := t.TempDir()
+    b, err := New(Config{BaseDir: tmp})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    reader := &cancellingReader{cancel: cancel}
+    if err := b.Upload(ctx, "endless", reader); !errors.Is(err, context.Canceled) {
+        t.Fatalf("expected context.Canceled, got %v", err)
+    }
+    if reader.reads != 1 {
+        t.Fatalf("expected copy to stop after cancellation, read %d times", reader.reads)
+    }
+    if _, err := os.Stat(filepath.Join(tmp, "endless")); !os.IsNotExist(err) {
+        t.Fatalf("expected no committed object")
+    }
+    entries, _ := os.ReadDir(tmp)
+    for _, e := range entries {
+        if isTempName(e.Name()) {
+            t.Fatalf("expected staged file removed, found %s", e.Name())
+        }
+    }
+}
+
+func TestFSBackend_DownloadCancelled(t *testing.T) {
+    b, err := New(Config{BaseDir: t.TempDir()})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    if err := b.Upload(context.Background(), "large", bytes.NewReader(make([]byte, 1<<20))); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+
+    ctx, cancel := context.WithCancel(context.Background())
+    rc, err := b.Download(ctx, "large")
+    if err != nil {
+        t.Fatalf("download: %v", err)
+    }
+    defer rc.Close()
+    if _, err := io.ReadFull(rc, make([]byte, 4<<10)); err != nil {
+        t.Fatalf("read: %v", err)
+    }
+    cancel()
+    if _, err := rc.Read(make([]byte, 4<<10)); !errors.Is(err, context.Canceled) {
+        t.Fatalf("expected context.Canceled after cancellation, got %v", err)
+    }
+    if _, err := io.Copy(io.Discard, rc); !errors.Is(err, context.Canceled) {
+        t.Fatalf("expected copy to stop with context.Canceled, got %v", err)
+    }
+}
+
+func TestFSBackend_SanitizeKey(t *testing.T) {
+    b, err := New(Config{BaseDir: t.TempDir()})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    ctx := context.Background()
+
+    for _, key := range []string{"../../etc/passwd", "/etc/passwd", "docs/../other.txt", "docs/..", "a\x00b"} {
+        if err := b.Upload(ctx, key, bytes.NewReader([]byte("x"))); !errors.Is(err, simplecontent.ErrInvalidKey) {
+            t.Fatalf("upload %q: expected ErrInvalidKey, got %v", key, err)
+        }
+        if _, err := b.Download(ctx, key); !errors.Is(err, simplecontent.ErrInvalidKey) {
+            t.Fatalf("download %q: expected ErrInvalidKey, got %v", key, err)
+        }
+        if _, err := b.GetObjectMeta(ctx, key); !errors.Is(err, simplecontent.ErrInvalidKey) {
+            t.Fatalf("meta %q: expected ErrInvalidKey, got %v", key, err)
+        }
+        if err := b.Delete(ctx, key); !errors.Is(err, simplecontent.ErrInvalidKey) {
+            t.Fatalf("delete %q: expected ErrInvalidKey, got %v", key, err)
+        }
+    }
+
+    // Dots that are not whole ".." segments are ordinary characters
+    if err := b.Upload(ctx, "docs/..hidden/v1..2.txt", bytes.NewReader([]byte("x"))); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+}
+
+func TestFSBackend_Exists(t *testing.T) {
+    b, err := New(Config{BaseDir: t.TempDir()})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    ctx := context.Background()
+
+    if err := b.Upload(ctx, "dir/obj", bytes.NewReader([]byte("x"))); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+    for key, want := range map[string]bool{"dir/obj": true, "dir/missing": false, "dir": false, "other/obj": false} {
+        got, err := b.Exists(ctx, key)
+        if err != nil || got != want {
+            t.Fatalf("exists %s: expected %v, got %v err=%v", key, want, got, err)
+        }
+    }
+    if _, err := b.Exists(ctx, "../escape"); !errors.Is(err, simplecontent.ErrInvalidKey) {
+        t.Fatalf("expected ErrInvalidKey, got %v", err)
+    }
+}
+
+func TestFSBackend_WriteProbe(t *testing.T) {
+    if os.Geteuid() == 0 {
+        t.Skip("permission checks are bypassed when running as root")
+    }
+    tmp := t.TempDir()
+    if err := os.Chmod(tmp, 0555); err != nil {
+        t.Fatalf("chmod: %v", err)
+    }
+    defer os.Chmod(tmp, 0755)
+
+    if _, err := New(Config{BaseDir: tmp}); err == nil {
+        t.Fatalf("expected error for non-writable base directory")
+    }
+    if _, err := New(Config{BaseDir: tmp, SkipWriteProbe: true}); err != nil {
+        t.Fatalf("expected probe to be skippable: %v", err)
+    }
+}
+
+func TestFSBackend_WriteProbeLeavesNoFiles(t *testing.T) {
+    tmp := t.TempDir()
+    if _, err := New(Config{BaseDir: tmp}); err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    entries, _ := os.ReadDir(tmp)
+    if len(entries) != 0 {
+        t.Fatalf("expected write probe to clean up, found %d entries", len(entries))
+    }
+}
+
+func TestFSBackend_StorageErrors(t *testing.T) {
+    b, err := New(Config{BaseDir: t.TempDir()})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    ctx := context.Background()
+
+    _, err = b.Download(ctx, "missing/key")
+    var se *simplecontent.StorageError
+    if !errors.As(err, &se) {
+        t.Fatalf("expected *StorageError, got %T: %v", err, err)
+    }
+    if se.Op != "download" || se.Key != "missing/key" || se.Backend != "fs" {
+        t.Fatalf("unexpected error context: %+v", se)
+    }
+    if !errors.Is(err, simplecontent.ErrObjectNotFound) {
+        t.Fatalf("expected ErrObjectNotFound, got %v", err)
+    }
+
+    if _, err := b.GetObjectMeta(ctx, "missing/key"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+        t.Fatalf("expected ErrObjectNotFound from GetObjectMeta, got %v", err)
+    }
+    if err := b.Delete(ctx, "missing/key"); !errors.As(err, &se) || se.Op != "delete" {
+        t.Fatalf("expected delete StorageError, got %v", err)
+    }
+    if err := b.Upload(ctx, "../escape", strings.NewReader("x")); !errors.As(err, &se) || !errors.Is(err, simplecontent.ErrInvalidKey) {
+        t.Fatalf("expected wrapped ErrInvalidKey, got %v", err)
+    }
+}
+
+func TestFSBackend_CacheControlByContentType(t *testing.T) {
+    b, err := New(Config{
+        BaseDir:                   t.TempDir(),
+        CacheControlByContentType: map[string]string{"text/plain": "max-age=60", "image/*": "max-age=31536000"},
+    })
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    ctx := context.Background()
+
+    for key, want := range map[string]string{"notes.txt": "max-age=60", "blob.bin": ""} {
+        body := "plain text"
+        if key == "blob.bin" {
+            body = "\x00\x01\x02"
+        }
+        if err := b.Upload(ctx, key, strings.NewReader(body)); err != nil {
+            t.Fatalf("upload %s: %v", key, err)
+        }
+        meta, err := b.GetObjectMeta(ctx, key)
+        if err != nil {
+            t.Fatalf("get meta %s: %v", key, err)
+        }
+        if meta.CacheControl != want {
+            t.Fatalf("expected cache control %q for %s (%s), got %q", want, key, meta.ContentType, meta.CacheControl)
+        }
+    }
+}
+
+func TestFSBackend_DeclaredContentTypeIsAuthoritative(t *testing.T) {
+    ctx := context.Background()
+    b, err := New(Config{BaseDir: t.TempDir(), DetectContentTypeFromExtension: true})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+
+    // SVG sniffs as text, and the declared type wins over the extension too
+    svg := `<svg xmlns="http://www.w3.org/2000/svg"></svg>`
+    for key, want := range map[string]string{"logo.svg": "image/svg+xml", "logo.txt": "image/svg+xml; charset=utf-8"} {
+        params := simplecontent.UploadParams{ObjectKey: key, MimeType: want}
+        if err := b.UploadWithParams(ctx, strings.NewReader(svg), params); err != nil {
+            t.Fatalf("upload %s: %v", key, err)
+        }
+        meta, err := b.GetObjectMeta(ctx, key)
+        if err != nil {
+            t.Fatalf("get meta %s: %v", key, err)
+        }
+        if meta.ContentType != want {
+            t.Fatalf("expected declared %q for %s, got %q", want, key, meta.ContentType)
+        }
+    }
+}
+
+func TestFSBackend_DetectContentTypeFromExtension(t *testing.T) {
+    dir := t.TempDir()
+    ctx := context.Background()
+    b, err := New(Config{BaseDir: dir, DetectContentTypeFromExtension: true})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+
+    uploads := map[string]string{
+        "data.json":   `{"a": 1}`,
+        "notes.xyz12": "plain text",
+    }
+    for key, body := range uploads {
+        if err := b.Upload(ctx, key, strings.NewReader(body)); err != nil {
+            t.Fatalf("upload %s: %v", key, err)
+        }
+    }
+
+    // Known extensions win over sniffing, which reports JSON as text
+    for key, want := range map[string]string{"data.json": "application/json", "notes.xyz12": "text/plain; charset=utf-8"} {
+        meta, err := b.GetObjectMeta(ctx, key)
+        if err != nil {
+            t.Fatalf("get meta %s: %v", key, err)
+        }
+        if meta.ContentType != want {
+            t.Fatalf("expected %q for %s, got %q", want, key, meta.ContentType)
+        }
+    }
+
+    // Without the option the content is sniffed
+    sniffing := newCompressedBackend(t, dir, "")
+    meta, err := sniffing.GetObjectMeta(ctx, "data.json")
+    if err != nil {
+        t.Fatalf("get meta: %v", err)
+    }
+    if meta.ContentType == "application/json" {
+        t.Fatalf("expected sniffed content type without the option, got %q", meta.ContentType)
+    }
+}
+
+func TestFSBackend_KeyPrefix(t *testing.T) {
+    tmp := t.TempDir()
+    b, err := New(Config{BaseDir: tmp, KeyPrefix: "app-v2/"})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    backend := b.(*Backend)
+    ctx := context.Background()
+
+    if err := backend.Upload(ctx, "docs/a.txt", strings.NewReader("namespaced")); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+    if _, err := os.Stat(filepath.Join(tmp, "app-v2", "docs", "a.txt")); err != nil {
+        t.Fatalf("expected object stored under the key prefix: %v", err)
+    }
+    objects, err := backend.List(ctx, "")
+    if err != nil || len(objects) != 1 || objects[0].Key != "docs/a.txt" {
+        t.Fatalf("expected listing relative to the key prefix, got %+v: %v", objects, err)
+    }
+
+    // Keys cannot escape the prefix, and the prefix cannot escape BaseDir
+    if err := os.WriteFile(filepath.Join(tmp, "secret"), []byte("x"), 0644); err != nil {
+        t.Fatalf("write: %v", err)
+    }
+    if _, err := backend.Download(ctx, "../secret"); !errors.Is(err, simplecontent.ErrInvalidKey) {
+        t.Fatalf("expected ErrInvalidKey, got %v", err)
+    }
+    if _, err := New(Config{BaseDir: tmp, KeyPrefix: "../outside"}); !errors.Is(err, simplecontent.ErrInvalidKey) {
+        t.Fatalf("expected ErrInvalidKey for escaping prefix, got %v", err)
+    }
+
+    // Deleting the last object keeps the prefix directory itself
+    if err := backend.Delete(ctx, "docs/a.txt"); err != nil {
+        t.Fatalf("delete: %v", err)
+    }
+    if _, err := os.Stat(filepath.Join(tmp, "app-v2")); err != nil {
+        t.Fatalf("expected prefix directory kept: %v", err)
+    }
+}
+
+func TestFSBackend_UserMetadata(t *testing.T) {
+    tmp := t.TempDir()
+    b, err := New(Config{BaseDir: tmp})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    backend := b.(*Backend)
+    ctx := context.Background()
+
+    params := simplecontent.UploadParams{
+        ObjectKey: "docs/report.pdf",
+        MimeType:  "application/pdf",
+        Metadata:  map[string]string{"owner": "alice", "source": "scanner"},
+    }
+    if err := backend.UploadWithParams(ctx, strings.NewReader("%PDF-1.4"), params); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+
+    meta, err := backend.GetObjectMeta(ctx, "docs/report.pdf")
+    if err != nil {
+        t.Fatalf("get object meta: %v", err)
+    }
+    if meta.ContentType != "application/pdf" || meta.Metadata["owner"] != "alice" || meta.Metadata["source"] != "scanner" {
+        t.Fatalf("expected stored metadata, got %+v", meta)
+    }
+    if meta.Metadata["content_type"] != "application/pdf" {
+        t.Fatalf("expected content_type in metadata, got %v", meta.Metadata)
+    }
+
+    // Replacing the object replaces its metadata
+    if err := backend.Upload(ctx, "docs/report.pdf", strings.NewReader("%PDF-1.5")); err != nil {
+        t.Fatalf("replace: %v", err)
+    }
+    if meta, err = backend.GetObjectMeta(ctx, "docs/report.pdf"); err != nil || meta.Metadata["owner"] != "" {
+        t.Fatalf("expected metadata to be replaced, got %+v: %v", meta, err)
+    }
+
+    if err := backend.UploadWithParams(ctx, strings.NewReader("%PDF-1.4"), params); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+    if err := backend.Delete(ctx, "docs/report.pdf"); err != nil {
+        t.Fatalf("delete: %v", err)
+    }
+    if _, err := os.Stat(filepath.Join(tmp, "docs")); !os.IsNotExist(err) {
+        t.Fatalf("expected sidecar and directory to be removed, got %v", err)
+    }
+}
+
+func TestFSBackend_SidecarSurvivesCrashDuringCommit(t *testing.T) {
+    tmp := t.TempDir()
+    b, err := New(Config{BaseDir: tmp})
+    if err != nil {
+        t.Fatalf("new fs backend: %v", err)
+    }
+    backend := b.(*Backend)
+    ctx := context.Background()
+
+    params := simplecontent.UploadParams{ObjectKey: "docs/a.txt", MimeType: "text/plain", Metadata: map[string]string{"owner": "alice"}}
+    if err := backend.UploadWithParams(ctx, strings.NewReader("v1"), params); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+    filePath := mustObjectPath(t, backend, "docs/a.txt")
+
+    // crash runs a write of content whose process dies inside place, after
+    // the data is renamed into place when renamed is set
+    crash := func(content, owner string, renamed bool) {
+        dataPath := tempName(filePath)
+        if err := os.WriteFile(dataPath, []byte(content), 0644); err != nil {
+            t.Fatalf("write staged file: %v", err)
+        }
+        written := sidecar{ContentType: "text/plain", Metadata: map[string]string{"owner": owner}}
+        defer func() { recover() }()
+        _ = backend.recordWrite(filePath, dataPath, written, func() error {
+            if renamed {
+                if err := os.Rename(dataPath, filePath); err != nil {
+                    t.Fatalf("rename: %v", err)
+                }
+            }
+            panic("crash")
+        })
+    }
+    owner := func() string {
+        meta, err := backend.GetObjectMeta(ctx, "docs/a.txt")
+        if err != nil {
+            t.Fatalf("get object meta: %v", err)
+        }
+        return meta.Metadata["owner"]
+    }
+
+    // Interrupted after the data rename: the staged sidecar describes the file
+    crash("version 2", "bob", true)
+    if got := owner(); got != "bob" {
+        t.Fatalf("expected metadata of the renamed file, got owner %q", got)
+    }
+
+    // Interrupted before it: the object keeps its metadata
+    crash("version 3", "carol", false)
+    if got := owner(); got != "bob" {
+        t.Fatalf("expected metadata of the file in place, got owner %q", got)
+    }
+
+    params.Metadata = map[string]string{"owner": "dave"}
+    if err := backend.UploadWithParams(ctx, strings.NewReader("v4"), params); err != nil {
+        t.Fatalf("upload: %v", err)
+    }
+    if got := owner(); got != "dave" {
+        t.Fatalf("expected metadata of the new upload, got owner %q", got)
+    }
+    if _, err := os.Stat(stagedSidecarPath(filePath)); !os.IsNotExist(err) {
+        t.Fatalf("expected staged sidecar to be committed, got %v", err)
+    }
+}
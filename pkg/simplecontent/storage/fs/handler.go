@@ -0,0 +1,28 @@
+package fs
+
+import (
+	"net/http"
+
+	"github.com/tendant/simple-content/pkg/simplecontent/httpstore"
+)
+
+// Handler returns an http.Handler serving the URLs GetUploadURL,
+// GetDownloadURL and GetPreviewURL generate, and their variants, through
+// httpstore: PUT /upload/{key} streams the body to UploadWithParams, GET
+// /download/{key} serves the object as an attachment named by its filename
+// parameter, and GET /preview/{key} serves its generated preview inline.
+// Downloads and previews support ranges and conditional requests. With
+// SignatureSecretKey, requests must carry a valid signature for their route.
+// The upload route is not served with ReadOnly.
+//
+// Routes are relative to URLPrefix, so mount the handler where it points,
+// stripping its path:
+//
+//	http.Handle("/files/", http.StripPrefix("/files", backend.Handler()))
+func (b *Backend) Handler() http.Handler {
+	var opts []httpstore.Option
+	if b.readOnly {
+		opts = append(opts, httpstore.WithReadOnly())
+	}
+	return httpstore.NewHandler(b, opts...)
+}
@@ -0,0 +1,113 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_Handler(t *testing.T) {
+	store, err := New(Config{BaseDir: t.TempDir(), URLPrefix: "http://files.example.com", SignatureSecretKey: "secret"})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	b := store.(*Backend)
+	handler := b.Handler()
+	ctx := context.Background()
+
+	serve := func(method, url string, body io.Reader) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, url, body))
+		return rec
+	}
+
+	uploadURL, err := b.GetUploadURL(ctx, "docs/report.txt")
+	if err != nil {
+		t.Fatalf("upload url: %v", err)
+	}
+	if rec := serve(http.MethodPut, uploadURL, strings.NewReader("quarterly")); rec.Code != http.StatusOK {
+		t.Fatalf("upload: unexpected response %d %s", rec.Code, rec.Body.String())
+	}
+	if got := readObject(t, b, "docs/report.txt"); got != "quarterly" {
+		t.Fatalf("unexpected content %q", got)
+	}
+
+	downloadURL, err := b.GetDownloadURL(ctx, "docs/report.txt", "q3.txt")
+	if err != nil {
+		t.Fatalf("download url: %v", err)
+	}
+	rec := serve(http.MethodGet, downloadURL, nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "quarterly" {
+		t.Fatalf("download: unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename=q3.txt` {
+		t.Fatalf("unexpected Content-Disposition %q", got)
+	}
+
+	optionsURL, err := b.GetDownloadURLWithOptions(ctx, "docs/report.txt", DownloadOptions{ContentType: "text/csv", Inline: true})
+	if err != nil {
+		t.Fatalf("download url with options: %v", err)
+	}
+	rec = serve(http.MethodGet, optionsURL, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/csv" || rec.Header().Get("Content-Disposition") != "inline" {
+		t.Fatalf("download with options: unexpected response %d %v", rec.Code, rec.Header())
+	}
+
+	previewURL, err := b.GetPreviewURL(ctx, "docs/report.txt")
+	if err != nil {
+		t.Fatalf("preview url: %v", err)
+	}
+	rec = serve(http.MethodGet, previewURL, nil)
+	if rec.Code != http.StatusOK || rec.Body.String() != "quarterly" || rec.Header().Get("Content-Disposition") != "inline" {
+		t.Fatalf("preview: unexpected response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+
+	typedURL, err := b.GetPreviewURLWithType(ctx, "docs/report.txt", "image/webp")
+	if err != nil {
+		t.Fatalf("preview url with type: %v", err)
+	}
+	rec = serve(http.MethodGet, typedURL, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/webp" {
+		t.Fatalf("preview with type: unexpected response %d %v", rec.Code, rec.Header())
+	}
+
+	// Downloads serve ranges and conditional requests
+	rangeReq := httptest.NewRequest(http.MethodGet, downloadURL, nil)
+	rangeReq.Header.Set("Range", "bytes=2-4")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, rangeReq)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "art" || rec.Header().Get("Content-Range") != "bytes 2-4/9" {
+		t.Fatalf("ranged download: unexpected response %d %q %v", rec.Code, rec.Body.String(), rec.Header())
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" {
+		t.Fatal("expected downloads to carry an ETag")
+	}
+	condReq := httptest.NewRequest(http.MethodGet, downloadURL, nil)
+	condReq.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, condReq)
+	if rec.Code != http.StatusNotModified {
+		t.Fatalf("conditional download: expected 304, got %d", rec.Code)
+	}
+
+	// The download signature does not authorise an upload, nor a tampered URL
+	rejected := map[string]*httptest.ResponseRecorder{
+		"replayed as upload": serve(http.MethodPut, strings.Replace(downloadURL, "/download/", "/upload/", 1), strings.NewReader("x")),
+		"other filename":     serve(http.MethodGet, strings.Replace(downloadURL, "q3.txt", "q4.txt", 1), nil),
+		"other preview type": serve(http.MethodGet, strings.Replace(typedURL, "webp", "png", 1), nil),
+		"unsigned":           serve(http.MethodGet, "http://files.example.com/download/docs/report.txt", nil),
+		"other type":         serve(http.MethodGet, strings.Replace(optionsURL, "text%2Fcsv", "text%2Fhtml", 1), nil),
+	}
+	for name, rec := range rejected {
+		if rec.Code != http.StatusForbidden && rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s: expected the request to be refused, got %d", name, rec.Code)
+		}
+	}
+	if got := readObject(t, b, "docs/report.txt"); got != "quarterly" {
+		t.Fatalf("expected the object to be unchanged, got %q", got)
+	}
+}
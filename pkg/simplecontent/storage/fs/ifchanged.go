@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// errUnchanged aborts a staged write whose content matches the stored object
+var errUnchanged = errors.New("content unchanged")
+
+// UploadIfChanged uploads reader to objectKey like Upload unless the content
+// is byte-for-byte identical to the object already stored where it would be
+// committed, in which case the object, its modification time and ETag are
+// left untouched and changed is false.
+//
+// The new content is hashed while it is staged to a temporary file, so
+// streams of unknown length are read only once. The comparison uses the
+// checksum recorded when the existing object was written, hashing the
+// existing object only if it predates stored checksums. The check and the
+// replace are not atomic with respect to concurrent writers of the same key.
+func (b *Backend) UploadIfChanged(ctx context.Context, objectKey string, reader io.Reader) (changed bool, err error) {
+	defer wrapError(&err, "upload_if_changed", objectKey)
+
+	_, err = b.uploadIf(ctx, reader, simplecontent.UploadParams{ObjectKey: objectKey}, true)
+	if errors.Is(err, errUnchanged) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// checkChanged returns errUnchanged when a staged upload holds the same
+// content as the object stored, loose or packed, where it is to be committed
+func (b *Backend) checkChanged(staged *stagedObject) error {
+	existing, err := b.contentSHA256(staged.filePath)
+	if os.IsNotExist(err) {
+		entry, err := b.packed(staged.filePath)
+		if err != nil || entry == nil {
+			return err
+		}
+		existing = entry.SHA256
+	} else if err != nil {
+		return err
+	}
+	if existing != "" && existing == staged.written.SHA256 {
+		return errUnchanged
+	}
+	return nil
+}
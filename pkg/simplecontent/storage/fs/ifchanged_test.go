@@ -0,0 +1,116 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_UploadIfChanged(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	changed, err := backend.UploadIfChanged(ctx, "sync/a.txt", strings.NewReader("v1"))
+	if err != nil || !changed {
+		t.Fatalf("expected first upload to write, changed=%v err=%v", changed, err)
+	}
+
+	path, _ := backend.objectPath("sync/a.txt")
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+
+	changed, err = backend.UploadIfChanged(ctx, "sync/a.txt", strings.NewReader("v1"))
+	if err != nil || changed {
+		t.Fatalf("expected identical upload to be skipped, changed=%v err=%v", changed, err)
+	}
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(past) {
+		t.Fatalf("expected mtime untouched, got %v (err=%v)", info.ModTime(), err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 2 {
+		t.Fatalf("expected only the object and its sidecar, got %d entries", len(entries))
+	}
+
+	changed, err = backend.UploadIfChanged(ctx, "sync/a.txt", strings.NewReader("v2"))
+	if err != nil || !changed {
+		t.Fatalf("expected different content to write, changed=%v err=%v", changed, err)
+	}
+	if got := readObject(t, backend, "sync/a.txt"); got != "v2" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
+
+func TestFSBackend_UploadIfChangedWithoutStoredChecksum(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	// An object written before checksums were recorded
+	path, _ := backend.objectPath("legacy")
+	if err := os.WriteFile(path, []byte("same"), 0644); err != nil {
+		t.Fatalf("write legacy object: %v", err)
+	}
+
+	changed, err := backend.UploadIfChanged(ctx, "legacy", strings.NewReader("same"))
+	if err != nil || changed {
+		t.Fatalf("expected identical legacy content to be skipped, changed=%v err=%v", changed, err)
+	}
+
+	if err := backend.PatchRange(ctx, "legacy", 0, []byte("S")); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	changed, err = backend.UploadIfChanged(ctx, "legacy", strings.NewReader("same"))
+	if err != nil || !changed {
+		t.Fatalf("expected patched object to be rewritten, changed=%v err=%v", changed, err)
+	}
+}
+
+func TestFSBackend_UploadIfChangedFinalizeKey(t *testing.T) {
+	b, err := New(Config{
+		BaseDir: t.TempDir(),
+		FinalizeKey: func(stagedKey string, meta simplecontent.ObjectMeta) (string, error) {
+			return "final/" + stagedKey, nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	changed, err := backend.UploadIfChanged(ctx, "a.txt", strings.NewReader("v1"))
+	if err != nil || !changed {
+		t.Fatalf("expected first upload to write, changed=%v err=%v", changed, err)
+	}
+	if got := readObject(t, backend, "final/a.txt"); got != "v1" {
+		t.Fatalf("expected object under the finalized key, got %q", got)
+	}
+	if ok, _ := backend.Exists(ctx, "a.txt"); ok {
+		t.Fatal("expected nothing under the staged key")
+	}
+
+	changed, err = backend.UploadIfChanged(ctx, "a.txt", strings.NewReader("v1"))
+	if err != nil || changed {
+		t.Fatalf("expected content matching the finalized key to be skipped, changed=%v err=%v", changed, err)
+	}
+	changed, err = backend.UploadIfChanged(ctx, "a.txt", strings.NewReader("v2"))
+	if err != nil || !changed {
+		t.Fatalf("expected different content to write, changed=%v err=%v", changed, err)
+	}
+	if got := readObject(t, backend, "final/a.txt"); got != "v2" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// FS returns a read-only io/fs view of the backend (see simplecontent.AsFS)
+// for http.FileServerFS, fs.WalkDir and similar consumers. Paths always use
+// "/" whatever the KeySeparator. Files List skips (sidecars, lease locks,
+// internal directories and, without IncludeHidden, dotfiles) are neither
+// listed nor opened. Uncompressed objects open as seekable files.
+func (b *Backend) FS() fs.FS {
+	return simplecontent.AsFS(fsView{b})
+}
+
+// fsView adapts the backend to the slash-separated paths of io/fs and hides
+// its internal files
+type fsView struct {
+	*Backend
+}
+
+func (v fsView) GetObjectMeta(ctx context.Context, name string) (*simplecontent.ObjectMeta, error) {
+	if v.internalPath(name) {
+		return nil, simplecontent.ErrObjectNotFound
+	}
+	// Directories are not objects; AsFS finds them through List
+	if info, err := v.FileInfo(v.key(name)); err == nil && info.IsDir() {
+		return nil, simplecontent.ErrObjectNotFound
+	}
+	meta, err := v.Backend.GetObjectMeta(ctx, v.key(name))
+	if err == nil {
+		meta.Key = strings.ReplaceAll(meta.Key, v.keySeparator, "/")
+	}
+	return meta, err
+}
+
+func (v fsView) Download(ctx context.Context, name string) (io.ReadCloser, error) {
+	if v.internalPath(name) {
+		return nil, simplecontent.ErrObjectNotFound
+	}
+	return v.Backend.Download(ctx, v.key(name))
+}
+
+func (v fsView) DownloadRange(ctx context.Context, name string, offset, length int64) (*simplecontent.ObjectRange, error) {
+	if v.internalPath(name) {
+		return nil, simplecontent.ErrObjectNotFound
+	}
+	return v.Backend.DownloadRange(ctx, v.key(name), offset, length)
+}
+
+func (v fsView) List(ctx context.Context, prefix string) ([]simplecontent.ObjectMeta, error) {
+	if v.keySeparator == "/" {
+		return v.Backend.List(ctx, prefix)
+	}
+	objects, err := v.Backend.List(ctx, strings.ReplaceAll(prefix, "/", v.keySeparator))
+	for i := range objects {
+		objects[i].Key = strings.ReplaceAll(objects[i].Key, v.keySeparator, "/")
+	}
+	return objects, err
+}
+
+// key maps a slash-separated path to the object key it names
+func (v fsView) key(name string) string {
+	return strings.ReplaceAll(name, "/", v.keySeparator)
+}
+
+// internalPath reports whether a slash-separated path names a file or
+// directory that List would skip
+func (v fsView) internalPath(name string) bool {
+	elems := strings.Split(name, "/")
+	if internalDirs[elems[0]] {
+		return true
+	}
+	for _, elem := range elems {
+		if v.isHidden(elem) {
+			return true
+		}
+	}
+	return isInternalFile(elems[len(elems)-1])
+}
@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"context"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestFSBackend_FS(t *testing.T) {
+	for _, sep := range []string{"/", ":"} {
+		t.Run(sep, func(t *testing.T) {
+			b, err := New(Config{BaseDir: t.TempDir(), KeySeparator: sep, Compression: CodecGzip})
+			if err != nil {
+				t.Fatalf("new fs backend: %v", err)
+			}
+			backend := b.(*Backend)
+			ctx := context.Background()
+
+			for _, key := range []string{"site/index.html", "site/css/main.css", "readme.txt"} {
+				key = strings.ReplaceAll(key, "/", sep)
+				if err := backend.Upload(ctx, key, strings.NewReader("content of "+key)); err != nil {
+					t.Fatalf("upload %s: %v", key, err)
+				}
+			}
+			if err := os.WriteFile(mustObjectPath(t, backend, ".DS_Store"), []byte("x"), 0644); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+
+			fsys := backend.FS()
+			if err := fstest.TestFS(fsys, "site/index.html", "site/css/main.css", "readme.txt"); err != nil {
+				t.Fatalf("fstest: %v", err)
+			}
+
+			var walked []string
+			err = fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				walked = append(walked, path)
+				return nil
+			})
+			want := []string{".", "readme.txt", "site", "site/css", "site/css/main.css", "site/index.html"}
+			if err != nil || !reflect.DeepEqual(walked, want) {
+				t.Fatalf("unexpected walk %v: %v", walked, err)
+			}
+
+			for _, name := range []string{".DS_Store", "readme.txt.meta.json", "missing"} {
+				if _, err := fs.Stat(fsys, name); !os.IsNotExist(err) {
+					t.Fatalf("expected %s not to exist, got %v", name, err)
+				}
+			}
+		})
+	}
+}
+
+func TestFSBackend_FSFileServer(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	if err := backend.Upload(context.Background(), "docs/page.txt", strings.NewReader("0123456789")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	srv := http.FileServerFS(backend.FS())
+	req := httptest.NewRequest(http.MethodGet, "/docs/page.txt", nil)
+	req.Header.Set("Range", "bytes=2-4")
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, req)
+	if rec.Code != http.StatusPartialContent || rec.Body.String() != "234" {
+		t.Fatalf("unexpected response %d %q", rec.Code, rec.Body.String())
+	}
+}
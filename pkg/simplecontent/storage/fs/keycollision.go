@@ -0,0 +1,185 @@
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// KeyCollisionPolicy selects what an upload to a key that already holds an
+// object does
+type KeyCollisionPolicy string
+
+const (
+	// KeyCollisionReplace replaces the existing object (default)
+	KeyCollisionReplace KeyCollisionPolicy = "replace"
+
+	// KeyCollisionError fails the upload with simplecontent.ErrObjectExists
+	// and keeps the existing object
+	KeyCollisionError KeyCollisionPolicy = "error"
+
+	// KeyCollisionSuffix keeps the existing object and commits the upload
+	// under the first free key with -1, -2, ... inserted before the
+	// extension ("photo.jpg" becomes "photo-1.jpg")
+	KeyCollisionSuffix KeyCollisionPolicy = "suffix"
+)
+
+// maxKeySuffix bounds the keys KeyCollisionSuffix tries
+const maxKeySuffix = 10000
+
+// validate checks that the collision policy is known
+func (p KeyCollisionPolicy) validate() error {
+	switch p {
+	case KeyCollisionReplace, KeyCollisionError, KeyCollisionSuffix:
+		return nil
+	default:
+		return fmt.Errorf("unknown key collision policy %q", p)
+	}
+}
+
+// collisionPolicy returns the collision policy of an upload: the configured
+// one, unless the upload asks not to replace an existing object
+func (b *Backend) collisionPolicy(params simplecontent.UploadParams) KeyCollisionPolicy {
+	if params.IfNotExists {
+		return KeyCollisionError
+	}
+	return b.onCollision
+}
+
+// checkCollision fails with simplecontent.ErrObjectExists, before an upload
+// stages any bytes, when collisions are errors and objectKey already holds
+// an object. Uploads whose key is chosen by FinalizeKey are only checked
+// when committed, as is a key taken while the upload is staged.
+func (b *Backend) checkCollision(objectKey, filePath string, policy KeyCollisionPolicy) error {
+	if policy != KeyCollisionError || b.finalizeKey != nil {
+		return nil
+	}
+	if entry, err := b.packed(filePath); err != nil {
+		return err
+	} else if entry != nil {
+		return fmt.Errorf("%w: %s", simplecontent.ErrObjectExists, objectKey)
+	}
+	if _, err := os.Lstat(filePath); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	sc, err := b.loadSidecar(filePath)
+	if err != nil || sc.reserved() {
+		return err
+	}
+	return fmt.Errorf("%w: %s", simplecontent.ErrObjectExists, objectKey)
+}
+
+// commitKey commits a staged upload to objectKey, applying policy, and
+// returns the key it was committed under
+func (b *Backend) commitKey(staged *stagedObject, objectKey string, policy KeyCollisionPolicy) (string, error) {
+	if policy == KeyCollisionReplace {
+		return objectKey, b.commitObject(staged)
+	}
+	return b.commitUnique(staged, objectKey, policy)
+}
+
+// commitUnique commits a staged upload to objectKey without replacing an
+// existing object, applying policy, and returns the key it was committed
+// under. Keys are claimed with a hardlink, so concurrent
+// uploads never claim the same key. A reservation counts as free, so the
+// upload fills it.
+func (b *Backend) commitUnique(staged *stagedObject, objectKey string, policy KeyCollisionPolicy) (string, error) {
+	for n := 0; ; n++ {
+		key := objectKey
+		if n > 0 {
+			key = b.suffixKey(objectKey, n)
+		}
+		filePath, err := b.objectPath(key)
+		if err != nil {
+			staged.discard()
+			return "", err
+		}
+
+		claimed, err := b.claimKey(staged, filePath)
+		if err != nil {
+			staged.discard()
+			return "", err
+		}
+		if claimed {
+			return key, nil
+		}
+		if policy == KeyCollisionError || n >= maxKeySuffix {
+			staged.discard()
+			return "", fmt.Errorf("%w: %s", simplecontent.ErrObjectExists, key)
+		}
+	}
+}
+
+// errKeyTaken is returned by the placement in claimKey when an object is
+// already stored at the key
+var errKeyTaken = errors.New("key taken")
+
+// claimKey commits the staged upload to filePath, under the object's lock,
+// unless an object is stored there, reporting whether it did
+func (b *Backend) claimKey(staged *stagedObject, filePath string) (bool, error) {
+	defer b.keyLocks.lock(filePath)()
+
+	err := b.recordWrite(filePath, staged.tmpPath, staged.written, func() error {
+		claimed, err := b.claimPath(staged, filePath)
+		if err == nil && !claimed {
+			return errKeyTaken
+		}
+		return err
+	})
+	if errors.Is(err, errKeyTaken) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	staged.filePath = filePath
+	return true, nil
+}
+
+// claimPath moves the staged file to filePath unless an object is stored
+// there, reporting whether it did
+func (b *Backend) claimPath(staged *stagedObject, filePath string) (bool, error) {
+	if entry, err := b.packed(filePath); err != nil || entry != nil {
+		return false, err
+	}
+	if err := b.mkdirAll(filepath.Dir(filePath)); err != nil {
+		return false, fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	err := os.Link(staged.tmpPath, filePath)
+	if os.IsExist(err) {
+		sc, err := b.loadSidecar(filePath)
+		if err != nil || !sc.reserved() {
+			return false, err
+		}
+		if err := replaceFile(staged.tmpPath, filePath, b.busyTimeout); err != nil {
+			return false, err
+		}
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to commit file: %w", err)
+	}
+	os.Remove(staged.tmpPath)
+	b.addDirEntry(filePath)
+	return true, nil
+}
+
+// suffixKey inserts -n before the extension of the last element of
+// objectKey
+func (b *Backend) suffixKey(objectKey string, n int) string {
+	start := 0
+	if i := strings.LastIndex(objectKey, b.keySeparator); i >= 0 {
+		start = i + len(b.keySeparator)
+	}
+	end := len(objectKey)
+	if i := strings.LastIndex(objectKey[start:], "."); i > 0 {
+		end = start + i
+	}
+	return objectKey[:end] + "-" + strconv.Itoa(n) + objectKey[end:]
+}
@@ -0,0 +1,237 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_KeyCollisionSuffix(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), OnKeyCollision: KeyCollisionSuffix})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	var keys []string
+	for _, content := range []string{"alice", "bob", "carol"} {
+		result, err := backend.UploadWithResult(ctx, strings.NewReader(content), simplecontent.UploadParams{ObjectKey: "uploads/photo.jpg"})
+		if err != nil {
+			t.Fatalf("upload %s: %v", content, err)
+		}
+		keys = append(keys, result.Key)
+	}
+	want := []string{"uploads/photo.jpg", "uploads/photo-1.jpg", "uploads/photo-2.jpg"}
+	for i, key := range want {
+		if keys[i] != key {
+			t.Fatalf("expected keys %v, got %v", want, keys)
+		}
+	}
+	if got := readObject(t, backend, "uploads/photo.jpg"); got != "alice" {
+		t.Fatalf("expected the first upload to be kept, got %q", got)
+	}
+	if got := readObject(t, backend, "uploads/photo-2.jpg"); got != "carol" {
+		t.Fatalf("unexpected content of suffixed key: %q", got)
+	}
+
+	// A reserved key is filled rather than suffixed
+	if err := backend.Reserve(ctx, "uploads/report", time.Minute); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	result, err := backend.UploadWithResult(ctx, strings.NewReader("report"), simplecontent.UploadParams{ObjectKey: "uploads/report"})
+	if err != nil || result.Key != "uploads/report" {
+		t.Fatalf("expected reservation to be filled, got %+v, %v", result, err)
+	}
+}
+
+func TestFSBackend_KeyCollisionSuffixEveryWriter(t *testing.T) {
+	ctx := context.Background()
+	for name, write := range writeEntryPoints(ctx) {
+		t.Run(name, func(t *testing.T) {
+			b, err := New(Config{BaseDir: t.TempDir(), OnKeyCollision: KeyCollisionSuffix})
+			if err != nil {
+				t.Fatalf("new fs backend: %v", err)
+			}
+			backend := b.(*Backend)
+			if err := backend.Upload(ctx, "k.txt", strings.NewReader("original")); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+
+			if err := write(backend, "k.txt", strings.NewReader("updated")); err != nil {
+				t.Fatalf("write: %v", err)
+			}
+			if got := readObject(t, backend, "k.txt"); got != "original" {
+				t.Fatalf("expected existing object kept, got %q", got)
+			}
+			if got := readObject(t, backend, "k-1.txt"); got != "updated" {
+				t.Fatalf("expected write under the suffixed key, got %q", got)
+			}
+		})
+	}
+}
+
+func TestFSBackend_KeyCollisionErrorAtCommit(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), OnKeyCollision: KeyCollisionError})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	batch := backend.NewBatch()
+	if err := backend.StageBatch(ctx, batch, "k.txt", strings.NewReader("staged")); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := backend.Upload(ctx, "k.txt", strings.NewReader("original")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.CommitBatch(ctx, batch); !errors.Is(err, simplecontent.ErrObjectExists) {
+		t.Fatalf("expected key taken while staged to fail the commit, got %v", err)
+	}
+	if got := readObject(t, backend, "k.txt"); got != "original" {
+		t.Fatalf("expected existing object kept, got %q", got)
+	}
+}
+
+func TestFSBackend_KeyCollisionError(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), OnKeyCollision: KeyCollisionError})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "a.txt", strings.NewReader("first")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Upload(ctx, "a.txt", strings.NewReader("second")); !errors.Is(err, simplecontent.ErrObjectExists) {
+		t.Fatalf("expected ErrObjectExists, got %v", err)
+	}
+	if got := readObject(t, backend, "a.txt"); got != "first" {
+		t.Fatalf("expected existing object to be kept, got %q", got)
+	}
+
+	if _, err := New(Config{BaseDir: t.TempDir(), OnKeyCollision: "rename"}); err == nil {
+		t.Fatal("expected an unknown policy to be rejected")
+	}
+}
+
+// gatedReader reports its first Read on started, then blocks it until
+// gate is closed
+type gatedReader struct {
+	started chan<- struct{}
+	gate    <-chan struct{}
+	once    sync.Once
+	r       io.Reader
+}
+
+func (g *gatedReader) Read(p []byte) (int, error) {
+	g.once.Do(func() {
+		g.started <- struct{}{}
+		<-g.gate
+	})
+	return g.r.Read(p)
+}
+
+func TestFSBackend_UploadIfNotExists(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "a.txt", strings.NewReader("first")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	params := simplecontent.UploadParams{ObjectKey: "a.txt", IfNotExists: true}
+	if err := backend.UploadWithParams(ctx, strings.NewReader("second"), params); !errors.Is(err, simplecontent.ErrObjectExists) {
+		t.Fatalf("expected ErrObjectExists, got %v", err)
+	}
+	if got := readObject(t, backend, "a.txt"); got != "first" {
+		t.Fatalf("expected existing object to be kept, got %q", got)
+	}
+
+	// Both uploads find the key free and stage before either commits
+	for i := range 20 {
+		key := fmt.Sprintf("race/%d.txt", i)
+		started := make(chan struct{})
+		gate := make(chan struct{})
+		errs := make(chan error, 2)
+		for _, content := range []string{"one", "two"} {
+			go func() {
+				reader := &gatedReader{started: started, gate: gate, r: strings.NewReader(content)}
+				errs <- backend.UploadWithParams(ctx, reader, simplecontent.UploadParams{ObjectKey: key, IfNotExists: true})
+			}()
+		}
+		<-started
+		<-started
+		close(gate)
+
+		won := 0
+		for range 2 {
+			if err := <-errs; err == nil {
+				won++
+			} else if !errors.Is(err, simplecontent.ErrObjectExists) {
+				t.Fatalf("expected ErrObjectExists, got %v", err)
+			}
+		}
+		if won != 1 {
+			t.Fatalf("expected exactly one upload to win, %d did", won)
+		}
+		if got := readObject(t, backend, key); got != "one" && got != "two" {
+			t.Fatalf("unexpected content %q", got)
+		}
+	}
+	if keys := listKeys(t, backend, "race/"); len(keys) != 20 {
+		t.Fatalf("expected no leftovers from losing uploads, got %v", keys)
+	}
+}
+
+func TestSuffixKey(t *testing.T) {
+	backend := &Backend{keySeparator: "::"}
+	for key, want := range map[string]string{
+		"photo.jpg":        "photo-3.jpg",
+		"a.b::photo":       "a.b::photo-3",
+		"dir::archive.tar": "dir::archive-3.tar",
+		"dir::.hidden":     "dir::.hidden-3",
+		"plain":            "plain-3",
+	} {
+		if got := backend.suffixKey(key, 3); got != want {
+			t.Fatalf("suffixKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestFSBackend_KeySeparatorRoundTrip(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), KeySeparator: ":", OnKeyCollision: KeyCollisionSuffix})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	// A slash would nest the key in a directory it is not listed under
+	if err := backend.Upload(ctx, "tenant:a/b.txt", strings.NewReader("x")); !errors.Is(err, simplecontent.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey for a key containing \"/\", got %v", err)
+	}
+
+	var keys []string
+	for range 2 {
+		result, err := backend.UploadWithResult(ctx, strings.NewReader("x"), simplecontent.UploadParams{ObjectKey: "tenant:a.b:c.txt"})
+		if err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		keys = append(keys, result.Key)
+	}
+	if want := []string{"tenant:a.b:c.txt", "tenant:a.b:c-1.txt"}; fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Fatalf("expected keys %v, got %v", want, keys)
+	}
+	if listed := listKeys(t, backend, ""); fmt.Sprint(listed) != fmt.Sprint([]string{keys[1], keys[0]}) {
+		t.Fatalf("expected uploaded keys to be listed as they were uploaded, got %v", listed)
+	}
+}
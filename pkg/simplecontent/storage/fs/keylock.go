@@ -0,0 +1,79 @@
+package fs
+
+import "sync"
+
+// keyLocks serialises writes to the same object file within the process,
+// so an object and its sidecar are always replaced together, while writes
+// to other objects proceed in parallel. Readers share the lock while they
+// pair an opened file with its sidecar. Entries are removed once no one
+// holds or waits for them.
+type keyLocks struct {
+	mu    sync.Mutex
+	locks map[string]*keyLock
+}
+
+// keyLock is the lock of one object file
+type keyLock struct {
+	sync.RWMutex
+	refs int // Holders and waiters
+}
+
+// lock takes the write lock of filePath and returns its release
+func (l *keyLocks) lock(filePath string) (unlock func()) {
+	k := l.acquire(filePath)
+	k.Lock()
+	return func() {
+		k.Unlock()
+		l.release(filePath, k)
+	}
+}
+
+// rlock takes the read lock of filePath and returns its release
+func (l *keyLocks) rlock(filePath string) (unlock func()) {
+	k := l.acquire(filePath)
+	k.RLock()
+	return func() {
+		k.RUnlock()
+		l.release(filePath, k)
+	}
+}
+
+// lockPair takes the write locks of two object files in a fixed order, so
+// concurrent calls on the same pair cannot deadlock
+func (l *keyLocks) lockPair(a, b string) (unlock func()) {
+	if a == b {
+		return l.lock(a)
+	}
+	if b < a {
+		a, b = b, a
+	}
+	unlockA := l.lock(a)
+	unlockB := l.lock(b)
+	return func() {
+		unlockB()
+		unlockA()
+	}
+}
+
+func (l *keyLocks) acquire(filePath string) *keyLock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.locks == nil {
+		l.locks = make(map[string]*keyLock)
+	}
+	k, ok := l.locks[filePath]
+	if !ok {
+		k = &keyLock{}
+		l.locks[filePath] = k
+	}
+	k.refs++
+	return k
+}
+
+func (l *keyLocks) release(filePath string, k *keyLock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if k.refs--; k.refs == 0 {
+		delete(l.locks, filePath)
+	}
+}
@@ -0,0 +1,109 @@
+package fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKeyLocks(t *testing.T) {
+	var locks keyLocks
+
+	unlockA := locks.lock("a")
+	acquired, released := make(chan struct{}), make(chan struct{})
+	go func() {
+		unlock := locks.lock("a")
+		close(acquired)
+		unlock()
+		close(released)
+	}()
+
+	// Other keys proceed while a is held
+	locks.lock("b")()
+	locks.lockPair("c", "b")()
+	select {
+	case <-acquired:
+		t.Fatal("second writer of a acquired the lock while it was held")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	unlockA()
+	select {
+	case <-acquired:
+	case <-time.After(time.Second):
+		t.Fatal("second writer of a never acquired the lock")
+	}
+	<-released
+	locks.rlock("a")()
+
+	locks.mu.Lock()
+	defer locks.mu.Unlock()
+	if len(locks.locks) != 0 {
+		t.Fatalf("expected released locks removed, got %d", len(locks.locks))
+	}
+}
+
+func TestFSBackend_ConcurrentWritesToSameKey(t *testing.T) {
+	b := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	versions := make(map[string]bool)
+	for i := range 8 {
+		versions[strings.Repeat(fmt.Sprintf("version %d;", i), 1000*(i+1))] = true
+	}
+	if err := b.Upload(ctx, "k.txt", strings.NewReader("initial")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	versions["initial"] = true
+
+	var wg sync.WaitGroup
+	for content := range versions {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				if err := b.Upload(ctx, "k.txt", strings.NewReader(content)); err != nil {
+					t.Errorf("upload: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 20 {
+				rc, err := b.Download(ctx, "k.txt")
+				if err != nil {
+					t.Errorf("download: %v", err)
+					return
+				}
+				data, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil || !versions[string(data)] {
+					t.Errorf("read a mix of versions (%d bytes): %v", len(data), err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// The sidecar describes the content that won
+	content := readObject(t, b, "k.txt")
+	meta, err := b.GetObjectMeta(ctx, "k.txt")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	if meta.Metadata["sha256"] != hex.EncodeToString(sum[:]) || meta.Size != int64(len(content)) {
+		t.Fatalf("sidecar does not match stored content: %+v", meta)
+	}
+}
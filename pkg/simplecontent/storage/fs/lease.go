@@ -0,0 +1,234 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// lockSuffix is appended to an object's path to name its lease lock file
+const lockSuffix = ".lock"
+
+// lease is the content of a <key>.lock file
+type lease struct {
+	ID        string    `json:"id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// readLease loads the lease recorded in a lock file
+func readLease(path string) (*lease, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var l lease
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("failed to decode lease: %w", err)
+	}
+	return &l, nil
+}
+
+// AcquireLease takes an exclusive lease on an existing object for ttl, so
+// workers sharing the store can agree on who processes it. The lease is
+// recorded in a <key>.lock file next to the object and expires on its own if
+// the holder crashes; an expired lease is taken over by the next caller.
+// AcquireLease returns simplecontent.ErrLeaseHeld while another lease is live.
+//
+// Leases are advisory: they do not block reads or writes of the object,
+// except that with EnforceLeases set, uploads to it by other backends fail
+// with simplecontent.ErrObjectBusy.
+func (b *Backend) AcquireLease(ctx context.Context, objectKey string, ttl time.Duration) (_ string, err error) {
+	defer wrapError(&err, "acquire_lease", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return "", err
+	}
+
+	if ttl <= 0 {
+		return "", errors.New("lease ttl must be positive")
+	}
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(filePath); os.IsNotExist(err) {
+		return "", simplecontent.ErrObjectNotFound
+	} else if err != nil {
+		return "", fmt.Errorf("failed to get file info: %w", err)
+	}
+
+	leaseID, err := acquireLock(ctx, filePath+lockSuffix, ttl)
+	if err != nil {
+		return "", err
+	}
+	b.leaseMu.Lock()
+	b.leases[leaseID] = filePath + lockSuffix
+	b.leaseMu.Unlock()
+	return leaseID, nil
+}
+
+// acquireLock creates the lock file lockPath holding a new lease that
+// expires after ttl, taking over an expired lease, and returns the lease ID.
+// It returns simplecontent.ErrLeaseHeld while another lease is live.
+func acquireLock(ctx context.Context, lockPath string, ttl time.Duration) (string, error) {
+	var id [16]byte
+	_, _ = rand.Read(id[:])
+	leaseID := hex.EncodeToString(id[:])
+	data, err := json.Marshal(lease{ID: leaseID, ExpiresAt: time.Now().Add(ttl).UTC()})
+	if err != nil {
+		return "", fmt.Errorf("failed to encode lease: %w", err)
+	}
+
+	// Write the lease in full before publishing it with os.Link, which fails
+	// rather than replacing a lock another worker holds
+	file, err := createTemp(lockPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create lock file: %w", err)
+	}
+	tmpPath := file.Name()
+	defer os.Remove(tmpPath)
+	_, err = file.Write(data)
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to write lock file: %w", err)
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return "", err
+		}
+		err := os.Link(tmpPath, lockPath)
+		if err == nil {
+			return leaseID, nil
+		} else if !os.IsExist(err) {
+			return "", fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		held, err := readLease(lockPath)
+		if os.IsNotExist(err) {
+			// Released concurrently
+			continue
+		} else if err != nil {
+			return "", err
+		}
+		if time.Now().Before(held.ExpiresAt) {
+			return "", simplecontent.ErrLeaseHeld
+		}
+		if err := breakLease(lockPath, held.ID); err != nil {
+			return "", err
+		}
+	}
+	return "", simplecontent.ErrLeaseHeld
+}
+
+// breakLease removes the expired lease id from lockPath. The lock is moved
+// aside first so that a fresh lease taken by a concurrent caller is put back
+// rather than deleted; that case reports simplecontent.ErrLeaseHeld.
+func breakLease(lockPath, id string) error {
+	stale := tempName(lockPath)
+	if err := os.Rename(lockPath, stale); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to remove expired lease: %w", err)
+	}
+	defer os.Remove(stale)
+
+	if moved, err := readLease(stale); err == nil && moved.ID != id {
+		_ = os.Link(stale, lockPath)
+		return simplecontent.ErrLeaseHeld
+	}
+	return nil
+}
+
+// ReleaseLease gives up a lease returned by AcquireLease. Releasing an
+// unknown lease, or one that expired and was taken over by another worker,
+// does nothing.
+func (b *Backend) ReleaseLease(leaseID string) {
+	b.leaseMu.Lock()
+	lockPath, ok := b.leases[leaseID]
+	delete(b.leases, leaseID)
+	b.leaseMu.Unlock()
+	if ok {
+		releaseLock(lockPath, leaseID)
+	}
+}
+
+// renewLock keeps lease id on lockPath from expiring, extending it every
+// third of ttl until the returned function is called, so the lock is only
+// ever taken over from a holder that has stopped
+func renewLock(lockPath, id string, ttl time.Duration) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = extendLock(lockPath, id, ttl)
+			}
+		}
+	}()
+	return func() {
+		close(done)
+		<-stopped
+	}
+}
+
+// extendLock moves the expiry of lease id on lockPath to ttl from now, if
+// lockPath still holds it
+func extendLock(lockPath, id string, ttl time.Duration) error {
+	held, err := readLease(lockPath)
+	if err != nil || held.ID != id {
+		return err
+	}
+	data, err := json.Marshal(lease{ID: id, ExpiresAt: time.Now().Add(ttl).UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to encode lease: %w", err)
+	}
+	return writeReplace(context.Background(), lockPath, bytes.NewReader(data), 0)
+}
+
+// releaseLock removes lockPath if it still holds lease id
+func releaseLock(lockPath, id string) {
+	if held, err := readLease(lockPath); err == nil && held.ID == id {
+		_ = breakLease(lockPath, id)
+	}
+}
+
+// checkLease returns simplecontent.ErrObjectBusy when leases are enforced
+// and another backend holds a live lease on the object at filePath
+func (b *Backend) checkLease(filePath string) error {
+	if !b.enforceLeases {
+		return nil
+	}
+	held, err := readLease(filePath + lockSuffix)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if !time.Now().Before(held.ExpiresAt) {
+		return nil
+	}
+	b.leaseMu.Lock()
+	_, own := b.leases[held.ID]
+	b.leaseMu.Unlock()
+	if own {
+		return nil
+	}
+	return fmt.Errorf("%w: leased until %s", simplecontent.ErrObjectBusy, held.ExpiresAt.Format(time.RFC3339))
+}
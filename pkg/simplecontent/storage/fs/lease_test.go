@@ -0,0 +1,204 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Leases(t *testing.T) {
+	dir := t.TempDir()
+	worker1 := newCompressedBackend(t, dir, "")
+	worker2 := newCompressedBackend(t, dir, "")
+	ctx := context.Background()
+
+	if err := worker1.Upload(ctx, "jobs/input.csv", strings.NewReader("a,b")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if _, err := worker1.AcquireLease(ctx, "jobs/missing.csv", time.Minute); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+
+	id, err := worker1.AcquireLease(ctx, "jobs/input.csv", time.Minute)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	if _, err := worker2.AcquireLease(ctx, "jobs/input.csv", time.Minute); !errors.Is(err, simplecontent.ErrLeaseHeld) {
+		t.Fatalf("expected ErrLeaseHeld, got %v", err)
+	}
+	if keys := listKeys(t, worker1, "jobs/"); len(keys) != 1 {
+		t.Fatalf("expected lock file excluded from listing, got %v", keys)
+	}
+
+	// Releasing someone else's lease ID does nothing
+	worker2.ReleaseLease(id)
+	if _, err := worker2.AcquireLease(ctx, "jobs/input.csv", time.Minute); !errors.Is(err, simplecontent.ErrLeaseHeld) {
+		t.Fatalf("expected lease still held, got %v", err)
+	}
+
+	worker1.ReleaseLease(id)
+	if _, err := os.Stat(mustObjectPath(t, worker1, "jobs/input.csv") + lockSuffix); !os.IsNotExist(err) {
+		t.Fatalf("expected lock file removed on release")
+	}
+	if _, err := worker2.AcquireLease(ctx, "jobs/input.csv", time.Minute); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+}
+
+func TestFSBackend_LeaseExpiry(t *testing.T) {
+	dir := t.TempDir()
+	crashed := newCompressedBackend(t, dir, "")
+	worker := newCompressedBackend(t, dir, "")
+	ctx := context.Background()
+
+	if err := crashed.Upload(ctx, "k", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	stale, err := crashed.AcquireLease(ctx, "k", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	id, err := worker.AcquireLease(ctx, "k", time.Minute)
+	if err != nil {
+		t.Fatalf("expected expired lease to be taken over: %v", err)
+	}
+
+	// A late release of the expired lease leaves the new holder's lock
+	crashed.ReleaseLease(stale)
+	held, err := readLease(mustObjectPath(t, worker, "k") + lockSuffix)
+	if err != nil || held.ID != id {
+		t.Fatalf("expected new lease intact, got %+v: %v", held, err)
+	}
+}
+
+// unreadSource fails the test if an upload reads from it
+type unreadSource struct{ t *testing.T }
+
+func (s unreadSource) Read([]byte) (int, error) {
+	s.t.Error("upload read content for a key it cannot write")
+	return 0, io.EOF
+}
+
+func TestFSBackend_UploadToProtectedKey(t *testing.T) {
+	ctx := context.Background()
+	protect := map[string]struct {
+		config  Config
+		setup   func(t *testing.T, owner, other *Backend)
+		wantErr error // nil when the upload succeeds
+	}{
+		"lease held by another backend": {
+			config: Config{EnforceLeases: true},
+			setup: func(t *testing.T, owner, other *Backend) {
+				if _, err := other.AcquireLease(ctx, "k.txt", time.Minute); err != nil {
+					t.Fatalf("acquire: %v", err)
+				}
+			},
+			wantErr: simplecontent.ErrObjectBusy,
+		},
+		"lease held by the uploader": {
+			config: Config{EnforceLeases: true},
+			setup: func(t *testing.T, owner, other *Backend) {
+				if _, err := owner.AcquireLease(ctx, "k.txt", time.Minute); err != nil {
+					t.Fatalf("acquire: %v", err)
+				}
+			},
+		},
+		"expired lease": {
+			config: Config{EnforceLeases: true},
+			setup: func(t *testing.T, owner, other *Backend) {
+				if _, err := other.AcquireLease(ctx, "k.txt", time.Millisecond); err != nil {
+					t.Fatalf("acquire: %v", err)
+				}
+				time.Sleep(5 * time.Millisecond)
+			},
+		},
+		"advisory lease": {
+			setup: func(t *testing.T, owner, other *Backend) {
+				if _, err := other.AcquireLease(ctx, "k.txt", time.Minute); err != nil {
+					t.Fatalf("acquire: %v", err)
+				}
+			},
+		},
+		"existing object with collisions as errors": {
+			config:  Config{OnKeyCollision: KeyCollisionError},
+			wantErr: simplecontent.ErrObjectExists,
+		},
+		"packed object with collisions as errors": {
+			config: Config{OnKeyCollision: KeyCollisionError},
+			setup: func(t *testing.T, owner, other *Backend) {
+				other.packAge = 0
+				if err := other.Compact(ctx, ""); err != nil {
+					t.Fatalf("compact: %v", err)
+				}
+			},
+			wantErr: simplecontent.ErrObjectExists,
+		},
+		"reservation with collisions as errors": {
+			config: Config{OnKeyCollision: KeyCollisionError},
+			setup: func(t *testing.T, owner, other *Backend) {
+				if err := other.Delete(ctx, "k.txt"); err != nil {
+					t.Fatalf("delete: %v", err)
+				}
+				if err := other.Reserve(ctx, "k.txt", time.Minute); err != nil {
+					t.Fatalf("reserve: %v", err)
+				}
+			},
+		},
+	}
+
+	for name, tc := range protect {
+		for method, write := range writeEntryPoints(ctx) {
+			t.Run(name+"/"+method, func(t *testing.T) {
+				dir := t.TempDir()
+				tc.config.BaseDir = dir
+				store, err := New(tc.config)
+				if err != nil {
+					t.Fatalf("new fs backend: %v", err)
+				}
+				owner := store.(*Backend)
+				other := newCompressedBackend(t, dir, "")
+				if err := other.Upload(ctx, "k.txt", strings.NewReader("original")); err != nil {
+					t.Fatalf("upload: %v", err)
+				}
+				if tc.setup != nil {
+					tc.setup(t, owner, other)
+				}
+
+				if tc.wantErr == nil {
+					if err := write(owner, "k.txt", strings.NewReader("updated")); err != nil {
+						t.Fatalf("upload: %v", err)
+					}
+					if got := readObject(t, owner, "k.txt"); got != "updated" {
+						t.Fatalf("expected upload to replace the object, got %q", got)
+					}
+					return
+				}
+
+				err = write(owner, "k.txt", unreadSource{t})
+				if !errors.Is(err, tc.wantErr) {
+					t.Fatalf("expected %v, got %v", tc.wantErr, err)
+				}
+				if got := readObject(t, owner, "k.txt"); got != "original" {
+					t.Fatalf("expected object kept, got %q", got)
+				}
+				entries, err := os.ReadDir(dir)
+				if err != nil {
+					t.Fatalf("read dir: %v", err)
+				}
+				for _, entry := range entries {
+					if isTempName(entry.Name()) {
+						t.Fatalf("expected nothing staged, found %s", entry.Name())
+					}
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package fs
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// internalDirs are top-level directories under baseDir that the backend
+// reserves for its own bookkeeping and never exposes as objects
+var internalDirs = map[string]bool{
+	".trash":      true,
+	previewDir:    true,
+	quarantineDir: true,
+	aliasDir:      true,
+	packDir:       true,
+	multipartDir:  true,
+}
+
+// isInternalFile reports whether a file name is a companion file kept next
+// to objects (metadata sidecars and indexes, lease and counter locks,
+// in-progress or abandoned uploads) rather than an object
+func isInternalFile(name string) bool {
+	return strings.HasSuffix(name, sidecarSuffix) || strings.HasSuffix(name, stagedSidecarSuffix) || strings.HasSuffix(name, lockSuffix) || strings.HasSuffix(name, counterLockSuffix) ||
+		isTempName(name) || name == sidecarIndexName
+}
+
+// isHidden reports whether enumeration skips a dot-prefixed file or
+// directory, such as .DS_Store, because IncludeHidden is off
+func (b *Backend) isHidden(name string) bool {
+	return !b.includeHidden && strings.HasPrefix(name, ".")
+}
+
+// List returns the metadata of every object whose key starts with prefix,
+// ordered by key. An empty prefix lists all objects. Sidecars, temporary
+// upload files, internal directories and unfilled reservations are never
+// listed, nor are dot-prefixed files and directories unless IncludeHidden is
+// set. ContentType is only populated when it was declared at upload; use
+// GetObjectMeta to detect it.
+func (b *Backend) List(ctx context.Context, prefix string) (_ []simplecontent.ObjectMeta, err error) {
+	defer wrapError(&err, "list", prefix)
+
+	var objects []simplecontent.ObjectMeta
+	err = b.walk(ctx, prefix, func(meta simplecontent.ObjectMeta) error {
+		objects = append(objects, meta)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Walk calls fn for every object whose key starts with prefix, with the same
+// filtering as List, without collecting the results. Walking stops at the
+// first error returned by fn.
+func (b *Backend) Walk(ctx context.Context, prefix string, fn func(simplecontent.ObjectMeta) error) (err error) {
+	defer wrapError(&err, "walk", prefix)
+	return b.walk(ctx, prefix, fn)
+}
+
+// WalkWithMeta is Walk reporting each object's full metadata, as
+// GetObjectMeta would, from the same directory walk: the content type and
+// checksum come from the sidecar, and an object is only opened to detect
+// its content type when none was declared at upload. The checksum recorded
+// at upload, if any, is in Metadata["sha256"].
+func (b *Backend) WalkWithMeta(ctx context.Context, prefix string, fn func(simplecontent.ObjectMeta) error) (err error) {
+	defer wrapError(&err, "walk_with_meta", prefix)
+
+	return b.walkObjects(ctx, prefix, func(filePath string, meta simplecontent.ObjectMeta, sc *sidecar) error {
+		if err := b.describeObject(&meta, filePath, sc); err != nil {
+			return err
+		}
+		return fn(meta)
+	})
+}
+
+// walk visits the objects under prefix
+func (b *Backend) walk(ctx context.Context, prefix string, fn func(simplecontent.ObjectMeta) error) error {
+	return b.walkObjects(ctx, prefix, func(_ string, meta simplecontent.ObjectMeta, _ *sidecar) error {
+		return fn(meta)
+	})
+}
+
+// walkObjects visits the objects under prefix with their file path and
+// sidecar, skipping unfilled reservations
+func (b *Backend) walkObjects(ctx context.Context, prefix string, fn func(filePath string, meta simplecontent.ObjectMeta, sc *sidecar) error) error {
+	return b.walkFiles(ctx, prefix, func(filePath string, meta simplecontent.ObjectMeta, sc *sidecar) error {
+		if sc.reserved() {
+			return nil
+		}
+		return fn(filePath, meta, sc)
+	})
+}
+
+// walkFiles visits the object files under prefix, reservations included,
+// starting from the deepest directory the prefix names so unrelated subtrees
+// are not read. With ShardDepth, every shard is walked, skipping the
+// directories below the shard levels that the prefix rules out.
+func (b *Backend) walkFiles(ctx context.Context, prefix string, fn func(filePath string, meta simplecontent.ObjectMeta, sc *sidecar) error) error {
+	root := b.baseDir
+	if i := strings.LastIndex(prefix, b.keySeparator); i > 0 && b.shardDepth == 0 {
+		dir, err := b.dirPath(prefix[:i])
+		if err != nil {
+			return err
+		}
+		root = dir
+	}
+
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			if filepath.Dir(path) == b.baseDir && internalDirs[d.Name()] || path == b.stagingDir {
+				return fs.SkipDir
+			}
+			if path != root && b.isHidden(d.Name()) {
+				return fs.SkipDir
+			}
+			if b.shardDepth > 0 && path != root && b.skipShardDir(path, d.Name(), prefix) {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || isInternalFile(d.Name()) || b.isHidden(d.Name()) {
+			return nil
+		}
+
+		key, err := b.objectKey(path)
+		if err != nil && b.shardDepth > 0 {
+			// A file among the shard directories
+			return nil
+		} else if err != nil || !strings.HasPrefix(key, prefix) || b.misplaced(path, key) {
+			return err
+		}
+
+		info, err := d.Info()
+		if os.IsNotExist(err) {
+			// Deleted or replaced while walking
+			return nil
+		} else if err != nil {
+			return err
+		}
+		sc, err := b.loadSidecar(path)
+		if err != nil {
+			return err
+		}
+
+		return fn(path, b.fileMeta(key, info, sc), sc)
+	})
+}
@@ -0,0 +1,202 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func listKeys(t *testing.T, b *Backend, prefix string) []string {
+	t.Helper()
+	objects, err := b.List(context.Background(), prefix)
+	if err != nil {
+		t.Fatalf("list %q: %v", prefix, err)
+	}
+	keys := []string{}
+	for _, obj := range objects {
+		keys = append(keys, obj.Key)
+	}
+	return keys
+}
+
+func TestFSBackend_ListSkipsInternalFiles(t *testing.T) {
+	dir := t.TempDir()
+	b, err := New(Config{BaseDir: dir, TimestampSource: TimestampSidecar, Compression: CodecGzip})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+
+	if err := backend.Upload(context.Background(), "docs/report.txt", strings.NewReader("hello")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	path, _ := backend.objectPath("docs/report.txt")
+	if _, err := os.Stat(sidecarPath(path)); err != nil {
+		t.Fatalf("expected sidecar to exist: %v", err)
+	}
+
+	// Leftovers of an upload interrupted by a crash, and backend bookkeeping
+	if err := os.WriteFile(tempName(path), []byte("partial"), 0644); err != nil {
+		t.Fatalf("write temp file: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, ".trash"), 0755); err != nil {
+		t.Fatalf("mkdir trash: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".trash", "old"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write trash file: %v", err)
+	}
+
+	objects, err := backend.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(objects) != 1 || objects[0].Key != "docs/report.txt" {
+		t.Fatalf("expected exactly docs/report.txt, got %+v", objects)
+	}
+	if objects[0].Size != 5 || objects[0].CreatedAt.IsZero() {
+		t.Fatalf("expected size and creation time from sidecar, got %+v", objects[0])
+	}
+
+	var walked []string
+	err = backend.Walk(context.Background(), "", func(meta simplecontent.ObjectMeta) error {
+		walked = append(walked, meta.Key)
+		return nil
+	})
+	if err != nil || !reflect.DeepEqual(walked, []string{"docs/report.txt"}) {
+		t.Fatalf("unexpected walk result %v: %v", walked, err)
+	}
+}
+
+func TestFSBackend_InternalFileKeysRejected(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+	if err := backend.UploadWithParams(ctx, strings.NewReader("a"), simplecontent.UploadParams{ObjectKey: "a.txt", MimeType: "text/plain"}); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	for _, key := range []string{"a.txt" + sidecarSuffix, "a.txt" + lockSuffix, tempName("a.txt"), "docs/" + sidecarIndexName} {
+		if err := backend.Upload(ctx, key, strings.NewReader("garbage")); !errors.Is(err, simplecontent.ErrInvalidKey) {
+			t.Errorf("expected ErrInvalidKey uploading %s, got %v", key, err)
+		}
+	}
+
+	meta, err := backend.GetObjectMeta(ctx, "a.txt")
+	if err != nil || meta.ContentType != "text/plain" {
+		t.Fatalf("expected the sidecar intact, got %+v, %v", meta, err)
+	}
+	if keys := listKeys(t, backend, ""); !reflect.DeepEqual(keys, []string{"a.txt"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+
+	// Directories may still be named like companion files
+	if err := backend.Upload(ctx, "b.lock/c.txt", strings.NewReader("c")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if keys := listKeys(t, backend, "b.lock/"); !reflect.DeepEqual(keys, []string{"b.lock/c.txt"}) {
+		t.Fatalf("unexpected keys %v", keys)
+	}
+}
+
+func TestFSBackend_ListPrefix(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), KeySeparator: ":"})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+
+	for _, key := range []string{"a:1", "a:2", "ab:1", "b:1"} {
+		if err := backend.Upload(context.Background(), key, strings.NewReader(key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+
+	if got := listKeys(t, backend, ""); !reflect.DeepEqual(got, []string{"a:1", "a:2", "ab:1", "b:1"}) {
+		t.Fatalf("unexpected full listing %v", got)
+	}
+	if got := listKeys(t, backend, "a"); !reflect.DeepEqual(got, []string{"a:1", "a:2", "ab:1"}) {
+		t.Fatalf("unexpected listing for prefix a: %v", got)
+	}
+	if got := listKeys(t, backend, "a:"); !reflect.DeepEqual(got, []string{"a:1", "a:2"}) {
+		t.Fatalf("unexpected listing for prefix a: %v", got)
+	}
+	if got := listKeys(t, backend, "missing:x"); len(got) != 0 {
+		t.Fatalf("expected empty listing for missing prefix, got %v", got)
+	}
+}
+
+func TestFSBackend_ListHiddenFiles(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+	for _, path := range []string{".DS_Store", "photos/.DS_Store", ".cache/thumb.png", "photos/cat.jpg", ".quarantine/bad"} {
+		full := filepath.Join(dir, filepath.FromSlash(path))
+		if err := os.MkdirAll(filepath.Dir(full), 0755); err != nil {
+			t.Fatalf("mkdir: %v", err)
+		}
+		if err := os.WriteFile(full, []byte("x"), 0644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	backend := newCompressedBackend(t, dir, "")
+	if keys := listKeys(t, backend, ""); !reflect.DeepEqual(keys, []string{"photos/cat.jpg"}) {
+		t.Fatalf("expected dotfiles excluded, got %v", keys)
+	}
+	if prefixes, err := backend.ListPrefixes(ctx, 1); err != nil || !reflect.DeepEqual(prefixes, []string{"photos"}) {
+		t.Fatalf("expected hidden directories excluded, got %v: %v", prefixes, err)
+	}
+
+	b, err := New(Config{BaseDir: dir, IncludeHidden: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	want := []string{".DS_Store", ".cache/thumb.png", "photos/.DS_Store", "photos/cat.jpg"}
+	if keys := listKeys(t, b.(*Backend), ""); !reflect.DeepEqual(keys, want) {
+		t.Fatalf("expected hidden files listed except internal dirs, got %v", keys)
+	}
+}
+
+func TestFSBackend_WalkWithMeta(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	params := simplecontent.UploadParams{ObjectKey: "docs/a.json", MimeType: "application/json"}
+	if err := backend.UploadWithParams(ctx, strings.NewReader(`{"a":1}`), params); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Upload(ctx, "docs/b.html", strings.NewReader("<html><body>hi</body></html>")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	got := map[string]simplecontent.ObjectMeta{}
+	err := backend.WalkWithMeta(ctx, "docs/", func(meta simplecontent.ObjectMeta) error {
+		got[meta.Key] = meta
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk with meta: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("expected 2 objects, got %v", got)
+	}
+	for key, meta := range got {
+		want, err := backend.GetObjectMeta(ctx, key)
+		if err != nil {
+			t.Fatalf("get object meta %s: %v", key, err)
+		}
+		if !reflect.DeepEqual(meta, *want) {
+			t.Fatalf("%s: walk reported %+v, GetObjectMeta %+v", key, meta, *want)
+		}
+	}
+	if got["docs/a.json"].ContentType != "application/json" || !strings.HasPrefix(got["docs/b.html"].ContentType, "text/html") {
+		t.Fatalf("unexpected content types: %+v", got)
+	}
+	if got["docs/a.json"].Metadata["sha256"] == "" {
+		t.Fatalf("expected the recorded checksum, got %v", got["docs/a.json"].Metadata)
+	}
+}
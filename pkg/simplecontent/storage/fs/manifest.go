@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// manifestEntry is one line of the StreamManifest output
+type manifestEntry struct {
+	Key         string    `json:"key"`
+	Size        int64     `json:"size"`
+	ContentType string    `json:"content_type"`
+	ModTime     time.Time `json:"mtime"`
+	SHA256      string    `json:"sha256,omitempty"`
+}
+
+// StreamManifest writes the metadata of every object whose key starts with
+// prefix to w as newline-delimited JSON, one object per line:
+//
+//	{"key":"docs/a.txt","size":12,"content_type":"text/plain; charset=utf-8","mtime":"...","sha256":"..."}
+//
+// Entries are written as the tree is walked, in directory order, so memory
+// use does not grow with the number of objects. Undeclared content types are
+// detected from the object's leading bytes and sha256 is omitted for objects
+// without a recorded checksum. A write error or cancelled context stops the
+// walk; lines already written are left in w.
+func (b *Backend) StreamManifest(ctx context.Context, prefix string, w io.Writer) (err error) {
+	defer wrapError(&err, "stream_manifest", prefix)
+
+	enc := json.NewEncoder(w)
+	return b.walkObjects(ctx, prefix, func(filePath string, meta simplecontent.ObjectMeta, sc *sidecar) error {
+		entry := manifestEntry{
+			Key:         meta.Key,
+			Size:        meta.Size,
+			ContentType: meta.ContentType,
+			ModTime:     meta.UpdatedAt.UTC(),
+			SHA256:      sc.SHA256,
+		}
+		if entry.ContentType == "" {
+			entry.ContentType = sniffContentType(filePath, sc.Codec)
+		}
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to write manifest: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_StreamManifest(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	if err := backend.UploadWithParams(ctx, strings.NewReader(`{"a":1}`), simplecontent.UploadParams{ObjectKey: "catalog/item.json", MimeType: "application/json"}); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Upload(ctx, "catalog/notes.txt", strings.NewReader("some notes")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Upload(ctx, "other/skip.txt", strings.NewReader("x")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := backend.StreamManifest(ctx, "catalog/", &buf); err != nil {
+		t.Fatalf("stream manifest: %v", err)
+	}
+
+	entries := map[string]manifestEntry{}
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var entry manifestEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("decode line %q: %v", scanner.Text(), err)
+		}
+		entries[entry.Key] = entry
+	}
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 manifest lines, got %v", entries)
+	}
+
+	item := entries["catalog/item.json"]
+	if item.Size != 7 || item.ContentType != "application/json" || item.ModTime.IsZero() || len(item.SHA256) != 64 {
+		t.Fatalf("unexpected entry %+v", item)
+	}
+	if notes := entries["catalog/notes.txt"]; !strings.HasPrefix(notes.ContentType, "text/plain") || notes.Size != 10 {
+		t.Fatalf("expected detected content type and original size, got %+v", notes)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := backend.StreamManifest(cancelled, "", &buf); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// Move re-keys the object stored at srcKey to dstKey, replacing any existing
+// object at dstKey, and carries its metadata over. The file is renamed, so
+// no bytes are rewritten; when the keys are on different filesystems (a
+// directory under BaseDir that is a mount point) it falls back to Copy
+// followed by Delete. A missing source returns
+// simplecontent.ErrObjectNotFound.
+func (b *Backend) Move(ctx context.Context, srcKey, dstKey string) (err error) {
+	defer wrapError(&err, "move", srcKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	srcPath, err := b.objectPath(srcKey)
+	if err != nil {
+		return err
+	}
+	dstPath, err := b.objectPath(dstKey)
+	if err != nil {
+		return err
+	}
+
+	unlock := b.keyLocks.lockPair(srcPath, dstPath)
+	defer func() {
+		if unlock != nil {
+			unlock()
+		}
+	}()
+
+	info, err := os.Stat(srcPath)
+	if os.IsNotExist(err) || err == nil && !info.Mode().IsRegular() {
+		return simplecontent.ErrObjectNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	sc, err := b.loadSidecar(srcPath)
+	if err != nil {
+		return err
+	}
+	if sc.reserved() {
+		return simplecontent.ErrObjectNotFound
+	}
+	if srcPath == dstPath {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if err := b.mkdirAll(filepath.Dir(dstPath)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	// The destination takes the source's metadata as is, replacing that of
+	// the object it overwrote, and its previews are stale
+	var renameErr error
+	err = b.placeObject(dstPath, srcPath, sc, true, func() error {
+		renameErr = os.Rename(srcPath, dstPath)
+		return renameErr
+	})
+	if isCrossDevice(renameErr) {
+		// Copy and Delete take the locks themselves
+		unlock()
+		unlock = nil
+		if err := b.Copy(ctx, srcKey, dstKey); err != nil {
+			return err
+		}
+		return b.Delete(ctx, srcKey)
+	} else if os.IsNotExist(renameErr) {
+		// Deleted since the check
+		return simplecontent.ErrObjectNotFound
+	} else if renameErr != nil {
+		return fmt.Errorf("failed to move file: %w", renameErr)
+	} else if err != nil {
+		return err
+	}
+	b.trackUnsynced(dstPath)
+	if err := b.removePreview(dstKey); err != nil {
+		return err
+	}
+	return b.removeCompanions(srcKey, srcPath)
+}
@@ -0,0 +1,54 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Move(t *testing.T) {
+	dir := t.TempDir()
+	backend := newCompressedBackend(t, dir, CodecGzip)
+	ctx := context.Background()
+
+	params := simplecontent.UploadParams{ObjectKey: "staging/upload.json", MimeType: "application/json", Metadata: map[string]string{"owner": "a"}}
+	if err := backend.UploadWithParams(ctx, strings.NewReader(`{"v":1}`), params); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	params = simplecontent.UploadParams{ObjectKey: "final/doc.json", MimeType: "text/plain", Metadata: map[string]string{"owner": "b"}}
+	if err := backend.UploadWithParams(ctx, strings.NewReader("old"), params); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if err := backend.Move(ctx, "staging/upload.json", "final/doc.json"); err != nil {
+		t.Fatalf("move: %v", err)
+	}
+	if got := readObject(t, backend, "final/doc.json"); got != `{"v":1}` {
+		t.Fatalf("unexpected content %q", got)
+	}
+	meta, err := backend.GetObjectMeta(ctx, "final/doc.json")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+	if meta.ContentType != "application/json" || meta.Metadata["owner"] != "a" || meta.Size != 7 {
+		t.Fatalf("expected the source's metadata, got %+v", meta)
+	}
+	if _, err := backend.Download(ctx, "staging/upload.json"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected source to be gone, got %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "staging")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty source directory to be removed, got %v", err)
+	}
+
+	if err := backend.Move(ctx, "staging/upload.json", "final/other.json"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound for a missing source, got %v", err)
+	}
+	if err := backend.Move(ctx, "final/doc.json", "final/doc.json"); err != nil {
+		t.Fatalf("move onto itself: %v", err)
+	}
+}
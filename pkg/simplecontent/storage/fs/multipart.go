@@ -0,0 +1,237 @@
+package fs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// multipartDir is the internal directory holding a directory of parts for
+// each multipart upload in progress, named by its upload ID
+const multipartDir = ".multipart"
+
+const (
+	multipartKeyFile = "key"   // Records the key of an upload
+	partPrefix       = "part-" // Followed by the part number
+)
+
+// InitiateMultipart starts an upload to objectKey assembled from parts
+// stored under .multipart until CompleteMultipart or AbortMultipart.
+// Uploads that are never completed or aborted stay there.
+func (b *Backend) InitiateMultipart(ctx context.Context, objectKey string) (_ string, err error) {
+	defer wrapError(&err, "initiate_multipart", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return "", err
+	}
+	if _, err := b.objectPath(objectKey); err != nil {
+		return "", err
+	}
+
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id[:])
+	dir := filepath.Join(b.baseDir, multipartDir, uploadID)
+	if err := b.mkdirAll(dir); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, multipartKeyFile), []byte(objectKey), 0644); err != nil {
+		os.RemoveAll(dir)
+		return "", fmt.Errorf("failed to record upload: %w", err)
+	}
+	return uploadID, nil
+}
+
+// UploadPart stores a part of a multipart upload. Parts are written
+// atomically, so a part replaced while the upload completes is assembled
+// either whole or as before. A part that would take the upload over
+// MaxObjectSize, or the largest MaxSizeByContentType limit, fails with
+// simplecontent.ErrObjectTooLarge and is not stored.
+func (b *Backend) UploadPart(ctx context.Context, uploadID string, partNumber int, r io.Reader) (err error) {
+	defer wrapError(&err, "upload_part", uploadID)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	if partNumber < 1 {
+		return fmt.Errorf("%w: part number %d is below 1", simplecontent.ErrInvalidPart, partNumber)
+	}
+	dir, err := b.multipartDir(uploadID)
+	if err != nil {
+		return err
+	}
+
+	// Hold off completion and abort while the part is written
+	defer b.keyLocks.rlock(dir)()
+	objectKey, err := b.multipartKey(dir)
+	if err != nil {
+		return err
+	}
+	if _, err := b.prepareWrite(objectKey, b.onCollision); err != nil {
+		return err
+	}
+	if r, err = b.limitPart(r, dir, partNumber); err != nil {
+		return err
+	}
+	return writeReplace(ctx, filepath.Join(dir, partPrefix+strconv.Itoa(partNumber)), r, 0)
+}
+
+// CompleteMultipart uploads the concatenated parts of an upload to its key
+// like Upload, then discards them. A failed upload keeps the parts, so
+// completion can be retried.
+func (b *Backend) CompleteMultipart(ctx context.Context, uploadID string) (err error) {
+	defer wrapError(&err, "complete_multipart", uploadID)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	dir, err := b.multipartDir(uploadID)
+	if err != nil {
+		return err
+	}
+
+	defer b.keyLocks.lock(dir)()
+	objectKey, err := b.multipartKey(dir)
+	if err != nil {
+		return err
+	}
+	parts, err := multipartParts(dir)
+	if err != nil {
+		return err
+	}
+
+	reader := &partsReader{paths: parts}
+	defer reader.Close()
+	if _, err := b.upload(ctx, reader, simplecontent.UploadParams{ObjectKey: objectKey}); err != nil {
+		return err
+	}
+	// The object is committed; parts left behind are only wasted space
+	os.RemoveAll(dir)
+	return nil
+}
+
+// AbortMultipart discards a multipart upload and its parts
+func (b *Backend) AbortMultipart(ctx context.Context, uploadID string) (err error) {
+	defer wrapError(&err, "abort_multipart", uploadID)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+	dir, err := b.multipartDir(uploadID)
+	if err != nil {
+		return err
+	}
+
+	defer b.keyLocks.lock(dir)()
+	if _, err := b.multipartKey(dir); err != nil {
+		return err
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to remove upload: %w", err)
+	}
+	return nil
+}
+
+// multipartDir returns the directory of an upload. IDs that InitiateMultipart
+// cannot have returned are rejected, so they never name a path elsewhere.
+func (b *Backend) multipartDir(uploadID string) (string, error) {
+	if id, err := hex.DecodeString(uploadID); err != nil || len(id) != 16 {
+		return "", simplecontent.ErrUploadNotFound
+	}
+	return filepath.Join(b.baseDir, multipartDir, uploadID), nil
+}
+
+// multipartKey returns the key an upload was initiated for
+func (b *Backend) multipartKey(dir string) (string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, multipartKeyFile))
+	if os.IsNotExist(err) {
+		return "", simplecontent.ErrUploadNotFound
+	} else if err != nil {
+		return "", fmt.Errorf("failed to read upload: %w", err)
+	}
+	return string(data), nil
+}
+
+// multipartParts returns the paths of the parts in dir in order, failing
+// with ErrInvalidPart unless they are numbered 1 to N
+func multipartParts(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	var numbers []int
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), partPrefix)
+		if !ok || isTempName(entry.Name()) {
+			continue
+		}
+		if n, err := strconv.Atoi(name); err == nil {
+			numbers = append(numbers, n)
+		}
+	}
+	if len(numbers) == 0 {
+		return nil, fmt.Errorf("%w: no parts were uploaded", simplecontent.ErrInvalidPart)
+	}
+	slices.Sort(numbers)
+
+	paths := make([]string, len(numbers))
+	for i, n := range numbers {
+		if n != i+1 {
+			return nil, fmt.Errorf("%w: part %d is missing", simplecontent.ErrInvalidPart, i+1)
+		}
+		paths[i] = filepath.Join(dir, partPrefix+strconv.Itoa(n))
+	}
+	return paths, nil
+}
+
+// partsReader reads files one after another, holding only the current one
+// open
+type partsReader struct {
+	paths []string
+	file  *os.File
+}
+
+func (r *partsReader) Read(p []byte) (int, error) {
+	for {
+		if r.file == nil {
+			if len(r.paths) == 0 {
+				return 0, io.EOF
+			}
+			file, err := os.Open(r.paths[0])
+			if err != nil {
+				return 0, err
+			}
+			r.file, r.paths = file, r.paths[1:]
+		}
+		n, err := r.file.Read(p)
+		if err == io.EOF {
+			r.file.Close()
+			r.file = nil
+			if n == 0 {
+				continue
+			}
+			err = nil
+		}
+		return n, err
+	}
+}
+
+func (r *partsReader) Close() error {
+	if r.file == nil {
+		return nil
+	}
+	err := r.file.Close()
+	r.file = nil
+	return err
+}
@@ -0,0 +1,96 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Multipart(t *testing.T) {
+	dir := t.TempDir()
+	b := newCompressedBackend(t, dir, "")
+	ctx := context.Background()
+
+	uploadID, err := b.InitiateMultipart(ctx, "videos/big.bin")
+	if err != nil {
+		t.Fatalf("initiate: %v", err)
+	}
+	// Parts may arrive in any order and be replaced
+	parts := map[int]string{3: "three", 1: "one-", 2: "tw"}
+	for n, content := range parts {
+		if err := b.UploadPart(ctx, uploadID, n, strings.NewReader(content)); err != nil {
+			t.Fatalf("upload part %d: %v", n, err)
+		}
+	}
+	if err := b.UploadPart(ctx, uploadID, 2, strings.NewReader("two-")); err != nil {
+		t.Fatalf("replace part: %v", err)
+	}
+	if exists, _ := b.Exists(ctx, "videos/big.bin"); exists {
+		t.Fatal("expected no object before completion")
+	}
+	if keys := listKeys(t, b, ""); len(keys) != 0 {
+		t.Fatalf("expected parts to stay out of listings, got %v", keys)
+	}
+
+	if err := b.CompleteMultipart(ctx, uploadID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if got := readObject(t, b, "videos/big.bin"); got != "one-two-three" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(dir, multipartDir, uploadID)); !os.IsNotExist(err) {
+		t.Fatalf("expected parts to be removed, got %v", err)
+	}
+	if err := b.CompleteMultipart(ctx, uploadID); !errors.Is(err, simplecontent.ErrUploadNotFound) {
+		t.Fatalf("expected ErrUploadNotFound completing twice, got %v", err)
+	}
+}
+
+func TestFSBackend_MultipartInvalid(t *testing.T) {
+	b := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	uploadID, err := b.InitiateMultipart(ctx, "a.bin")
+	if err != nil {
+		t.Fatalf("initiate: %v", err)
+	}
+	if err := b.CompleteMultipart(ctx, uploadID); !errors.Is(err, simplecontent.ErrInvalidPart) {
+		t.Fatalf("expected ErrInvalidPart without parts, got %v", err)
+	}
+	if err := b.UploadPart(ctx, uploadID, 0, strings.NewReader("x")); !errors.Is(err, simplecontent.ErrInvalidPart) {
+		t.Fatalf("expected ErrInvalidPart for part 0, got %v", err)
+	}
+	for _, n := range []int{1, 3} {
+		if err := b.UploadPart(ctx, uploadID, n, strings.NewReader("x")); err != nil {
+			t.Fatalf("upload part %d: %v", n, err)
+		}
+	}
+	if err := b.CompleteMultipart(ctx, uploadID); !errors.Is(err, simplecontent.ErrInvalidPart) || !strings.Contains(err.Error(), "part 2") {
+		t.Fatalf("expected ErrInvalidPart naming part 2, got %v", err)
+	}
+
+	// A failed completion keeps the upload, which can still be aborted
+	if err := b.AbortMultipart(ctx, uploadID); err != nil {
+		t.Fatalf("abort: %v", err)
+	}
+	if err := b.UploadPart(ctx, uploadID, 2, strings.NewReader("x")); !errors.Is(err, simplecontent.ErrUploadNotFound) {
+		t.Fatalf("expected ErrUploadNotFound after abort, got %v", err)
+	}
+	if exists, _ := b.Exists(ctx, "a.bin"); exists {
+		t.Fatal("expected no object after abort")
+	}
+
+	for _, id := range []string{"", "../../etc", strings.Repeat("0", 32)} {
+		if err := b.AbortMultipart(ctx, id); !errors.Is(err, simplecontent.ErrUploadNotFound) {
+			t.Fatalf("expected ErrUploadNotFound for %q, got %v", id, err)
+		}
+	}
+	if _, err := b.InitiateMultipart(ctx, "../escape"); !errors.Is(err, simplecontent.ErrInvalidKey) {
+		t.Fatalf("expected ErrInvalidKey, got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package fs
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Observer receives the size and latency of uploads, downloads and deletes,
+// for exporting metrics such as Prometheus counters and histograms. Its
+// methods run synchronously on the calling goroutine, so they should return
+// quickly, and may be called concurrently.
+type Observer interface {
+	// ObserveUpload reports an upload once it has been committed or has
+	// failed, with the size of the content committed (0 on failure)
+	ObserveUpload(key string, bytes int64, dur time.Duration, err error)
+
+	// ObserveDownload reports a download when its reader is closed, with
+	// the bytes read and the time since Download was called. Downloads
+	// failing to open are reported immediately, with no bytes.
+	ObserveDownload(key string, bytes int64, dur time.Duration, err error)
+
+	// ObserveDelete reports a delete
+	ObserveDelete(key string, dur time.Duration, err error)
+}
+
+// observeUpload reports an upload of written bytes started at start and
+// failing with *err, if at all, to the Observer
+func (b *Backend) observeUpload(key string, start time.Time, written *int64, err *error) {
+	if b.observer != nil {
+		b.observer.ObserveUpload(key, *written, time.Since(start), *err)
+	}
+}
+
+// observeDelete reports a delete started at start to the Observer
+func (b *Backend) observeDelete(key string, start time.Time, err *error) {
+	if b.observer != nil {
+		b.observer.ObserveDelete(key, time.Since(start), *err)
+	}
+}
+
+// observeDownload reports a download started at start to the Observer. A
+// failed download is reported right away; otherwise rc is wrapped to count
+// the bytes read and report them on Close. Objects opened as files keep
+// their io.Seeker and io.WriterTo fast paths.
+func (b *Backend) observeDownload(key string, start time.Time, rc *io.ReadCloser, err *error) {
+	if b.observer == nil {
+		return
+	}
+	if *err != nil {
+		b.observer.ObserveDownload(key, 0, time.Since(start), *err)
+		return
+	}
+	counter := &downloadCounter{observer: b.observer, key: key, start: start}
+	if file, ok := (*rc).(*objectFile); ok {
+		*rc = &observedFile{objectFile: file, counter: counter}
+	} else {
+		*rc = &observedReader{ReadCloser: *rc, counter: counter}
+	}
+}
+
+// downloadCounter tallies the bytes read from a download and reports them
+// once, on the first Close
+type downloadCounter struct {
+	observer Observer
+	key      string
+	start    time.Time
+	mu       sync.Mutex
+	bytes    int64
+	err      error // First read error other than io.EOF
+	once     sync.Once
+}
+
+func (c *downloadCounter) add(n int64, err error) {
+	c.mu.Lock()
+	c.bytes += n
+	if err != nil && err != io.EOF && c.err == nil {
+		c.err = err
+	}
+	c.mu.Unlock()
+}
+
+func (c *downloadCounter) report() {
+	c.once.Do(func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.observer.ObserveDownload(c.key, c.bytes, time.Since(c.start), c.err)
+	})
+}
+
+// observedReader counts the bytes read from a decoded download
+type observedReader struct {
+	io.ReadCloser
+	counter *downloadCounter
+}
+
+func (r *observedReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.counter.add(int64(n), err)
+	return n, err
+}
+
+func (r *observedReader) Close() error {
+	err := r.ReadCloser.Close()
+	r.counter.report()
+	return err
+}
+
+// observedFile counts the bytes read from a download served straight from
+// its file
+type observedFile struct {
+	*objectFile
+	counter *downloadCounter
+}
+
+func (f *observedFile) Read(p []byte) (int, error) {
+	n, err := f.objectFile.Read(p)
+	f.counter.add(int64(n), err)
+	return n, err
+}
+
+func (f *observedFile) ReadAt(p []byte, off int64) (int, error) {
+	n, err := f.objectFile.ReadAt(p, off)
+	f.counter.add(int64(n), err)
+	return n, err
+}
+
+func (f *observedFile) WriteTo(w io.Writer) (int64, error) {
+	n, err := f.objectFile.WriteTo(w)
+	f.counter.add(n, err)
+	return n, err
+}
+
+func (f *observedFile) Close() error {
+	err := f.objectFile.Close()
+	f.counter.report()
+	return err
+}
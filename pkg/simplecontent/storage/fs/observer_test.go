@@ -0,0 +1,108 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+type observation struct {
+	op    string
+	key   string
+	bytes int64
+	err   error
+}
+
+type recordingObserver struct {
+	mu   sync.Mutex
+	seen []observation
+}
+
+func (o *recordingObserver) record(op, key string, bytes int64, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.seen = append(o.seen, observation{op: op, key: key, bytes: bytes, err: err})
+}
+
+func (o *recordingObserver) ObserveUpload(key string, bytes int64, _ time.Duration, err error) {
+	o.record("upload", key, bytes, err)
+}
+
+func (o *recordingObserver) ObserveDownload(key string, bytes int64, _ time.Duration, err error) {
+	o.record("download", key, bytes, err)
+}
+
+func (o *recordingObserver) ObserveDelete(key string, _ time.Duration, err error) {
+	o.record("delete", key, 0, err)
+}
+
+func (o *recordingObserver) last(t *testing.T, n int) observation {
+	t.Helper()
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if len(o.seen) != n {
+		t.Fatalf("expected %d observations, got %+v", n, o.seen)
+	}
+	return o.seen[n-1]
+}
+
+func TestFSBackend_Observer(t *testing.T) {
+	for _, codec := range []string{"", CodecGzip} {
+		t.Run("codec="+codec, func(t *testing.T) {
+			observer := &recordingObserver{}
+			store, err := New(Config{BaseDir: t.TempDir(), Compression: codec, Observer: observer})
+			if err != nil {
+				t.Fatalf("new fs backend: %v", err)
+			}
+			ctx := context.Background()
+			content := strings.Repeat("observe ", 100)
+
+			if err := store.Upload(ctx, "a.txt", strings.NewReader(content)); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+			if got := observer.last(t, 1); got.op != "upload" || got.key != "a.txt" || got.bytes != int64(len(content)) || got.err != nil {
+				t.Fatalf("unexpected upload observation %+v", got)
+			}
+
+			// Reported on Close, once, with the bytes read
+			rc, err := store.Download(ctx, "a.txt")
+			if err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			if _, seekable := rc.(io.Seeker); seekable != (codec == "") {
+				t.Fatalf("expected uncompressed downloads to stay seekable, got %T", rc)
+			}
+			var buf bytes.Buffer
+			if _, err := io.Copy(&buf, rc); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+			observer.last(t, 1)
+			rc.Close()
+			rc.Close()
+			if got := observer.last(t, 2); got.op != "download" || got.bytes != int64(len(content)) || got.err != nil {
+				t.Fatalf("unexpected download observation %+v", got)
+			}
+
+			if _, err := store.Download(ctx, "missing.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+				t.Fatalf("expected ErrObjectNotFound, got %v", err)
+			}
+			if got := observer.last(t, 3); got.op != "download" || got.bytes != 0 || !errors.Is(got.err, simplecontent.ErrObjectNotFound) {
+				t.Fatalf("unexpected failed download observation %+v", got)
+			}
+
+			if err := store.Delete(ctx, "a.txt"); err != nil {
+				t.Fatalf("delete: %v", err)
+			}
+			if got := observer.last(t, 4); got.op != "delete" || got.key != "a.txt" || got.err != nil {
+				t.Fatalf("unexpected delete observation %+v", got)
+			}
+		})
+	}
+}
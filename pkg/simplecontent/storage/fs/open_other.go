@@ -0,0 +1,16 @@
+//go:build !windows
+
+package fs
+
+import "os"
+
+// openObject opens an object file for reading
+func openObject(path string) (*os.File, error) {
+	return os.Open(path)
+}
+
+// isBusy reports whether a rename failed because the target is held open.
+// Open files never block a rename outside Windows.
+func isBusy(err error) bool {
+	return false
+}
@@ -0,0 +1,37 @@
+//go:build windows
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// errorSharingViolation is ERROR_SHARING_VIOLATION, which syscall does not
+// define
+const errorSharingViolation syscall.Errno = 32
+
+// openObject opens an object file for reading with FILE_SHARE_DELETE, so
+// replacing or deleting the object while it is being read succeeds as it
+// does on Unix, with the reader keeping the old content
+func openObject(path string) (*os.File, error) {
+	name, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	h, err := syscall.CreateFile(name, syscall.GENERIC_READ,
+		syscall.FILE_SHARE_READ|syscall.FILE_SHARE_WRITE|syscall.FILE_SHARE_DELETE,
+		nil, syscall.OPEN_EXISTING, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err != nil {
+		return nil, &os.PathError{Op: "open", Path: path, Err: err}
+	}
+	return os.NewFile(uintptr(h), path), nil
+}
+
+// isBusy reports whether a rename failed because another process holds the
+// target open without FILE_SHARE_DELETE
+func isBusy(err error) bool {
+	var errno syscall.Errno
+	return errors.As(err, &errno) && (errno == syscall.ERROR_ACCESS_DENIED || errno == errorSharingViolation)
+}
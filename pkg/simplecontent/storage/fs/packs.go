@@ -0,0 +1,412 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// packDir is the directory under baseDir holding the pack files Compact
+// writes, each with an index of the objects it contains
+const packDir = ".packs"
+
+const (
+	packExt      = ".pack"
+	packIndexExt = ".idx"
+)
+
+// packEntry is one line of a pack index: where the stored bytes of the
+// object at Path (relative to baseDir) are in the pack, and the file info
+// and sidecar the object had when it was packed
+type packEntry struct {
+	Path    string    `json:"path"`
+	Offset  int64     `json:"offset"`
+	Length  int64     `json:"length"`
+	ModTime time.Time `json:"mtime"`
+	sidecar
+
+	pack string // Path of the pack file
+}
+
+// packSet caches the entries of every pack index, reloading them when the
+// pack directory changes
+type packSet struct {
+	mu        sync.Mutex
+	compactMu sync.Mutex           // Serializes Compact
+	entries   map[string]packEntry // Packed objects by path relative to baseDir
+	modTime   time.Time            // Pack directory mtime when entries were loaded
+}
+
+// Compact moves the objects under prefix last written more than PackAge ago
+// into a new pack file, an append-only bundle of their stored bytes with an
+// index, and removes the original files and sidecars. Download,
+// GetObjectMeta and Exists resolve packed objects transparently, so an
+// archive of many small objects needs a few files instead of an inode or two
+// per object.
+//
+// Compaction requires objects to be immutable once written: packed objects
+// are not listed or walked, cannot be deleted, and a key uploaded again
+// after it was packed shadows the packed copy only until it is deleted.
+// Objects TransparentDecompress decodes are left in place.
+func (b *Backend) Compact(ctx context.Context, prefix string) (err error) {
+	defer wrapError(&err, "compact", prefix)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	b.packs.compactMu.Lock()
+	defer b.packs.compactMu.Unlock()
+
+	dir := filepath.Join(b.baseDir, packDir)
+	if err := b.mkdirAll(dir); err != nil {
+		return fmt.Errorf("failed to create pack directory: %w", err)
+	}
+	name := filepath.Join(dir, fmt.Sprintf("pack-%016x", time.Now().UnixNano()))
+
+	pack, err := createTemp(name + packExt)
+	if err != nil {
+		return fmt.Errorf("failed to create pack: %w", err)
+	}
+	tmp := pack.Name()
+	defer func() {
+		if pack != nil {
+			pack.Close()
+			os.Remove(tmp)
+		}
+	}()
+
+	// Append every aged object to the pack, remembering the file each came
+	// from so it is only removed if it was not replaced meanwhile
+	cutoff := time.Now().Add(-b.packAge)
+	var entries []packEntry
+	var infos []os.FileInfo
+	var offset int64
+	err = b.walkObjects(ctx, prefix, func(filePath string, meta simplecontent.ObjectMeta, sc *sidecar) error {
+		if meta.UpdatedAt.After(cutoff) || b.extensionCodec(meta.Key) != "" {
+			return nil
+		}
+		entry, info, err := appendPacked(ctx, pack, offset, filePath, sc)
+		if os.IsNotExist(err) {
+			// Deleted while compacting
+			return nil
+		} else if err != nil {
+			return err
+		}
+		if entry.Path, err = filepath.Rel(b.baseDir, filePath); err != nil {
+			return err
+		}
+		entry.Path = filepath.ToSlash(entry.Path)
+		offset += entry.Length
+		entries = append(entries, entry)
+		infos = append(infos, info)
+		return nil
+	})
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	// The pack must be durable before its index makes it visible, and the
+	// index before the originals are removed
+	if err := pack.Sync(); err != nil {
+		return fmt.Errorf("failed to sync pack: %w", err)
+	}
+	if err := pack.Close(); err != nil {
+		return fmt.Errorf("failed to write pack: %w", err)
+	}
+	pack = nil
+	if err := chmodTemp(tmp, b.fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name+packExt); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to rename pack: %w", err)
+	}
+	if err := b.writePackIndex(name+packIndexExt, entries); err != nil {
+		os.Remove(name + packExt)
+		return err
+	}
+	b.packs.invalidate()
+
+	for i, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			// Objects not removed yet keep shadowing their packed copies
+			return err
+		}
+		if err := b.removePacked(filepath.Join(b.baseDir, filepath.FromSlash(entry.Path)), infos[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// appendPacked copies the stored bytes of the object at filePath to the end
+// of pack, which is offset bytes long, and returns its entry without Path
+func appendPacked(ctx context.Context, pack *os.File, offset int64, filePath string, sc *sidecar) (packEntry, os.FileInfo, error) {
+	file, err := openObject(filePath)
+	if err != nil {
+		return packEntry{}, nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return packEntry{}, nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	n, err := io.Copy(pack, contextReader{ctx: ctx, r: file})
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return packEntry{}, nil, ctxErr
+		}
+		return packEntry{}, nil, fmt.Errorf("failed to write pack: %w", err)
+	}
+
+	// Record the detected content type, as the packed bytes are not read
+	// to describe the object
+	entry := packEntry{Offset: offset, Length: n, ModTime: info.ModTime(), sidecar: *sc}
+	if entry.ContentType == "" {
+		entry.ContentType = sniffContentType(filePath, sc.Codec)
+	}
+	return entry, info, nil
+}
+
+// writePackIndex durably writes the index of a pack
+func (b *Backend) writePackIndex(path string, entries []packEntry) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, entry := range entries {
+		if err := enc.Encode(entry); err != nil {
+			return fmt.Errorf("failed to encode pack index entry: %w", err)
+		}
+	}
+
+	out, err := createTemp(path)
+	if err != nil {
+		return fmt.Errorf("failed to create pack index: %w", err)
+	}
+	tmp := out.Name()
+	if _, err := out.Write(buf.Bytes()); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write pack index: %w", err)
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to sync pack index: %w", err)
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write pack index: %w", err)
+	}
+	if err := chmodTemp(tmp, b.fileMode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to rename pack index: %w", err)
+	}
+	return nil
+}
+
+// removePacked removes the original of a packed object, described by info
+// when it was packed. A file replaced since is put back and shadows the
+// packed copy.
+func (b *Backend) removePacked(filePath string, info os.FileInfo) error {
+	aside := tempName(filePath)
+	if err := os.Rename(filePath, aside); os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to remove packed file: %w", err)
+	}
+	if moved, err := os.Stat(aside); err != nil || !os.SameFile(info, moved) || fileETag(moved) != fileETag(info) {
+		if err := os.Link(aside, filePath); err != nil && !os.IsExist(err) {
+			return fmt.Errorf("failed to restore file: %w", err)
+		}
+		os.Remove(aside)
+		return nil
+	}
+
+	if err := os.Remove(aside); err != nil {
+		return fmt.Errorf("failed to remove packed file: %w", err)
+	}
+	if err := b.removeSidecar(filePath); err != nil {
+		return err
+	}
+	b.cleanupEmptyDirectories(filepath.Dir(filePath))
+	return nil
+}
+
+// packed returns the pack entry of the object at filePath, or nil when it is
+// not packed
+func (b *Backend) packed(filePath string) (*packEntry, error) {
+	rel, err := filepath.Rel(b.baseDir, filePath)
+	if err != nil {
+		return nil, nil
+	}
+	return b.packs.lookup(filepath.Join(b.baseDir, packDir), filepath.ToSlash(rel))
+}
+
+// downloadPacked opens the packed object at filePath as objectKey, returning
+// nil when it is not packed
+func (b *Backend) downloadPacked(ctx context.Context, objectKey, filePath string) (*openedObject, error) {
+	entry, err := b.packed(filePath)
+	if entry == nil || err != nil {
+		return nil, err
+	}
+	file, err := os.Open(entry.pack)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open pack: %w", err)
+	}
+	section := &packedObject{
+		SectionReader: io.NewSectionReader(file, entry.Offset, entry.Length),
+		file:          file,
+		ctx:           ctx,
+	}
+	rc, err := openDecoded(section, entry.Codec)
+	if err != nil {
+		return nil, err
+	}
+	return &openedObject{rc: rc, key: objectKey, size: contentSize(packedInfo{entry}, &entry.sidecar), sc: &entry.sidecar}, nil
+}
+
+// packedMeta describes the packed object at filePath, reporting false when
+// it is not packed. Unless describe is set, the metadata is only what
+// fileMeta reports.
+func (b *Backend) packedMeta(objectKey, filePath string, describe bool) (*simplecontent.ObjectMeta, bool, error) {
+	entry, err := b.packed(filePath)
+	if entry == nil || err != nil {
+		return nil, false, err
+	}
+	meta := b.fileMeta(objectKey, packedInfo{entry}, &entry.sidecar)
+	if !describe {
+		return &meta, true, nil
+	}
+	if err := b.describeObject(&meta, filePath, &entry.sidecar); err != nil {
+		return nil, true, err
+	}
+	return &meta, true, nil
+}
+
+// lookup finds the entry for path in the indexes under dir
+func (p *packSet) lookup(dir, path string) (*packEntry, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, err := os.Stat(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read pack directory: %w", err)
+	}
+	if p.entries == nil || !info.ModTime().Equal(p.modTime) {
+		if err := p.load(dir); err != nil {
+			return nil, err
+		}
+		p.modTime = info.ModTime()
+	}
+
+	entry, ok := p.entries[path]
+	if !ok {
+		return nil, nil
+	}
+	return &entry, nil
+}
+
+// load reads every pack index under dir. Indexes are read in name order, so
+// an object packed again is found in its newest pack. p.mu must be held.
+func (p *packSet) load(dir string) error {
+	names, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to read pack directory: %w", err)
+	}
+
+	entries := make(map[string]packEntry)
+	for _, name := range names {
+		if !strings.HasSuffix(name.Name(), packIndexExt) || isTempName(name.Name()) {
+			continue
+		}
+		index := filepath.Join(dir, name.Name())
+		pack := strings.TrimSuffix(index, packIndexExt) + packExt
+		if err := readPackIndex(index, pack, entries); err != nil {
+			return err
+		}
+	}
+	p.entries = entries
+	return nil
+}
+
+// readPackIndex adds the entries of the index of pack to entries
+func readPackIndex(index, pack string, entries map[string]packEntry) error {
+	file, err := os.Open(index)
+	if err != nil {
+		return fmt.Errorf("failed to read pack index: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var entry packEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return fmt.Errorf("failed to decode pack index: %w", err)
+		}
+		entry.pack = pack
+		entries[entry.Path] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read pack index: %w", err)
+	}
+	return nil
+}
+
+// invalidate makes the next lookup reload the indexes
+func (p *packSet) invalidate() {
+	p.mu.Lock()
+	p.entries = nil
+	p.mu.Unlock()
+}
+
+// packedObject reads one object out of a pack file. Close may be called more
+// than once, and reads stop with the context's error once ctx is done.
+type packedObject struct {
+	*io.SectionReader
+	file *os.File
+	ctx  context.Context
+	once sync.Once
+	err  error
+}
+
+func (o *packedObject) Read(p []byte) (int, error) {
+	if err := o.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return o.SectionReader.Read(p)
+}
+
+func (o *packedObject) Close() error {
+	o.once.Do(func() { o.err = o.file.Close() })
+	return o.err
+}
+
+// packedInfo is the file info a packed object had when it was packed
+type packedInfo struct {
+	entry *packEntry
+}
+
+func (i packedInfo) Name() string       { return filepath.Base(i.entry.Path) }
+func (i packedInfo) Size() int64        { return i.entry.Length }
+func (i packedInfo) Mode() os.FileMode  { return 0444 }
+func (i packedInfo) ModTime() time.Time { return i.entry.ModTime }
+func (i packedInfo) IsDir() bool        { return false }
+func (i packedInfo) Sys() any           { return nil }
@@ -0,0 +1,132 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Compact(t *testing.T) {
+	dir := t.TempDir()
+	b, err := New(Config{BaseDir: dir, PackAge: time.Hour})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	objects := map[string]string{
+		"archive/2024/a.txt": "first object",
+		"archive/2024/b.txt": "second object",
+		"archive/c.json":     `{"c":true}`,
+	}
+	for key, content := range objects {
+		params := simplecontent.UploadParams{ObjectKey: key, Metadata: map[string]string{"origin": key}}
+		if err := backend.UploadWithParams(ctx, strings.NewReader(content), params); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+		old := time.Now().Add(-2 * time.Hour)
+		if err := os.Chtimes(mustObjectPath(t, backend, key), old, old); err != nil {
+			t.Fatalf("age %s: %v", key, err)
+		}
+	}
+	if err := backend.Upload(ctx, "archive/new.txt", strings.NewReader("too new")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	before, err := backend.GetObjectMeta(ctx, "archive/2024/a.txt")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+
+	if err := backend.Compact(ctx, "archive/"); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+
+	// The aged objects moved into a single pack, the new one stayed loose
+	if _, err := os.Stat(filepath.Join(dir, "archive", "2024")); !os.IsNotExist(err) {
+		t.Fatalf("expected packed directory to be removed, got %v", err)
+	}
+	packs, _ := filepath.Glob(filepath.Join(dir, packDir, "*"+packExt))
+	if len(packs) != 1 {
+		t.Fatalf("expected one pack, got %v", packs)
+	}
+	if got := listKeys(t, backend, "archive/"); !reflect.DeepEqual(got, []string{"archive/new.txt"}) {
+		t.Fatalf("expected only the loose object listed, got %v", got)
+	}
+
+	// Packed objects still read and describe as before
+	for key, content := range objects {
+		if got := readObject(t, backend, key); got != content {
+			t.Fatalf("unexpected content of %s: %q", key, got)
+		}
+		if ok, err := backend.Exists(ctx, key); err != nil || !ok {
+			t.Fatalf("expected %s to exist: %v, %v", key, ok, err)
+		}
+	}
+	meta, err := backend.GetObjectMeta(ctx, "archive/2024/a.txt")
+	if err != nil {
+		t.Fatalf("get packed meta: %v", err)
+	}
+	if meta.ETag != before.ETag || meta.Size != before.Size || meta.ContentType != before.ContentType {
+		t.Fatalf("packed metadata changed: %+v, was %+v", meta, before)
+	}
+	if meta.Metadata["origin"] != "archive/2024/a.txt" {
+		t.Fatalf("expected user metadata to be kept, got %v", meta.Metadata)
+	}
+
+	// Packed objects are seekable
+	rc, err := backend.Download(ctx, "archive/2024/b.txt")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer rc.Close()
+	if _, err := rc.(io.Seeker).Seek(7, io.SeekStart); err != nil {
+		t.Fatalf("seek: %v", err)
+	}
+	if rest, _ := io.ReadAll(rc); string(rest) != "object" {
+		t.Fatalf("unexpected content after seek: %q", rest)
+	}
+
+	// A new backend reads the pack indexes
+	reader, err := New(Config{BaseDir: dir})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	if got := readObject(t, reader.(*Backend), "archive/c.json"); got != objects["archive/c.json"] {
+		t.Fatalf("unexpected content from reader: %q", got)
+	}
+	if _, err := reader.Download(ctx, "archive/missing"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func TestFSBackend_CompactCompressed(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	content := strings.Repeat("compressible ", 100)
+	if err := backend.Upload(ctx, "logs/app.log", strings.NewReader(content)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Compact(ctx, ""); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if _, err := os.Stat(mustObjectPath(t, backend, "logs/app.log")); !os.IsNotExist(err) {
+		t.Fatalf("expected object to be packed, got %v", err)
+	}
+	if got := readObject(t, backend, "logs/app.log"); got != content {
+		t.Fatalf("unexpected content of packed compressed object")
+	}
+	meta, err := backend.GetObjectMeta(ctx, "logs/app.log")
+	if err != nil || meta.Size != int64(len(content)) {
+		t.Fatalf("expected decoded size, got %+v, %v", meta, err)
+	}
+}
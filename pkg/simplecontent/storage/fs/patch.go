@@ -0,0 +1,119 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// PatchRange overwrites len(data) bytes of an existing object starting at
+// offset, without rewriting the rest of the file. Like Download, it follows
+// an alias to the object it refers to.
+//
+// Writes that would extend past the current end of the object fail with
+// simplecontent.ErrRangeNotSatisfiable unless AllowPatchGrow is configured.
+//
+// PatchRange deliberately breaks the replace-by-rename model used by Upload:
+// the file is modified in place, so concurrent readers may observe a
+// partially patched object and hardlinked copies (see Copy) change as well.
+// Any validator derived from the previous content no longer applies after a
+// patch, and the stored checksum is dropped. Compressed and packed objects
+// cannot be patched and return an error wrapping errors.ErrUnsupported.
+func (b *Backend) PatchRange(ctx context.Context, objectKey string, offset int64, data []byte) (err error) {
+	defer wrapError(&err, "patch_range", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return err
+	}
+	if offset < 0 {
+		return simplecontent.ErrRangeNotSatisfiable
+	}
+
+	// Patch the file, or else refuse a packed object and follow an alias
+	err = b.patchFile(ctx, filePath, offset, data)
+	if os.IsNotExist(err) {
+		if err := b.checkNotPacked(filePath); err != nil {
+			return err
+		}
+		if _, filePath, err = b.resolveAlias(objectKey); err != nil {
+			return err
+		}
+		err = b.patchFile(ctx, filePath, offset, data)
+	}
+	if os.IsNotExist(err) {
+		if err := b.checkNotPacked(filePath); err != nil {
+			return err
+		}
+		return simplecontent.ErrObjectNotFound
+	}
+	return err
+}
+
+// patchFile patches the object file at filePath under its write lock, so
+// the object is not replaced between reading its sidecar and updating it. A
+// missing object returns an error satisfying os.IsNotExist.
+func (b *Backend) patchFile(ctx context.Context, filePath string, offset int64, data []byte) error {
+	defer b.keyLocks.lock(filePath)()
+
+	file, err := os.OpenFile(filePath, os.O_WRONLY, 0)
+	if os.IsNotExist(err) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	sc, err := b.loadSidecar(filePath)
+	if err != nil {
+		return err
+	} else if sc.reserved() {
+		return simplecontent.ErrObjectNotFound
+	} else if sc.Codec != "" {
+		return fmt.Errorf("cannot patch %s-compressed object: %w", sc.Codec, errors.ErrUnsupported)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	if !b.allowPatchGrow && offset+int64(len(data)) > info.Size() {
+		return simplecontent.ErrRangeNotSatisfiable
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := file.WriteAt(data, offset); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write file: %w", err)
+	}
+
+	// The recorded checksum no longer describes the content
+	if sc.SHA256 == "" {
+		return nil
+	}
+	sc.SHA256 = ""
+	return b.storeSidecar(filePath, sc)
+}
+
+// checkNotPacked fails for an object stored in a pack, which is shared with
+// other objects and cannot be patched in place
+func (b *Backend) checkNotPacked(filePath string) error {
+	entry, err := b.packed(filePath)
+	if err != nil {
+		return err
+	} else if entry != nil {
+		return fmt.Errorf("cannot patch packed object: %w", errors.ErrUnsupported)
+	}
+	return nil
+}
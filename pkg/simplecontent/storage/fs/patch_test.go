@@ -0,0 +1,104 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_PatchRange(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "cfg", bytes.NewReader([]byte("hello world"))); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.PatchRange(ctx, "cfg", 6, []byte("there")); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+
+	rc, err := backend.Download(ctx, "cfg")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	got, _ := io.ReadAll(rc)
+	_ = rc.Close()
+	if string(got) != "hello there" {
+		t.Fatalf("unexpected content %q", string(got))
+	}
+
+	if err := backend.PatchRange(ctx, "cfg", 8, []byte("growing")); !errors.Is(err, simplecontent.ErrRangeNotSatisfiable) {
+		t.Fatalf("expected ErrRangeNotSatisfiable, got %v", err)
+	}
+	if err := backend.PatchRange(ctx, "missing", 0, []byte("x")); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+func TestFSBackend_PatchRangeGrow(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), AllowPatchGrow: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "cfg", bytes.NewReader([]byte("abc"))); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.PatchRange(ctx, "cfg", 2, []byte("CDE")); err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	meta, err := backend.GetObjectMeta(ctx, "cfg")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+	if meta.Size != 5 {
+		t.Fatalf("expected grown size 5, got %d", meta.Size)
+	}
+}
+
+func TestFSBackend_PatchRangeAliasedAndPacked(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), PackAge: time.Hour})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	for _, key := range []string{"live/cfg", "archive/old"} {
+		if err := backend.Upload(ctx, key, strings.NewReader("hello world")); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if err := backend.CreateAlias(ctx, "current", "live/cfg"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	if err := backend.PatchRange(ctx, "current", 0, []byte("HELLO")); err != nil {
+		t.Fatalf("patch through alias: %v", err)
+	}
+	if got := readObject(t, backend, "live/cfg"); got != "HELLO world" {
+		t.Fatalf("expected the alias target patched, got %q", got)
+	}
+
+	age(t, backend, "archive/old", 2*time.Hour)
+	if err := backend.Compact(ctx, "archive/"); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if err := backend.PatchRange(ctx, "archive/old", 0, []byte("x")); !errors.Is(err, errors.ErrUnsupported) {
+		t.Fatalf("expected packed objects to be unsupported, got %v", err)
+	}
+	if got := readObject(t, backend, "archive/old"); got != "hello world" {
+		t.Fatalf("expected the packed object unchanged, got %q", got)
+	}
+}
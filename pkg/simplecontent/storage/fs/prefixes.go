@@ -0,0 +1,62 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+)
+
+// ListPrefixes returns the distinct key prefixes depth levels deep, such as
+// the tenants at depth 1 when keys start with a tenant segment. Only the
+// directory levels above depth are read, so the cost does not depend on the
+// number of objects below them. Prefixes are returned sorted, without a
+// trailing separator; keys with fewer than depth+1 segments contribute none.
+// With ShardDepth, the shard levels are read first, and each prefix is
+// returned once however many shards hold it.
+func (b *Backend) ListPrefixes(ctx context.Context, depth int) (_ []string, err error) {
+	defer wrapError(&err, "list_prefixes", "")
+
+	if depth < 1 {
+		return nil, errors.New("depth must be at least 1")
+	}
+
+	level := []string{b.baseDir}
+	for i := 0; i < b.shardDepth+depth; i++ {
+		var next []string
+		for _, dir := range level {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			entries, err := os.ReadDir(dir)
+			if os.IsNotExist(err) {
+				// Removed while listing
+				continue
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to read directory: %w", err)
+			}
+			for _, entry := range entries {
+				if !entry.IsDir() || (i == 0 && internalDirs[entry.Name()]) || b.isHidden(entry.Name()) ||
+					filepath.Join(dir, entry.Name()) == b.stagingDir || (i < b.shardDepth && !isShardName(entry.Name())) {
+					continue
+				}
+				next = append(next, filepath.Join(dir, entry.Name()))
+			}
+		}
+		level = next
+	}
+
+	prefixes := make([]string, 0, len(level))
+	for _, dir := range level {
+		key, err := b.objectKey(dir)
+		if err != nil {
+			return nil, err
+		}
+		prefixes = append(prefixes, key)
+	}
+	sort.Strings(prefixes)
+	return slices.Compact(prefixes), nil
+}
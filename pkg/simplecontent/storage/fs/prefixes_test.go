@@ -0,0 +1,44 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_ListPrefixes(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	for _, key := range []string{"acme/2024/a", "acme/2025/b", "globex/2024/c", "initech/d", "top-level-object"} {
+		if err := backend.Upload(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(backend.baseDir, previewDir, "acme"), 0755); err != nil {
+		t.Fatalf("mkdir: %v", err)
+	}
+
+	tenants, err := backend.ListPrefixes(ctx, 1)
+	if err != nil {
+		t.Fatalf("list prefixes: %v", err)
+	}
+	if !reflect.DeepEqual(tenants, []string{"acme", "globex", "initech"}) {
+		t.Fatalf("unexpected tenants %v", tenants)
+	}
+
+	years, err := backend.ListPrefixes(ctx, 2)
+	if err != nil {
+		t.Fatalf("list prefixes: %v", err)
+	}
+	if !reflect.DeepEqual(years, []string{"acme/2024", "acme/2025", "globex/2024"}) {
+		t.Fatalf("unexpected depth-2 prefixes %v", years)
+	}
+
+	if _, err := backend.ListPrefixes(ctx, 0); err == nil {
+		t.Fatalf("expected depth 0 to be rejected")
+	}
+}
@@ -0,0 +1,154 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// previewDir is the internal directory under baseDir holding generated previews
+const previewDir = ".previews"
+
+// PreviewFunc writes a lightweight representation of the object stored at
+// key (such as a scaled image or the first page of a document) to w, reading
+// the original content from src.
+type PreviewFunc func(ctx context.Context, key string, src io.Reader, w io.Writer) error
+
+// previewKey returns the key a generated preview of objectKey is cached under
+func (b *Backend) previewKey(objectKey string) string {
+	return previewDir + b.keySeparator + objectKey
+}
+
+// previewFor returns the preview function registered for a content type
+func (b *Backend) previewFor(contentType string) PreviewFunc {
+	return matchContentType(b.previews, contentType)
+}
+
+// matchContentType looks up a content type in a map keyed by media type,
+// trying the exact media type before a "type/*" wildcard
+func matchContentType[T any](m map[string]T, contentType string) T {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = contentType
+	}
+	if v, ok := m[mediaType]; ok {
+		return v
+	}
+	if major, _, ok := strings.Cut(mediaType, "/"); ok {
+		return m[major+"/*"]
+	}
+	var zero T
+	return zero
+}
+
+// GeneratePreview returns the key to serve as the preview of objectKey.
+//
+// When a PreviewFunc is configured for the object's content type, the preview
+// is generated once and cached as a derived object under the internal
+// .previews directory; it is regenerated when the original is replaced and
+// removed when the original is deleted. Objects without a matching
+// PreviewFunc are their own preview and objectKey is returned unchanged.
+func (b *Backend) GeneratePreview(ctx context.Context, objectKey string) (_ string, err error) {
+	defer wrapError(&err, "generate_preview", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return "", err
+	}
+
+	meta, err := b.GetObjectMeta(ctx, objectKey)
+	if err != nil {
+		return "", err
+	}
+	fn := b.previewFor(meta.ContentType)
+	if fn == nil {
+		return objectKey, nil
+	}
+
+	key := b.previewKey(objectKey)
+	previewPath, err := b.reservedPath(key)
+	if err != nil {
+		return "", err
+	}
+	if info, err := os.Stat(previewPath); err == nil && !info.ModTime().Before(meta.UpdatedAt) {
+		return key, nil
+	}
+
+	src, err := b.Download(ctx, objectKey)
+	if err != nil {
+		return "", err
+	}
+	defer src.Close()
+
+	// Stream the preview into the derived object as it is generated
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(fn(ctx, objectKey, src, pw))
+	}()
+	if err := b.writeObject(ctx, previewPath, pr, simplecontent.UploadParams{ObjectKey: key, MimeType: b.previewType}, nil); err != nil {
+		pr.CloseWithError(err)
+		return "", fmt.Errorf("failed to generate preview: %w", err)
+	}
+	return key, nil
+}
+
+// GetPreviewURLWithType returns a URL for previewing content that is served
+// with contentType, such as "image/webp", whatever type is stored, for
+// renditions whose format differs from the original. The type is covered by
+// the URL's signature.
+func (b *Backend) GetPreviewURLWithType(ctx context.Context, objectKey, contentType string) (_ string, err error) {
+	defer wrapError(&err, "get_preview_url", objectKey)
+
+	if b.urlPrefix == "" {
+		return "", errors.New("direct preview required for filesystem backend")
+	}
+	if _, _, err := mime.ParseMediaType(contentType); err != nil {
+		return "", fmt.Errorf("invalid preview content type %q: %w", contentType, err)
+	}
+	return b.previewURL(objectKey, contentType)
+}
+
+// ValidatePreviewTypeSignature validates a presigned preview URL signature
+// covering a forced content type, as built by GetPreviewURLWithType.
+// Returns nil if signature is valid, error otherwise
+func (b *Backend) ValidatePreviewTypeSignature(objectKey, contentType, signature string, expiresAt int64) error {
+	if b.downloadSigner == nil {
+		return nil
+	}
+	return b.downloadSigner.Validate("GET", previewURLPath(objectKey, contentType), signature, expiresAt)
+}
+
+// previewURLPath returns the signed path of a preview URL
+func previewURLPath(objectKey, contentType string) string {
+	path := "/preview/" + objectKey
+	if contentType != "" {
+		path += "?type=" + url.QueryEscape(contentType)
+	}
+	return path
+}
+
+// removePreview deletes the cached preview of objectKey, if any
+func (b *Backend) removePreview(objectKey string) error {
+	previewPath, err := b.reservedPath(b.previewKey(objectKey))
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(previewPath); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to delete preview: %w", err)
+	}
+	if err := b.removeSidecar(previewPath); err != nil {
+		return err
+	}
+	b.cleanupEmptyDirectories(filepath.Dir(previewPath))
+	return nil
+}
@@ -0,0 +1,100 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_GeneratePreview(t *testing.T) {
+	calls := 0
+	b, err := New(Config{
+		BaseDir: t.TempDir(),
+		Previews: map[string]PreviewFunc{
+			"text/plain": func(ctx context.Context, key string, src io.Reader, w io.Writer) error {
+				calls++
+				data, err := io.ReadAll(src)
+				if err != nil {
+					return err
+				}
+				_, err = io.WriteString(w, strings.ToUpper(string(data[:4])))
+				return err
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "docs/readme.txt", strings.NewReader("hello world")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	key, err := backend.GeneratePreview(ctx, "docs/readme.txt")
+	if err != nil {
+		t.Fatalf("generate preview: %v", err)
+	}
+	if key == "docs/readme.txt" {
+		t.Fatalf("expected a derived preview key")
+	}
+	if got := readObject(t, backend, key); got != "HELL" {
+		t.Fatalf("unexpected preview %q", got)
+	}
+
+	// Cached until the original changes
+	if _, err := backend.GeneratePreview(ctx, "docs/readme.txt"); err != nil || calls != 1 {
+		t.Fatalf("expected cached preview, calls=%d err=%v", calls, err)
+	}
+	if objects, _ := backend.List(ctx, ""); len(objects) != 1 {
+		t.Fatalf("expected previews to be hidden from List, got %+v", objects)
+	}
+
+	// Unsupported types are served as is
+	if err := backend.Upload(ctx, "img.bin", strings.NewReader("\x00\x01\x02")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if key, err := backend.GeneratePreview(ctx, "img.bin"); err != nil || key != "img.bin" {
+		t.Fatalf("expected fallback to original, got %q err=%v", key, err)
+	}
+
+	// Deleting the original removes its preview
+	previewPath, _ := backend.reservedPath(backend.previewKey("docs/readme.txt"))
+	if err := backend.Delete(ctx, "docs/readme.txt"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(previewPath); !os.IsNotExist(err) {
+		t.Fatalf("expected preview removed, stat err=%v", err)
+	}
+}
+
+func TestFSBackend_GeneratePreviewError(t *testing.T) {
+	b, err := New(Config{
+		BaseDir: t.TempDir(),
+		Previews: map[string]PreviewFunc{
+			"text/*": func(ctx context.Context, key string, src io.Reader, w io.Writer) error {
+				return errors.New("renderer unavailable")
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "a.txt", strings.NewReader("text")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if _, err := backend.GeneratePreview(ctx, "a.txt"); err == nil || !strings.Contains(err.Error(), "renderer unavailable") {
+		t.Fatalf("expected generator error, got %v", err)
+	}
+	previewPath, _ := backend.reservedPath(backend.previewKey("a.txt"))
+	if _, err := os.Stat(previewPath); !os.IsNotExist(err) {
+		t.Fatalf("expected no preview cached after failure")
+	}
+}
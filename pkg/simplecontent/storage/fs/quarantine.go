@@ -0,0 +1,211 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// quarantineDir is the internal directory corrupt objects are moved into
+const quarantineDir = ".quarantine"
+
+// reasonSuffix names the record kept next to a quarantined object
+const reasonSuffix = ".reason.json"
+
+// QuarantinedObject describes an object moved aside by QuarantineOnCorruption
+type QuarantinedObject struct {
+	Key           string    `json:"key"`
+	Reason        string    `json:"reason"`
+	QuarantinedAt time.Time `json:"quarantined_at"`
+}
+
+// quarantineKey returns the internal key an object is quarantined under
+func (b *Backend) quarantineKey(objectKey string) string {
+	return quarantineDir + b.keySeparator + objectKey
+}
+
+// Verify reads an object in full and checks it against the checksum recorded
+// when it was written, returning an error wrapping
+// simplecontent.ErrChecksumMismatch when the content has drifted. Objects
+// without a recorded checksum always verify. Aliases verify the object they
+// refer to, and packed objects the checksum kept in their pack. With
+// QuarantineOnCorruption a corrupt object is quarantined before Verify
+// returns; for an alias that is its target. Packed objects cannot be moved
+// out of their pack and are only reported.
+func (b *Backend) Verify(ctx context.Context, objectKey string) (err error) {
+	defer wrapError(&err, "verify", objectKey)
+
+	obj, err := b.openResolved(ctx, objectKey)
+	if err != nil {
+		return err
+	}
+	defer obj.rc.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, obj.rc); err != nil {
+		return fmt.Errorf("failed to read file: %w", err)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return b.checkSHA256(obj.key, obj.sc.SHA256, hex.EncodeToString(hash.Sum(nil)))
+}
+
+// checkSHA256 compares the checksum of content read from objectKey with the
+// one recorded for it, quarantining the object on a mismatch when configured
+func (b *Backend) checkSHA256(objectKey, want, got string) error {
+	if want == "" || want == got {
+		return nil
+	}
+	err := fmt.Errorf("%w: expected sha256 %s, got %s", simplecontent.ErrChecksumMismatch, want, got)
+	if b.quarantineBad && !b.readOnly {
+		if qerr := b.quarantine(objectKey, want, err.Error()); qerr != nil {
+			return errors.Join(err, fmt.Errorf("failed to quarantine: %w", qerr))
+		}
+	}
+	return err
+}
+
+// quarantine moves an object and its sidecar under .quarantine with a record
+// of why. Objects whose recorded checksum is no longer want were replaced
+// since they were read and are left in place.
+func (b *Backend) quarantine(objectKey, want, reason string) error {
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return err
+	}
+	quarantinePath, err := b.reservedPath(b.quarantineKey(objectKey))
+	if err != nil {
+		return err
+	}
+
+	sc, err := b.loadSidecar(filePath)
+	if err != nil {
+		return err
+	} else if sc.SHA256 != want {
+		return nil
+	}
+
+	if err := b.mkdirAll(filepath.Dir(quarantinePath)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	data, err := json.Marshal(QuarantinedObject{Key: objectKey, Reason: reason, QuarantinedAt: time.Now().UTC()})
+	if err != nil {
+		return fmt.Errorf("failed to encode quarantine record: %w", err)
+	}
+	if err := writeReplace(context.Background(), quarantinePath+reasonSuffix, bytes.NewReader(data), 0); err != nil {
+		return err
+	}
+
+	if err := b.moveSidecar(filePath, quarantinePath); err != nil {
+		return err
+	}
+	if err := os.Rename(filePath, quarantinePath); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	if err := b.removePreview(objectKey); err != nil {
+		return err
+	}
+	b.cleanupEmptyDirectories(filepath.Dir(filePath))
+	return nil
+}
+
+// ListQuarantined returns the objects currently held in quarantine, ordered
+// by key.
+func (b *Backend) ListQuarantined(ctx context.Context) (_ []QuarantinedObject, err error) {
+	defer wrapError(&err, "list_quarantined", "")
+
+	root := filepath.Join(b.baseDir, quarantineDir)
+	var objects []QuarantinedObject
+	err = filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == root {
+				return fs.SkipAll
+			}
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), reasonSuffix) {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			// Released while listing
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read quarantine record: %w", err)
+		}
+		var obj QuarantinedObject
+		if err := json.Unmarshal(data, &obj); err != nil {
+			return fmt.Errorf("failed to decode quarantine record: %w", err)
+		}
+		objects = append(objects, obj)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Release moves a quarantined object back to its key, for example after it
+// was found to be intact or repaired in place. It fails if a new object has
+// since been written to the key.
+func (b *Backend) Release(ctx context.Context, objectKey string) (err error) {
+	defer wrapError(&err, "release", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return err
+	}
+	quarantinePath, err := b.reservedPath(b.quarantineKey(objectKey))
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(quarantinePath); os.IsNotExist(err) {
+		return simplecontent.ErrObjectNotFound
+	} else if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	if _, err := os.Stat(filePath); err == nil {
+		return fmt.Errorf("object %q has been replaced since it was quarantined", objectKey)
+	}
+
+	if err := b.mkdirAll(filepath.Dir(filePath)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if err := b.moveSidecar(quarantinePath, filePath); err != nil {
+		return err
+	}
+	if err := os.Rename(quarantinePath, filePath); err != nil {
+		return fmt.Errorf("failed to move file: %w", err)
+	}
+	if err := os.Remove(quarantinePath + reasonSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete quarantine record: %w", err)
+	}
+	b.cleanupEmptyDirectories(filepath.Dir(quarantinePath))
+	return nil
+}
@@ -0,0 +1,167 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_QuarantineOnCorruption(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), QuarantineOnCorruption: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	for _, key := range []string{"scrub/a.txt", "serve/b.txt"} {
+		if err := backend.Upload(ctx, key, strings.NewReader("intact content")); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+		if err := os.WriteFile(mustObjectPath(t, backend, key), []byte("rotted content"), 0644); err != nil {
+			t.Fatalf("corrupt %s: %v", key, err)
+		}
+	}
+
+	if err := backend.Verify(ctx, "scrub/a.txt"); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch from Verify, got %v", err)
+	}
+	rc, verify, err := backend.DownloadWithVerify(ctx, "serve/b.txt")
+	if err != nil {
+		t.Fatalf("download with verify: %v", err)
+	}
+	io.Copy(io.Discard, rc)
+	rc.Close()
+	if err := verify(); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch from verify, got %v", err)
+	}
+
+	for _, key := range []string{"scrub/a.txt", "serve/b.txt"} {
+		if _, err := backend.Download(ctx, key); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+			t.Fatalf("expected quarantined %s to be hidden from Download, got %v", key, err)
+		}
+		if _, err := backend.GetObjectMeta(ctx, key); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+			t.Fatalf("expected quarantined %s to be hidden from GetObjectMeta, got %v", key, err)
+		}
+	}
+	if objects, err := backend.List(ctx, ""); err != nil || len(objects) != 0 {
+		t.Fatalf("expected quarantine excluded from List, got %v %v", objects, err)
+	}
+
+	quarantined, err := backend.ListQuarantined(ctx)
+	if err != nil {
+		t.Fatalf("list quarantined: %v", err)
+	}
+	if len(quarantined) != 2 || quarantined[0].Key != "scrub/a.txt" || quarantined[1].Key != "serve/b.txt" {
+		t.Fatalf("unexpected quarantine listing %+v", quarantined)
+	}
+	if !strings.Contains(quarantined[0].Reason, "checksum mismatch") || quarantined[0].QuarantinedAt.IsZero() {
+		t.Fatalf("expected reason record, got %+v", quarantined[0])
+	}
+
+	if err := backend.Release(ctx, "scrub/a.txt"); err != nil {
+		t.Fatalf("release: %v", err)
+	}
+	if got := readObject(t, backend, "scrub/a.txt"); got != "rotted content" {
+		t.Fatalf("expected released object restored, got %q", got)
+	}
+	if quarantined, _ := backend.ListQuarantined(ctx); len(quarantined) != 1 {
+		t.Fatalf("expected one object left in quarantine, got %+v", quarantined)
+	}
+	if err := backend.Release(ctx, "scrub/a.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound releasing twice, got %v", err)
+	}
+
+	// A key rewritten while quarantined is not overwritten by Release
+	if err := backend.Upload(ctx, "serve/b.txt", strings.NewReader("fresh")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Release(ctx, "serve/b.txt"); err == nil {
+		t.Fatalf("expected release over a live object to fail")
+	}
+}
+
+func TestFSBackend_VerifyWithoutQuarantine(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "k", strings.NewReader("compressed content")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.Verify(ctx, "k"); err != nil {
+		t.Fatalf("expected intact object to verify: %v", err)
+	}
+
+	sc, _ := readSidecar(mustObjectPath(t, backend, "k"))
+	sc.SHA256 = strings.Repeat("0", 64)
+	if err := writeSidecar(mustObjectPath(t, backend, "k"), sc); err != nil {
+		t.Fatalf("write sidecar: %v", err)
+	}
+	if err := backend.Verify(ctx, "k"); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if got := readObject(t, backend, "k"); got != "compressed content" {
+		t.Fatalf("expected object left in place, got %q", got)
+	}
+}
+
+func TestFSBackend_VerifyAliasedAndPacked(t *testing.T) {
+	dir := t.TempDir()
+	b, err := New(Config{BaseDir: dir, QuarantineOnCorruption: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	// An alias verifies, and quarantines, the object it refers to
+	if err := backend.Upload(ctx, "target.txt", strings.NewReader("intact content")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.CreateAlias(ctx, "alias.txt", "target.txt"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	if err := os.WriteFile(mustObjectPath(t, backend, "target.txt"), []byte("rotted content"), 0644); err != nil {
+		t.Fatalf("corrupt: %v", err)
+	}
+	if err := backend.Verify(ctx, "alias.txt"); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch through the alias, got %v", err)
+	}
+	quarantined, err := backend.ListQuarantined(ctx)
+	if err != nil || len(quarantined) != 1 || quarantined[0].Key != "target.txt" {
+		t.Fatalf("expected the target quarantined, got %v %v", quarantined, err)
+	}
+
+	// A packed object verifies against the checksum kept in its pack
+	if err := backend.Upload(ctx, "packed.txt", strings.NewReader("intact content")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	backend.packAge = 0
+	if err := backend.Compact(ctx, "packed"); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if err := backend.Verify(ctx, "packed.txt"); err != nil {
+		t.Fatalf("expected intact packed object to verify, got %v", err)
+	}
+	packs, _ := filepath.Glob(filepath.Join(dir, packDir, "*"+packExt))
+	if len(packs) != 1 {
+		t.Fatalf("expected one pack, got %v", packs)
+	}
+	data, err := os.ReadFile(packs[0])
+	if err != nil {
+		t.Fatalf("read pack: %v", err)
+	}
+	if err := os.WriteFile(packs[0], bytes.Replace(data, []byte("intact"), []byte("rotted"), 1), 0644); err != nil {
+		t.Fatalf("corrupt pack: %v", err)
+	}
+	if err := backend.Verify(ctx, "packed.txt"); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch for the packed object, got %v", err)
+	}
+}
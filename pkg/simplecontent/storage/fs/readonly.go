@@ -0,0 +1,30 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// checkWritable fails every operation that would modify the store of a
+// backend configured with ReadOnly, before it touches the filesystem
+func (b *Backend) checkWritable() error {
+	if b.readOnly {
+		return simplecontent.ErrReadOnly
+	}
+	return nil
+}
+
+// checkBaseDir verifies that the base directory of a read-only backend
+// exists, which New would otherwise create
+func checkBaseDir(dir string) error {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return fmt.Errorf("base directory of a read-only store must exist: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("base directory %s is not a directory", dir)
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// snapshotTree records every path under dir with its size and mtime
+func snapshotTree(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	tree := make(map[string]string)
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		tree[p] = fmt.Sprintf("%s %v %d", info.Mode(), info.ModTime(), info.Size())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("walk %s: %v", dir, err)
+	}
+	return tree
+}
+
+func TestFSBackend_ReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	ctx := context.Background()
+
+	writer := newCompressedBackend(t, dir, "")
+	if err := writer.Upload(ctx, "docs/a.txt", strings.NewReader("archived")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	store, err := New(Config{BaseDir: dir, ReadOnly: true, URLPrefix: "https://cdn.example.com", SignatureSecretKey: "secret", StagingDir: "staging"})
+	if err != nil {
+		t.Fatalf("new read-only backend: %v", err)
+	}
+	b := store.(*Backend)
+	before := snapshotTree(t, dir)
+
+	writes := map[string]func() error{
+		"upload": func() error { return b.Upload(ctx, "docs/b.txt", strings.NewReader("new")) },
+		"upload with params": func() error {
+			return b.UploadWithParams(ctx, strings.NewReader("new"), simplecontent.UploadParams{ObjectKey: "docs/a.txt"})
+		},
+		"delete":      func() error { return b.Delete(ctx, "docs/a.txt") },
+		"copy":        func() error { return b.Copy(ctx, "docs/a.txt", "docs/c.txt") },
+		"move":        func() error { return b.Move(ctx, "docs/a.txt", "docs/c.txt") },
+		"reserve":     func() error { return b.Reserve(ctx, "docs/d.txt", time.Minute) },
+		"patch range": func() error { return b.PatchRange(ctx, "docs/a.txt", 0, []byte("X")) },
+		"delete many": func() error { _, err := b.DeleteMany(ctx, []string{"docs/a.txt"}); return err },
+	}
+	for name, write := range writes {
+		if err := write(); !errors.Is(err, simplecontent.ErrReadOnly) {
+			t.Errorf("%s: expected ErrReadOnly, got %v", name, err)
+		}
+	}
+
+	if got := readObject(t, b, "docs/a.txt"); got != "archived" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if meta, err := b.GetObjectMeta(ctx, "docs/a.txt"); err != nil || meta.Size != int64(len("archived")) {
+		t.Fatalf("unexpected meta %+v, %v", meta, err)
+	}
+	if url, err := b.GetDownloadURL(ctx, "docs/a.txt", ""); err != nil || !strings.Contains(url, "signature=") {
+		t.Fatalf("unexpected download url %q, %v", url, err)
+	}
+
+	after := snapshotTree(t, dir)
+	if len(after) != len(before) {
+		t.Fatalf("expected the tree to be untouched, had %d entries, now %d", len(before), len(after))
+	}
+	for p, v := range before {
+		if after[p] != v {
+			t.Errorf("%s changed", p)
+		}
+	}
+}
+
+func TestFSBackend_ReadOnlyMissingDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	if _, err := New(Config{BaseDir: dir, ReadOnly: true}); err == nil {
+		t.Fatal("expected error for a missing base directory")
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Fatalf("expected the base directory not to be created, got %v", err)
+	}
+}
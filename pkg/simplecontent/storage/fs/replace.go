@@ -0,0 +1,36 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// replaceFile atomically renames tmp over dst.
+//
+// Readers opened by this backend never block the rename: on Unix they keep
+// reading the replaced file, and on Windows objects are opened with
+// FILE_SHARE_DELETE (see openObject), which gives the same behaviour. Another
+// process holding dst open on Windows can still make the rename fail; it is
+// then retried for up to timeout before failing with
+// simplecontent.ErrObjectBusy.
+func replaceFile(tmp, dst string, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	delay := 10 * time.Millisecond
+	for {
+		err := os.Rename(tmp, dst)
+		if err == nil {
+			return nil
+		}
+		if !isBusy(err) {
+			return fmt.Errorf("failed to rename file: %w", err)
+		}
+		if time.Now().Add(delay).After(deadline) {
+			return fmt.Errorf("%w: %v", simplecontent.ErrObjectBusy, err)
+		}
+		time.Sleep(delay)
+		delay = min(2*delay, time.Second)
+	}
+}
@@ -0,0 +1,62 @@
+package fs
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_OverwriteDuringDownload(t *testing.T) {
+	for _, codec := range []string{CodecNone, CodecGzip} {
+		t.Run(codec, func(t *testing.T) {
+			backend := newCompressedBackend(t, t.TempDir(), codec)
+			ctx := context.Background()
+
+			original := strings.Repeat("old content ", 10000)
+			if err := backend.Upload(ctx, "media/clip", strings.NewReader(original)); err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+
+			rc, err := backend.Download(ctx, "media/clip")
+			if err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			defer rc.Close()
+			head := make([]byte, 100)
+			if _, err := io.ReadFull(rc, head); err != nil {
+				t.Fatalf("read: %v", err)
+			}
+
+			// Replacing the object while it is open succeeds on every platform
+			if err := backend.Upload(ctx, "media/clip", strings.NewReader("new content")); err != nil {
+				t.Fatalf("overwrite during download: %v", err)
+			}
+			if got := readObject(t, backend, "media/clip"); got != "new content" {
+				t.Fatalf("expected new readers to see the replacement, got %q", got)
+			}
+
+			// The in-flight reader keeps reading the content it opened
+			rest, err := io.ReadAll(rc)
+			if err != nil {
+				t.Fatalf("read rest: %v", err)
+			}
+			if string(head)+string(rest) != original {
+				t.Fatalf("expected in-flight download to see the original content")
+			}
+
+			// Deleting while open behaves the same way
+			rc2, err := backend.Download(ctx, "media/clip")
+			if err != nil {
+				t.Fatalf("download: %v", err)
+			}
+			defer rc2.Close()
+			if err := backend.Delete(ctx, "media/clip"); err != nil {
+				t.Fatalf("delete during download: %v", err)
+			}
+			if got, _ := io.ReadAll(rc2); string(got) != "new content" {
+				t.Fatalf("expected in-flight download to survive delete, got %q", got)
+			}
+		})
+	}
+}
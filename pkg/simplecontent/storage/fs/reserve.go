@@ -0,0 +1,131 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// Reserve claims objectKey for ttl before its content is ready, so workers
+// sharing the store never choose the same key. It creates a zero-byte
+// placeholder whose sidecar marks it reserved, and fails with
+// simplecontent.ErrObjectExists when an object or a live reservation is
+// already there. The next Upload of the key fills the placeholder and
+// clears the reservation.
+//
+// Until then Download, GetObjectMeta and List treat the key as missing. An
+// expired reservation can be claimed again by Reserve, and is removed by
+// ReapReservations.
+func (b *Backend) Reserve(ctx context.Context, objectKey string, ttl time.Duration) (err error) {
+	defer wrapError(&err, "reserve", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	if ttl <= 0 {
+		return errors.New("reservation ttl must be positive")
+	}
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return err
+	}
+	if err := b.mkdirAll(filepath.Dir(filePath)); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+
+	// Serialise with other reservations, so only one caller takes over an
+	// expired one
+	lockPath := filePath + lockSuffix
+	leaseID, err := b.waitLock(ctx, lockPath)
+	if err != nil {
+		return err
+	}
+	defer releaseLock(lockPath, leaseID)
+
+	reservation := &sidecar{ReservedUntil: time.Now().Add(ttl).UTC()}
+	file, err := os.OpenFile(filePath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0666)
+	if os.IsExist(err) {
+		sc, err := b.loadSidecar(filePath)
+		if err != nil {
+			return err
+		}
+		if !sc.reserved() || time.Now().Before(sc.ReservedUntil) {
+			return simplecontent.ErrObjectExists
+		}
+		return b.storeSidecar(filePath, reservation)
+	} else if err != nil {
+		return fmt.Errorf("failed to create placeholder: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to create placeholder: %w", err)
+	}
+	if err := chmodTemp(filePath, b.fileMode); err != nil {
+		return err
+	}
+	return b.storeSidecar(filePath, reservation)
+}
+
+// ReapReservations removes the placeholders of reservations that expired
+// without an upload and returns how many were removed. Run it periodically
+// so abandoned keys become free for Reserve and stop taking up entries.
+func (b *Backend) ReapReservations(ctx context.Context) (_ int, err error) {
+	defer wrapError(&err, "reap_reservations", "")
+
+	if err := b.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	var expired []string
+	err = b.walkFiles(ctx, "", func(filePath string, _ simplecontent.ObjectMeta, sc *sidecar) error {
+		if sc.reserved() && now.After(sc.ReservedUntil) {
+			expired = append(expired, filePath)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	reaped := 0
+	for _, filePath := range expired {
+		if ok, err := b.reapReservation(ctx, filePath, now); err != nil {
+			return reaped, err
+		} else if ok {
+			reaped++
+		}
+	}
+	return reaped, nil
+}
+
+// reapReservation removes an expired placeholder unless it was filled or
+// reserved again since it was found
+func (b *Backend) reapReservation(ctx context.Context, filePath string, now time.Time) (bool, error) {
+	lockPath := filePath + lockSuffix
+	leaseID, err := b.waitLock(ctx, lockPath)
+	if err != nil {
+		return false, err
+	}
+	defer releaseLock(lockPath, leaseID)
+
+	sc, err := b.loadSidecar(filePath)
+	if err != nil || !sc.reserved() || !now.After(sc.ReservedUntil) {
+		return false, err
+	}
+	if err := os.Remove(filePath); os.IsNotExist(err) {
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to remove placeholder: %w", err)
+	}
+	if err := b.removeSidecar(filePath); err != nil {
+		return false, err
+	}
+	b.cleanupEmptyDirectories(filepath.Dir(filePath))
+	return true, nil
+}
@@ -0,0 +1,81 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Reserve(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if err := backend.Reserve(ctx, "jobs/1/out.txt", time.Minute); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if err := backend.Reserve(ctx, "jobs/1/out.txt", time.Minute); !errors.Is(err, simplecontent.ErrObjectExists) {
+		t.Fatalf("expected ErrObjectExists for a live reservation, got %v", err)
+	}
+
+	// The placeholder is not an object yet
+	if _, err := backend.Download(ctx, "jobs/1/out.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound downloading a reservation, got %v", err)
+	}
+	if _, err := backend.GetObjectMeta(ctx, "jobs/1/out.txt"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound for reservation metadata, got %v", err)
+	}
+	if keys := listKeys(t, backend, "jobs/"); len(keys) != 0 {
+		t.Fatalf("expected reservation to be unlisted, got %v", keys)
+	}
+
+	if err := backend.Upload(ctx, "jobs/1/out.txt", strings.NewReader("result")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if got := readObject(t, backend, "jobs/1/out.txt"); got != "result" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if keys := listKeys(t, backend, "jobs/"); len(keys) != 1 {
+		t.Fatalf("expected filled reservation to be listed, got %v", keys)
+	}
+	if err := backend.Reserve(ctx, "jobs/1/out.txt", time.Minute); !errors.Is(err, simplecontent.ErrObjectExists) {
+		t.Fatalf("expected ErrObjectExists for an object, got %v", err)
+	}
+}
+
+func TestFSBackend_ReapReservations(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	if err := backend.Reserve(ctx, "stale/a", time.Millisecond); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	if err := backend.Reserve(ctx, "live/b", time.Hour); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	// An expired reservation can be claimed again
+	if err := backend.Reserve(ctx, "stale/a", time.Millisecond); err != nil {
+		t.Fatalf("reserve expired key: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	reaped, err := backend.ReapReservations(ctx)
+	if err != nil {
+		t.Fatalf("reap: %v", err)
+	}
+	if reaped != 1 {
+		t.Fatalf("expected 1 reservation reaped, got %d", reaped)
+	}
+	if _, err := os.Stat(mustObjectPath(t, backend, "stale/a")); !os.IsNotExist(err) {
+		t.Fatalf("expected placeholder to be removed, got %v", err)
+	}
+	if _, err := os.Stat(mustObjectPath(t, backend, "live/b")); err != nil {
+		t.Fatalf("expected live reservation to remain: %v", err)
+	}
+}
@@ -0,0 +1,147 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// SameContent reports whether two objects hold the same bytes. It answers
+// from the cheapest signal available: hardlinked copies share a file,
+// objects of different sizes differ, and objects that both have a recorded
+// checksum are compared by checksum. Only otherwise are the objects read, in
+// step, stopping at the first difference. Compressed objects are compared by
+// their original content.
+func (b *Backend) SameContent(ctx context.Context, keyA, keyB string) (_ bool, err error) {
+	defer wrapError(&err, "same_content", keyA)
+
+	infoA, scA, err := b.statObject(keyA)
+	if err != nil {
+		return false, err
+	}
+	infoB, scB, err := b.statObject(keyB)
+	if err != nil {
+		return false, err
+	}
+
+	if os.SameFile(infoA, infoB) {
+		return true, nil
+	}
+	if b.fileMeta(keyA, infoA, scA).Size != b.fileMeta(keyB, infoB, scB).Size {
+		return false, nil
+	}
+	if scA.SHA256 != "" && scB.SHA256 != "" {
+		return scA.SHA256 == scB.SHA256, nil
+	}
+
+	ra, err := b.download(ctx, keyA)
+	if err != nil {
+		return false, err
+	}
+	defer ra.Close()
+	rb, err := b.download(ctx, keyB)
+	if err != nil {
+		return false, err
+	}
+	defer rb.Close()
+
+	return compareStreams(ctx, ra, rb, keyA, keyB)
+}
+
+// Matches reports whether the object at objectKey holds exactly the bytes
+// reader yields. When reader's length is known up front (it has a Len
+// method, as bytes.Reader and strings.Reader do, or is an *os.File) a size
+// difference answers without reading; otherwise both are read in step,
+// stopping at the first difference. Compressed objects are compared by their
+// original content. reader is not read to the end when they differ.
+func (b *Backend) Matches(ctx context.Context, objectKey string, reader io.Reader) (_ bool, err error) {
+	defer wrapError(&err, "matches", objectKey)
+
+	info, sc, err := b.statObject(objectKey)
+	if err != nil {
+		return false, err
+	}
+	if size, ok := readerSize(reader); ok && size != b.fileMeta(objectKey, info, sc).Size {
+		return false, nil
+	}
+
+	rc, err := b.download(ctx, objectKey)
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+
+	return compareStreams(ctx, rc, reader, objectKey, "reader")
+}
+
+// readerSize returns the number of bytes left in r, if known without
+// reading it
+func readerSize(r io.Reader) (int64, bool) {
+	switch r := r.(type) {
+	case interface{ Len() int }:
+		return int64(r.Len()), true
+	case *os.File:
+		info, err := r.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return 0, false
+		}
+		pos, err := r.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return 0, false
+		}
+		return info.Size() - pos, true
+	}
+	return 0, false
+}
+
+// compareStreams reads a and b in step and reports whether they yield the
+// same bytes, stopping at the first difference. nameA and nameB label read
+// errors.
+func compareStreams(ctx context.Context, a, b io.Reader, nameA, nameB string) (bool, error) {
+	bufA := make([]byte, 32<<10)
+	bufB := make([]byte, 32<<10)
+	for {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		nA, errA := io.ReadFull(a, bufA)
+		nB, errB := io.ReadFull(b, bufB)
+		if !bytes.Equal(bufA[:nA], bufB[:nB]) {
+			return false, nil
+		}
+		endA := errA == io.EOF || errA == io.ErrUnexpectedEOF
+		endB := errB == io.EOF || errB == io.ErrUnexpectedEOF
+		if errA != nil && !endA {
+			return false, fmt.Errorf("failed to read %s: %w", nameA, errA)
+		}
+		if errB != nil && !endB {
+			return false, fmt.Errorf("failed to read %s: %w", nameB, errB)
+		}
+		if endA || endB {
+			return endA && endB, nil
+		}
+	}
+}
+
+// statObject returns the file info and sidecar of an object
+func (b *Backend) statObject(objectKey string) (os.FileInfo, *sidecar, error) {
+	filePath, err := b.objectPath(objectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	info, err := os.Stat(filePath)
+	if os.IsNotExist(err) {
+		return nil, nil, simplecontent.ErrObjectNotFound
+	} else if err != nil {
+		return nil, nil, fmt.Errorf("failed to get file info: %w", err)
+	}
+	sc, err := b.loadSidecar(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	return info, sc, nil
+}
@@ -0,0 +1,116 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_SameContent(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	body := strings.Repeat("0123456789", 10000)
+	objects := map[string]string{
+		"a":       body,
+		"b":       body,
+		"shorter": body[:len(body)-1],
+		"late":    body[:len(body)-1] + "x",
+	}
+	for key, content := range objects {
+		if err := backend.Upload(ctx, key, strings.NewReader(content)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+
+	// Drop the recorded checksums of two objects to force a byte comparison
+	for _, key := range []string{"b", "late"} {
+		path := mustObjectPath(t, backend, key)
+		sc, _ := readSidecar(path)
+		sc.SHA256 = ""
+		if err := writeSidecar(path, sc); err != nil {
+			t.Fatalf("write sidecar: %v", err)
+		}
+	}
+
+	for _, tc := range []struct {
+		a, b string
+		want bool
+	}{
+		{"a", "b", true},
+		{"a", "a", true},
+		{"a", "shorter", false},
+		{"a", "late", false},
+	} {
+		same, err := backend.SameContent(ctx, tc.a, tc.b)
+		if err != nil {
+			t.Fatalf("same content %s/%s: %v", tc.a, tc.b, err)
+		}
+		if same != tc.want {
+			t.Fatalf("expected SameContent(%s, %s) = %v", tc.a, tc.b, tc.want)
+		}
+	}
+
+	if _, err := backend.SameContent(ctx, "a", "missing"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
+
+// countingReader counts the bytes read through it and hides any Len method
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestFSBackend_Matches(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	body := strings.Repeat("0123456789", 10000)
+	if err := backend.Upload(ctx, "obj", strings.NewReader(body)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	cases := []struct {
+		name   string
+		reader io.Reader
+		want   bool
+	}{
+		{"identical", strings.NewReader(body), true},
+		{"identical stream", &countingReader{r: strings.NewReader(body)}, true},
+		{"shorter stream", &countingReader{r: strings.NewReader(body[:len(body)-1])}, false},
+		{"longer stream", &countingReader{r: strings.NewReader(body + "x")}, false},
+		{"late difference", strings.NewReader(body[:len(body)-1] + "x"), false},
+	}
+	for _, c := range cases {
+		got, err := backend.Matches(ctx, "obj", c.reader)
+		if err != nil || got != c.want {
+			t.Fatalf("%s: expected %v, got %v err=%v", c.name, c.want, got, err)
+		}
+	}
+
+	// A known size mismatch answers without reading, and an early
+	// difference stops reading
+	sized := strings.NewReader(body + "x")
+	if ok, err := backend.Matches(ctx, "obj", sized); ok || err != nil || sized.Len() != len(body)+1 {
+		t.Fatalf("expected size mismatch without reading, ok=%v err=%v", ok, err)
+	}
+	early := &countingReader{r: strings.NewReader("x" + body)}
+	if ok, err := backend.Matches(ctx, "obj", early); ok || err != nil || early.n >= len(body) {
+		t.Fatalf("expected early exit, ok=%v err=%v read=%d", ok, err, early.n)
+	}
+
+	if _, err := backend.Matches(ctx, "missing", strings.NewReader(body)); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,109 @@
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxShardDepth bounds ShardDepth; each level multiplies the directories
+// under baseDir by 256
+const maxShardDepth = 4
+
+// shardPrefix returns the shard directories of a slash-separated key,
+// joined with "/": one level per depth, each named by the next byte of the
+// SHA-256 of the key in hex
+func shardPrefix(key string, depth int) string {
+	sum := sha256.Sum256([]byte(key))
+	levels := make([]string, depth)
+	for i := range levels {
+		levels[i] = hex.EncodeToString(sum[i : i+1])
+	}
+	return strings.Join(levels, "/")
+}
+
+// isShardName reports whether a directory name can be a shard level
+func isShardName(name string) bool {
+	if len(name) != 2 {
+		return false
+	}
+	for _, c := range []byte(name) {
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// validateShardDepth checks that ShardDepth is within range
+func validateShardDepth(depth int) error {
+	if depth < 0 || depth > maxShardDepth {
+		return fmt.Errorf("shard depth must be between 0 and %d, got %d", maxShardDepth, depth)
+	}
+	return nil
+}
+
+// sharded reports whether a slash-separated key or path is nested under
+// shard directories: every one outside the internal directories, when
+// ShardDepth is set
+func (b *Backend) sharded(key string) bool {
+	first, _, _ := strings.Cut(key, "/")
+	return b.shardDepth > 0 && !internalDirs[first]
+}
+
+// shard nests a slash-separated key under its shard directories. The key
+// is hashed as its path is cleaned, so keys naming the same path share
+// their shard.
+func (b *Backend) shard(key string) string {
+	if !b.sharded(key) {
+		return key
+	}
+	key = path.Clean(key)
+	return shardPrefix(key, b.shardDepth) + "/" + key
+}
+
+// unshard strips the shard directories from a slash-separated path relative
+// to baseDir
+func (b *Backend) unshard(rel string) (string, error) {
+	if !b.sharded(rel) {
+		return rel, nil
+	}
+	levels := strings.SplitN(rel, "/", b.shardDepth+1)
+	if len(levels) <= b.shardDepth {
+		return "", fmt.Errorf("%s is not below the shard directories", rel)
+	}
+	return levels[b.shardDepth], nil
+}
+
+// skipShardDir reports whether a walk for prefix can skip the directory at
+// dirPath: a shard level not named like one, or a directory below them
+// holding no keys starting with prefix
+func (b *Backend) skipShardDir(dirPath, name, prefix string) bool {
+	rel, err := filepath.Rel(b.baseDir, dirPath)
+	if err != nil {
+		return false
+	}
+	if depth := strings.Count(filepath.ToSlash(rel), "/") + 1; depth <= b.shardDepth {
+		return !isShardName(name)
+	}
+	key, err := b.objectKey(dirPath)
+	if err != nil {
+		return false
+	}
+	key += b.keySeparator
+	return !strings.HasPrefix(key, prefix) && !strings.HasPrefix(prefix, key)
+}
+
+// misplaced reports whether the object file at filePath, whose key is key,
+// is not where objectPath puts the key, as objects stored before ShardDepth
+// was set are not. Walks skip such files.
+func (b *Backend) misplaced(filePath, key string) bool {
+	if b.shardDepth == 0 {
+		return false
+	}
+	want, err := b.objectPath(key)
+	return err != nil || want != filePath
+}
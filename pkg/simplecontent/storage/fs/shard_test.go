@@ -0,0 +1,69 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_Sharding(t *testing.T) {
+	dir := t.TempDir()
+	store, err := New(Config{BaseDir: dir, ShardDepth: 2})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	b := store.(*Backend)
+	ctx := context.Background()
+
+	keys := []string{"docs/a.txt", "docs/b.txt", "docs/sub/c.txt", "images/d.png", "e.txt"}
+	for _, key := range keys {
+		if err := b.Upload(ctx, key, strings.NewReader(key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "6b", "7b", "docs", "a.txt")); err != nil {
+		t.Fatalf("expected docs/a.txt under its shard directories: %v", err)
+	}
+	// Not an object: its path is not where its key is sharded
+	if err := os.WriteFile(filepath.Join(dir, "stray.txt"), []byte("x"), 0644); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	if got := readObject(t, b, "docs/sub/c.txt"); got != "docs/sub/c.txt" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if meta, err := b.GetObjectMeta(ctx, "images/d.png"); err != nil || meta.Key != "images/d.png" {
+		t.Fatalf("unexpected meta %+v, %v", meta, err)
+	}
+
+	want := slices.Sorted(slices.Values(keys))
+	if got := listKeys(t, b, ""); !slices.Equal(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+	if got := listKeys(t, b, "docs/"); !slices.Equal(got, []string{"docs/a.txt", "docs/b.txt", "docs/sub/c.txt"}) {
+		t.Fatalf("unexpected listing of docs/: %v", got)
+	}
+	if got := listKeys(t, b, "docs/s"); !slices.Equal(got, []string{"docs/sub/c.txt"}) {
+		t.Fatalf("unexpected listing of docs/s: %v", got)
+	}
+	prefixes, err := b.ListPrefixes(ctx, 1)
+	if err != nil || !slices.Equal(prefixes, []string{"docs", "images"}) {
+		t.Fatalf("unexpected prefixes %v, %v", prefixes, err)
+	}
+
+	if err := b.Delete(ctx, "docs/a.txt"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "6b")); !os.IsNotExist(err) {
+		t.Fatalf("expected empty shard directories to be removed, got %v", err)
+	}
+
+	for _, depth := range []int{-1, maxShardDepth + 1} {
+		if _, err := New(Config{BaseDir: t.TempDir(), ShardDepth: depth}); err == nil {
+			t.Fatalf("expected shard depth %d to be rejected", depth)
+		}
+	}
+}
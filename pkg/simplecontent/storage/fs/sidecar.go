@@ -0,0 +1,226 @@
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// sidecarSuffix is appended to an object's path to name its metadata sidecar
+const sidecarSuffix = ".meta.json"
+
+// stagedSidecarSuffix names the sidecar written ahead of an object's new
+// file, before it replaces the current sidecar; see stageSidecar
+const stagedSidecarSuffix = sidecarSuffix + ".staged"
+
+// sidecar holds metadata recorded next to an object in <key>.meta.json
+type sidecar struct {
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+	SHA256      string    `json:"sha256,omitempty"`       // Hex SHA-256 of the original content
+	ContentType string    `json:"content_type,omitempty"` // Content type declared at upload
+	Codec       string    `json:"codec,omitempty"`        // Compression codec the object is stored with
+	Size        int64     `json:"size,omitempty"`         // Original size of a compressed object
+
+	// Metadata is the user metadata supplied with UploadParams.Metadata
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// ReservedUntil marks a placeholder created by Reserve that has not been
+	// uploaded yet, and when the reservation expires
+	ReservedUntil time.Time `json:"reserved_until,omitzero"`
+
+	// Data identifies the object file a staged sidecar was written for
+	Data *dataStamp `json:"data,omitempty"`
+}
+
+// dataStamp identifies an object file by its size and modification time,
+// which a rename leaves unchanged
+type dataStamp struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+func stampOf(info os.FileInfo) *dataStamp {
+	return &dataStamp{Size: info.Size(), ModTime: info.ModTime()}
+}
+
+func (d *dataStamp) matches(info os.FileInfo) bool {
+	return info.Size() == d.Size && info.ModTime().Equal(d.ModTime)
+}
+
+// isZero reports whether the sidecar records nothing and need not be stored
+func (s *sidecar) isZero() bool {
+	return s.CreatedAt.IsZero() && s.SHA256 == "" && s.ContentType == "" && s.Codec == "" && len(s.Metadata) == 0 && s.ReservedUntil.IsZero()
+}
+
+// reserved reports whether the sidecar belongs to an unfilled reservation
+func (s *sidecar) reserved() bool {
+	return !s.ReservedUntil.IsZero()
+}
+
+// sidecarPath returns the sidecar path for an object file path
+func sidecarPath(filePath string) string {
+	return filePath + sidecarSuffix
+}
+
+// stagedSidecarPath returns the staged sidecar path for an object file path
+func stagedSidecarPath(filePath string) string {
+	return filePath + stagedSidecarSuffix
+}
+
+// readSidecar loads the sidecar for an object, returning an empty sidecar
+// when none has been written. A staged sidecar takes precedence once the
+// object file is the one it was staged for.
+func readSidecar(filePath string) (*sidecar, error) {
+	if sc, err := readStagedSidecar(filePath); sc != nil || err != nil {
+		return sc, err
+	}
+	sc, err := decodeSidecar(sidecarPath(filePath))
+	if err != nil || sc == nil {
+		return &sidecar{}, err
+	}
+	sc.Data = nil
+	return sc, nil
+}
+
+// readStagedSidecar returns the staged sidecar of an object if the object
+// file carries its stamp, i.e. the write that staged it has moved its data
+// into place but not yet committed the sidecar, or was interrupted there
+func readStagedSidecar(filePath string) (*sidecar, error) {
+	sc, err := decodeSidecar(stagedSidecarPath(filePath))
+	if err != nil || sc == nil || sc.Data == nil {
+		return nil, err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil || !sc.Data.matches(info) {
+		return nil, nil
+	}
+	sc.Data = nil
+	return sc, nil
+}
+
+// decodeSidecar reads the sidecar file at path, returning nil when there is
+// none
+func decodeSidecar(path string) (*sidecar, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read metadata sidecar: %w", err)
+	}
+
+	var sc sidecar
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("failed to decode metadata sidecar: %w", err)
+	}
+	return &sc, nil
+}
+
+// writeSidecar atomically replaces the sidecar for an object, superseding
+// any staged one. An empty sidecar removes any existing file instead.
+func writeSidecar(filePath string, sc *sidecar) error {
+	if err := removeStagedSidecar(filePath); err != nil {
+		return err
+	}
+	path := sidecarPath(filePath)
+	if sc.isZero() {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove metadata sidecar: %w", err)
+		}
+		return nil
+	}
+	return encodeSidecar(path, sc, false)
+}
+
+// stageSidecar writes sc as the staged sidecar of filePath before dataPath,
+// the object's new file, is renamed or linked there. It is stamped with
+// dataPath's size and modification time, so readers keep using the current
+// sidecar until the new file is in place, and a crash before commitSidecar
+// cannot pair the new content with the old metadata. With sync the staged
+// sidecar is made durable before the data is moved.
+func stageSidecar(filePath, dataPath string, sc *sidecar, sync bool) error {
+	info, err := os.Stat(dataPath)
+	if err != nil {
+		return fmt.Errorf("failed to get file info: %w", err)
+	}
+	staged := *sc
+	staged.Data = stampOf(info)
+	return encodeSidecar(stagedSidecarPath(filePath), &staged, sync)
+}
+
+// commitSidecar replaces the sidecar of filePath with the one staged for
+// it, whose content is sc, once the object's new file is in place
+func commitSidecar(filePath string, sc *sidecar) error {
+	if sc.isZero() {
+		if err := os.Remove(sidecarPath(filePath)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove metadata sidecar: %w", err)
+		}
+		return removeStagedSidecar(filePath)
+	}
+	if err := os.Rename(stagedSidecarPath(filePath), sidecarPath(filePath)); err != nil {
+		return fmt.Errorf("failed to rename metadata sidecar: %w", err)
+	}
+	return nil
+}
+
+// settleStagedSidecar commits a sidecar left staged by an interrupted write
+// if it describes the object file in place, and removes it otherwise. The
+// caller holds the object's lock.
+func settleStagedSidecar(filePath string) error {
+	sc, err := readStagedSidecar(filePath)
+	if err != nil {
+		return err
+	} else if sc == nil {
+		return removeStagedSidecar(filePath)
+	}
+	return commitSidecar(filePath, sc)
+}
+
+// hasStagedSidecar reports whether a sidecar is staged for filePath
+func hasStagedSidecar(filePath string) bool {
+	_, err := os.Lstat(stagedSidecarPath(filePath))
+	return err == nil
+}
+
+// removeStagedSidecar removes the staged sidecar of filePath, if any
+func removeStagedSidecar(filePath string) error {
+	if err := os.Remove(stagedSidecarPath(filePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove metadata sidecar: %w", err)
+	}
+	return nil
+}
+
+// encodeSidecar atomically writes sc to path
+func encodeSidecar(path string, sc *sidecar, sync bool) error {
+	data, err := json.Marshal(sc)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata sidecar: %w", err)
+	}
+
+	file, err := createTemp(path)
+	if err != nil {
+		return fmt.Errorf("failed to create metadata sidecar: %w", err)
+	}
+	tmpPath := file.Name()
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write metadata sidecar: %w", err)
+	}
+	if sync {
+		if err := file.Sync(); err != nil {
+			file.Close()
+			os.Remove(tmpPath)
+			return fmt.Errorf("failed to sync metadata sidecar: %w", err)
+		}
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write metadata sidecar: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to rename metadata sidecar: %w", err)
+	}
+	return nil
+}
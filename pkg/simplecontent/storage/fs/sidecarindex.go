@@ -0,0 +1,280 @@
+package fs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// sidecarIndexName names the per-directory metadata index kept in place of
+// per-object sidecars when SidecarIndex is enabled
+const sidecarIndexName = ".meta.index"
+
+// defaultSidecarIndexBatch is how many pending entries trigger a flush when
+// SidecarIndexBatch is not set
+const defaultSidecarIndexBatch = 256
+
+// indexEntry is one line of a metadata index: the sidecar of the object
+// named Name in the index's directory. Later lines replace earlier ones, and
+// an entry recording nothing removes the object's metadata.
+type indexEntry struct {
+	Name string `json:"name"`
+	sidecar
+}
+
+// sidecarIndex buffers sidecar writes and appends them to per-directory
+// index files in batches, caching what it has read of each index
+type sidecarIndex struct {
+	mu        sync.Mutex
+	batchSize int
+	pending   map[string]map[string]sidecar // Unflushed entries by directory and name
+	count     int                           // Number of pending entries
+	loaded    map[string]*dirIndex          // Index contents read so far, by directory
+}
+
+// dirIndex is the cached content of one directory's index file
+type dirIndex struct {
+	entries map[string]sidecar
+	offset  int64 // Bytes of the file applied to entries
+}
+
+func newSidecarIndex(batchSize int) *sidecarIndex {
+	if batchSize <= 0 {
+		batchSize = defaultSidecarIndexBatch
+	}
+	return &sidecarIndex{
+		batchSize: batchSize,
+		pending:   make(map[string]map[string]sidecar),
+		loaded:    make(map[string]*dirIndex),
+	}
+}
+
+// loadSidecar returns the metadata of the object at filePath. With
+// SidecarIndex, entries pending or in the directory index take precedence
+// over a per-object sidecar, which is still read for objects written before
+// the index was enabled.
+func (b *Backend) loadSidecar(filePath string) (*sidecar, error) {
+	if x := b.sidecarIndex; x != nil {
+		x.mu.Lock()
+		sc, ok, err := x.lookup(filePath)
+		x.mu.Unlock()
+		if err != nil || ok {
+			return sc, err
+		}
+	}
+	return readSidecar(filePath)
+}
+
+// storeSidecar records the metadata of the object at filePath: in its
+// sidecar file, or with SidecarIndex as a pending index entry that is
+// appended once the batch fills or FlushMetadata is called
+func (b *Backend) storeSidecar(filePath string, sc *sidecar) error {
+	x := b.sidecarIndex
+	if x == nil {
+		return writeSidecar(filePath, sc)
+	}
+
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	dir, name := filepath.Split(filePath)
+	entries := x.pending[dir]
+	if entries == nil {
+		entries = make(map[string]sidecar)
+		x.pending[dir] = entries
+	}
+	if _, ok := entries[name]; !ok {
+		x.count++
+	}
+	entries[name] = *sc
+	if x.count < x.batchSize {
+		return nil
+	}
+	return x.flush()
+}
+
+// removeSidecar deletes the metadata of a removed object
+func (b *Backend) removeSidecar(filePath string) error {
+	if b.sidecarIndex != nil {
+		if err := b.storeSidecar(filePath, &sidecar{}); err != nil {
+			return err
+		}
+	}
+	if err := os.Remove(sidecarPath(filePath)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete metadata sidecar: %w", err)
+	}
+	return removeStagedSidecar(filePath)
+}
+
+// moveSidecar moves the metadata of an object renamed from oldPath to newPath
+func (b *Backend) moveSidecar(oldPath, newPath string) error {
+	if b.sidecarIndex != nil {
+		sc, err := b.loadSidecar(oldPath)
+		if err != nil {
+			return err
+		}
+		if err := b.storeSidecar(newPath, sc); err != nil {
+			return err
+		}
+		return b.removeSidecar(oldPath)
+	}
+	for _, path := range []func(string) string{sidecarPath, stagedSidecarPath} {
+		if err := os.Rename(path(oldPath), path(newPath)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to move metadata sidecar: %w", err)
+		}
+	}
+	return nil
+}
+
+// FlushMetadata appends the metadata entries buffered by SidecarIndex to
+// their directory indexes, with a single write per directory. Metadata is
+// readable through the backend before it is flushed, but is lost if the
+// process exits first, so bulk loads should flush when they finish (and call
+// FlushAll after it with DeferSync). Without SidecarIndex it does nothing.
+func (b *Backend) FlushMetadata(ctx context.Context) (err error) {
+	defer wrapError(&err, "flush_metadata", "")
+
+	x := b.sidecarIndex
+	if x == nil {
+		return nil
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	return x.flush()
+}
+
+// flush appends every pending entry to its directory index. Directories
+// that fail keep their entries for the next flush. x.mu must be held.
+func (x *sidecarIndex) flush() error {
+	var errs []error
+	for dir, entries := range x.pending {
+		if err := appendIndex(dir, entries); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		x.count -= len(entries)
+		delete(x.pending, dir)
+	}
+	return errors.Join(errs...)
+}
+
+// appendIndex writes entries to the end of a directory's index file
+func appendIndex(dir string, entries map[string]sidecar) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for name, sc := range entries {
+		if err := enc.Encode(indexEntry{Name: name, sidecar: sc}); err != nil {
+			return fmt.Errorf("failed to encode metadata index entry: %w", err)
+		}
+	}
+
+	file, err := os.OpenFile(filepath.Join(dir, sidecarIndexName), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if os.IsNotExist(err) {
+		// The directory was removed with the objects the entries describe
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to open metadata index: %w", err)
+	}
+	if _, err := file.Write(buf.Bytes()); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write metadata index: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write metadata index: %w", err)
+	}
+	return nil
+}
+
+// lookup finds the indexed metadata of the object at filePath, reporting
+// false when neither a pending entry nor the directory index has one.
+// x.mu must be held.
+func (x *sidecarIndex) lookup(filePath string) (*sidecar, bool, error) {
+	dir, name := filepath.Split(filePath)
+	if sc, ok := x.pending[dir][name]; ok {
+		return &sc, true, nil
+	}
+
+	idx, err := x.load(dir)
+	if err != nil {
+		return nil, false, err
+	}
+	sc, ok := idx.entries[name]
+	return &sc, ok, nil
+}
+
+// load brings the cached index of dir up to date with its file, reading only
+// what was appended since the last load unless the file was replaced or
+// removed. x.mu must be held.
+func (x *sidecarIndex) load(dir string) (*dirIndex, error) {
+	idx := x.loaded[dir]
+	if idx == nil {
+		idx = &dirIndex{entries: make(map[string]sidecar)}
+		x.loaded[dir] = idx
+	}
+
+	file, err := os.Open(filepath.Join(dir, sidecarIndexName))
+	if os.IsNotExist(err) {
+		*idx = dirIndex{entries: make(map[string]sidecar)}
+		return idx, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read metadata index: %w", err)
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read metadata index: %w", err)
+	}
+	if info.Size() < idx.offset {
+		*idx = dirIndex{entries: make(map[string]sidecar)}
+	}
+	if info.Size() == idx.offset {
+		return idx, nil
+	}
+	if _, err := file.Seek(idx.offset, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read metadata index: %w", err)
+	}
+
+	// Apply complete lines only; a partial last line is being appended by
+	// another process and is read once it is finished
+	r := bufio.NewReader(file)
+	for {
+		line, err := r.ReadBytes('\n')
+		if err == io.EOF {
+			return idx, nil
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read metadata index: %w", err)
+		}
+		idx.offset += int64(len(line))
+
+		var entry indexEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata index: %w", err)
+		}
+		idx.entries[entry.Name] = entry.sidecar
+	}
+}
+
+// dropIndex removes the index of a directory whose objects are all gone,
+// with any entries pending for it, so the directory can be removed
+func (b *Backend) dropIndex(dir string) {
+	x := b.sidecarIndex
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	os.Remove(filepath.Join(dir, sidecarIndexName))
+	dir += string(filepath.Separator)
+	x.count -= len(x.pending[dir])
+	delete(x.pending, dir)
+	delete(x.loaded, dir)
+}
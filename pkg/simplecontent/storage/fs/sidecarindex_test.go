@@ -0,0 +1,125 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func newIndexedBackend(t *testing.T, dir string, batch int) *Backend {
+	t.Helper()
+	b, err := New(Config{BaseDir: dir, SidecarIndex: true, SidecarIndexBatch: batch})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	return b.(*Backend)
+}
+
+func TestFSBackend_SidecarIndex(t *testing.T) {
+	dir := t.TempDir()
+	backend := newIndexedBackend(t, dir, 100)
+	ctx := context.Background()
+
+	for _, key := range []string{"docs/a.json", "docs/b.json", "docs/c.json"} {
+		params := simplecontent.UploadParams{ObjectKey: key, MimeType: "application/json"}
+		if err := backend.UploadWithParams(ctx, strings.NewReader(`{}`), params); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+
+	// Nothing is written per object, and pending metadata is already visible
+	indexPath := filepath.Join(dir, "docs", sidecarIndexName)
+	if _, err := os.Stat(sidecarPath(mustObjectPath(t, backend, "docs/a.json"))); !os.IsNotExist(err) {
+		t.Fatalf("expected no per-object sidecar, got %v", err)
+	}
+	if _, err := os.Stat(indexPath); !os.IsNotExist(err) {
+		t.Fatalf("expected no index before flushing, got %v", err)
+	}
+	meta, err := backend.GetObjectMeta(ctx, "docs/b.json")
+	if err != nil || meta.ContentType != "application/json" {
+		t.Fatalf("unexpected metadata before flush: %+v, %v", meta, err)
+	}
+
+	if err := backend.FlushMetadata(ctx); err != nil {
+		t.Fatalf("flush metadata: %v", err)
+	}
+	data, err := os.ReadFile(indexPath)
+	if err != nil {
+		t.Fatalf("read index: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 3 {
+		t.Fatalf("expected 3 index entries, got %d", lines)
+	}
+	if got := listKeys(t, backend, "docs/"); !reflect.DeepEqual(got, []string{"docs/a.json", "docs/b.json", "docs/c.json"}) {
+		t.Fatalf("index listed as an object: %v", got)
+	}
+
+	// Another backend reads the flushed index, including later appends
+	reader := newIndexedBackend(t, dir, 100)
+	meta, err = reader.GetObjectMeta(ctx, "docs/c.json")
+	if err != nil || meta.ContentType != "application/json" {
+		t.Fatalf("unexpected metadata from index: %+v, %v", meta, err)
+	}
+	params := simplecontent.UploadParams{ObjectKey: "docs/a.json", MimeType: "text/plain"}
+	if err := backend.UploadWithParams(ctx, strings.NewReader("plain"), params); err != nil {
+		t.Fatalf("replace: %v", err)
+	}
+	if err := backend.FlushMetadata(ctx); err != nil {
+		t.Fatalf("flush metadata: %v", err)
+	}
+	if meta, err = reader.GetObjectMeta(ctx, "docs/a.json"); err != nil || meta.ContentType != "text/plain" {
+		t.Fatalf("expected appended entry to win: %+v, %v", meta, err)
+	}
+
+	// Deleting every object removes the index with the directory
+	for _, key := range []string{"docs/a.json", "docs/b.json", "docs/c.json"} {
+		if err := backend.Delete(ctx, key); err != nil {
+			t.Fatalf("delete %s: %v", key, err)
+		}
+	}
+	if err := backend.FlushMetadata(ctx); err != nil {
+		t.Fatalf("flush metadata: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "docs")); !os.IsNotExist(err) {
+		t.Fatalf("expected docs directory to be removed, got %v", err)
+	}
+}
+
+func TestFSBackend_SidecarIndexBatch(t *testing.T) {
+	dir := t.TempDir()
+	backend := newIndexedBackend(t, dir, 2)
+	ctx := context.Background()
+
+	for _, key := range []string{"a.txt", "b.txt"} {
+		params := simplecontent.UploadParams{ObjectKey: key, MimeType: "text/plain"}
+		if err := backend.UploadWithParams(ctx, strings.NewReader(key), params); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, sidecarIndexName)); err != nil {
+		t.Fatalf("expected a full batch to be flushed: %v", err)
+	}
+}
+
+func TestFSBackend_SidecarIndexReadsOldSidecars(t *testing.T) {
+	dir := t.TempDir()
+	b, err := New(Config{BaseDir: dir})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	params := simplecontent.UploadParams{ObjectKey: "old.bin", MimeType: "application/x-old"}
+	if err := b.UploadWithParams(context.Background(), strings.NewReader("old"), params); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	backend := newIndexedBackend(t, dir, 0)
+	meta, err := backend.GetObjectMeta(context.Background(), "old.bin")
+	if err != nil || meta.ContentType != "application/x-old" {
+		t.Fatalf("expected sidecar metadata without an index entry: %+v, %v", meta, err)
+	}
+}
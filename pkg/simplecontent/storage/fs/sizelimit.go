@@ -0,0 +1,134 @@
+package fs
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/mimetype"
+)
+
+// MaxObjectSize returns the largest upload accepted for contentType, in
+// bytes, from MaxSizeByContentType or else MaxObjectSize, or 0 when uploads
+// of that type are unlimited. As an undeclared type is detected from the
+// upload, an empty contentType gives the largest limit of any type. Callers
+// such as HTTP handlers can use it to reject a declared Content-Length
+// before reading the body.
+func (b *Backend) MaxObjectSize(contentType string) int64 {
+	if contentType == "" && len(b.maxSizes) > 0 {
+		if b.maxSize <= 0 {
+			return 0
+		}
+		limit := b.maxSize
+		for _, l := range b.maxSizes {
+			limit = max(limit, l)
+		}
+		return limit
+	}
+	if l := matchContentType(b.maxSizes, contentType); l > 0 {
+		return l
+	}
+	if b.maxSize > 0 {
+		return b.maxSize
+	}
+	return 0
+}
+
+// limitSize applies the size limit for an upload's content type, from
+// MaxSizeByContentType or else MaxObjectSize. It returns the reader to
+// upload from, which reads at most one byte past the limit, and verify
+// extended to reject uploads over it. Undeclared content types are detected
+// from the leading bytes, which are read ahead and replayed.
+func (b *Backend) limitSize(reader io.Reader, params simplecontent.UploadParams, verify func(written int64, sum string) error) (io.Reader, func(written int64, sum string) error, error) {
+	if len(b.maxSizes) == 0 && b.maxSize <= 0 {
+		return reader, verify, nil
+	}
+
+	contentType := params.MimeType
+	if contentType == "" && len(b.maxSizes) > 0 {
+		head := make([]byte, mimetype.SniffLen)
+		n, err := io.ReadFull(reader, head)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, nil, fmt.Errorf("%w: %w", simplecontent.ErrSourceRead, err)
+		}
+		head = head[:n]
+		contentType = detectContentType(params.ObjectKey, head)
+		reader = io.MultiReader(bytes.NewReader(head), reader)
+	}
+
+	limit := b.MaxObjectSize(contentType)
+	if limit <= 0 {
+		return reader, verify, nil
+	}
+	if params.Size > limit {
+		return nil, nil, &simplecontent.ObjectTooLargeError{Limit: limit, ContentType: contentType, Size: params.Size}
+	}
+
+	next := verify
+	verify = func(written int64, sum string) error {
+		if written > limit {
+			return &simplecontent.ObjectTooLargeError{Limit: limit, ContentType: contentType}
+		}
+		if next != nil {
+			return next(written, sum)
+		}
+		return nil
+	}
+	return io.LimitReader(reader, limit+1), verify, nil
+}
+
+// limitPart returns r cut off with an ObjectTooLargeError once it and the
+// other parts already stored in dir hold more than an object may. The
+// content type of a multipart upload is only known once it is complete, so
+// the largest limit of any type applies.
+func (b *Backend) limitPart(r io.Reader, dir string, partNumber int) (io.Reader, error) {
+	limit := b.MaxObjectSize("")
+	if limit <= 0 {
+		return r, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	remaining := limit
+	for _, entry := range entries {
+		name, ok := strings.CutPrefix(entry.Name(), partPrefix)
+		if !ok || isTempName(entry.Name()) || name == strconv.Itoa(partNumber) {
+			continue
+		}
+		info, err := entry.Info()
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to get file info: %w", err)
+		}
+		remaining -= info.Size()
+	}
+	if remaining < 0 {
+		return nil, &simplecontent.ObjectTooLargeError{Limit: limit, Size: limit - remaining}
+	}
+	return &sizeLimitReader{r: r, n: remaining, limit: limit}, nil
+}
+
+// sizeLimitReader reads from r until more than n bytes have been read, then
+// fails with an ObjectTooLargeError for limit
+type sizeLimitReader struct {
+	r     io.Reader
+	n     int64 // Bytes left before the limit is exceeded
+	limit int64
+}
+
+func (l *sizeLimitReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.n+1 {
+		p = p[:l.n+1]
+	}
+	n, err := l.r.Read(p)
+	if l.n -= int64(n); l.n < 0 {
+		return n, &simplecontent.ObjectTooLargeError{Limit: l.limit}
+	}
+	return n, err
+}
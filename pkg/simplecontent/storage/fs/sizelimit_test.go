@@ -0,0 +1,253 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_MaxSizeByContentType(t *testing.T) {
+	b, err := New(Config{
+		BaseDir:              t.TempDir(),
+		MaxObjectSize:        32,
+		MaxSizeByContentType: map[string]int64{"image/*": 64, "text/plain": 8},
+	})
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	upload := func(key, mimeType string, content []byte) error {
+		return backend.UploadWithParams(ctx, bytes.NewReader(content), simplecontent.UploadParams{ObjectKey: key, MimeType: mimeType})
+	}
+
+	png := append([]byte("\x89PNG\r\n\x1a\n"), bytes.Repeat([]byte{0}, 40)...)
+	if err := upload("img/a.png", "", png); err != nil {
+		t.Fatalf("expected sniffed image within its limit, got %v", err)
+	}
+	if got := readObject(t, backend, "img/a.png"); got != string(png) {
+		t.Fatalf("expected sniffed bytes to be kept, got %d bytes", len(got))
+	}
+
+	cases := []struct {
+		key, mimeType string
+		size          int
+	}{
+		{"img/b.png", "image/png", 65},
+		{"docs/a.txt", "text/plain", 9},
+		{"data/a.bin", "application/octet-stream", 33},
+	}
+	for _, c := range cases {
+		err := upload(c.key, c.mimeType, bytes.Repeat([]byte("x"), c.size))
+		if !errors.Is(err, simplecontent.ErrObjectTooLarge) {
+			t.Fatalf("%s: expected ErrObjectTooLarge, got %v", c.key, err)
+		}
+		if _, err := os.Stat(mustObjectPath(t, backend, c.key)); !os.IsNotExist(err) {
+			t.Fatalf("%s: expected rejected upload to publish nothing", c.key)
+		}
+	}
+
+	err = backend.UploadWithParams(ctx, strings.NewReader("short"), simplecontent.UploadParams{ObjectKey: "docs/b.txt", MimeType: "text/plain", Size: 100})
+	if !errors.Is(err, simplecontent.ErrObjectTooLarge) {
+		t.Fatalf("expected declared size over the limit to fail fast, got %v", err)
+	}
+	if err := upload("data/b.bin", "application/octet-stream", bytes.Repeat([]byte("x"), 32)); err != nil {
+		t.Fatalf("expected upload at the global limit, got %v", err)
+	}
+}
+
+func TestFSBackend_ObjectTooLargeError(t *testing.T) {
+	b, err := New(Config{
+		BaseDir:              t.TempDir(),
+		MaxObjectSize:        16,
+		MaxSizeByContentType: map[string]int64{"image/*": 64},
+	})
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	backend := b.(*Backend)
+
+	for contentType, want := range map[string]int64{"image/png": 64, "text/plain": 16, "": 64} {
+		if got := backend.MaxObjectSize(contentType); got != want {
+			t.Fatalf("MaxObjectSize(%q) = %d, want %d", contentType, got, want)
+		}
+	}
+
+	params := simplecontent.UploadParams{ObjectKey: "big.txt", MimeType: "text/plain"}
+	err = backend.UploadWithParams(context.Background(), strings.NewReader(strings.Repeat("x", 17)), params)
+	var tooLarge *simplecontent.ObjectTooLargeError
+	if !errors.As(err, &tooLarge) || !errors.Is(err, simplecontent.ErrObjectTooLarge) {
+		t.Fatalf("expected ObjectTooLargeError, got %v", err)
+	}
+	if tooLarge.Limit != 16 || tooLarge.ContentType != "text/plain" {
+		t.Fatalf("unexpected error details: %+v", tooLarge)
+	}
+	if _, err := os.Stat(mustObjectPath(t, backend, "big.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected partial upload to be removed, got %v", err)
+	}
+}
+
+func TestFSBackend_ObjectTooLargeErrorWithoutContentType(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), MaxObjectSize: 16})
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+
+	err = b.Upload(context.Background(), "big.bin", strings.NewReader(strings.Repeat("x", 17)))
+	var tooLarge *simplecontent.ObjectTooLargeError
+	if !errors.As(err, &tooLarge) || tooLarge.ContentType != "" {
+		t.Fatalf("expected ObjectTooLargeError without a content type, got %v", err)
+	}
+	if want := "the 16 byte limit"; !strings.HasSuffix(tooLarge.Error(), want) {
+		t.Fatalf("expected message ending in %q, got %q", want, tooLarge.Error())
+	}
+
+	declared := &simplecontent.ObjectTooLargeError{Limit: 16, Size: 17}
+	if want := "17 bytes exceeds the 16 byte limit"; !strings.HasSuffix(declared.Error(), want) {
+		t.Fatalf("expected message ending in %q, got %q", want, declared.Error())
+	}
+}
+
+// writeEntryPoints returns a function for each method that writes an object,
+// uploading r to key through it
+func writeEntryPoints(ctx context.Context) map[string]func(b *Backend, key string, r io.Reader) error {
+	return map[string]func(b *Backend, key string, r io.Reader) error{
+		"Upload": func(b *Backend, key string, r io.Reader) error {
+			return b.Upload(ctx, key, r)
+		},
+		"UploadTee": func(b *Backend, key string, r io.Reader) error {
+			_, err := b.UploadTee(ctx, key, r, io.Discard)
+			return err
+		},
+		"UploadIfChanged": func(b *Backend, key string, r io.Reader) error {
+			_, err := b.UploadIfChanged(ctx, key, r)
+			return err
+		},
+		"UploadWithDerived": func(b *Backend, key string, r io.Reader) error {
+			_, err := b.UploadWithDerived(ctx, key, r, func(io.Reader) (map[string]io.Reader, error) {
+				return nil, nil
+			})
+			return err
+		},
+		"CommitBatch": func(b *Backend, key string, r io.Reader) error {
+			batch := b.NewBatch()
+			if err := b.StageBatch(ctx, batch, key, r); err != nil {
+				b.RollbackBatch(batch)
+				return err
+			}
+			return b.CommitBatch(ctx, batch)
+		},
+		"CompleteMultipart": func(b *Backend, key string, r io.Reader) error {
+			uploadID, err := b.InitiateMultipart(ctx, key)
+			if err != nil {
+				return err
+			}
+			if err := b.UploadPart(ctx, uploadID, 1, r); err != nil {
+				b.AbortMultipart(ctx, uploadID)
+				return err
+			}
+			return b.CompleteMultipart(ctx, uploadID)
+		},
+	}
+}
+
+func TestFSBackend_MaxObjectSizeEveryWriter(t *testing.T) {
+	ctx := context.Background()
+	for name, write := range writeEntryPoints(ctx) {
+		t.Run(name, func(t *testing.T) {
+			b, err := New(Config{BaseDir: t.TempDir(), MaxObjectSize: 10})
+			if err != nil {
+				t.Fatalf("new backend: %v", err)
+			}
+			backend := b.(*Backend)
+
+			err = write(backend, "big.bin", strings.NewReader(strings.Repeat("x", 100)))
+			if !errors.Is(err, simplecontent.ErrObjectTooLarge) {
+				t.Fatalf("expected ErrObjectTooLarge, got %v", err)
+			}
+			if ok, err := backend.Exists(ctx, "big.bin"); err != nil || ok {
+				t.Fatalf("expected rejected write to store nothing, got %v, %v", ok, err)
+			}
+			if err := write(backend, "small.bin", strings.NewReader("0123456789")); err != nil {
+				t.Fatalf("expected write at the limit, got %v", err)
+			}
+		})
+	}
+}
+
+func TestFSBackend_MaxObjectSizeDerivedAndParts(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), MaxObjectSize: 10})
+	if err != nil {
+		t.Fatalf("new backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	_, err = backend.UploadWithDerived(ctx, "a.bin", strings.NewReader("small"), func(io.Reader) (map[string]io.Reader, error) {
+		return map[string]io.Reader{"a-big.bin": strings.NewReader(strings.Repeat("x", 100))}, nil
+	})
+	if !errors.Is(err, simplecontent.ErrObjectTooLarge) {
+		t.Fatalf("expected oversized variant to fail, got %v", err)
+	}
+	for _, key := range []string{"a.bin", "a-big.bin"} {
+		if ok, _ := backend.Exists(ctx, key); ok {
+			t.Fatalf("expected nothing published, found %s", key)
+		}
+	}
+
+	uploadID, err := backend.InitiateMultipart(ctx, "parts.bin")
+	if err != nil {
+		t.Fatalf("initiate: %v", err)
+	}
+	if err := backend.UploadPart(ctx, uploadID, 1, strings.NewReader("012345")); err != nil {
+		t.Fatalf("first part: %v", err)
+	}
+	err = backend.UploadPart(ctx, uploadID, 2, strings.NewReader("6789ab"))
+	if !errors.Is(err, simplecontent.ErrObjectTooLarge) {
+		t.Fatalf("expected parts over the limit to fail, got %v", err)
+	}
+	if err := backend.UploadPart(ctx, uploadID, 1, strings.NewReader("0123")); err != nil {
+		t.Fatalf("expected a replaced part not to count twice, got %v", err)
+	}
+	if err := backend.UploadPart(ctx, uploadID, 2, strings.NewReader("456789")); err != nil {
+		t.Fatalf("second part: %v", err)
+	}
+	if err := backend.CompleteMultipart(ctx, uploadID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	if got := readObject(t, backend, "parts.bin"); got != "0123456789" {
+		t.Fatalf("unexpected content %q", got)
+	}
+}
+
+func TestFSBackend_MaxSizeByContentTypeEveryWriter(t *testing.T) {
+	ctx := context.Background()
+	for name, write := range writeEntryPoints(ctx) {
+		t.Run(name, func(t *testing.T) {
+			b, err := New(Config{BaseDir: t.TempDir(), MaxSizeByContentType: map[string]int64{"text/plain": 8}})
+			if err != nil {
+				t.Fatalf("new backend: %v", err)
+			}
+			backend := b.(*Backend)
+
+			content := strings.Repeat("x", 20)
+			err = write(backend, "notes.txt", strings.NewReader(content))
+			if !errors.Is(err, simplecontent.ErrObjectTooLarge) {
+				t.Fatalf("expected ErrObjectTooLarge, got %v", err)
+			}
+			if ok, err := backend.Exists(ctx, "notes.txt"); err != nil || ok {
+				t.Fatalf("expected rejected write to store nothing, got %v, %v", ok, err)
+			}
+			if err := write(backend, "data.bin", strings.NewReader(content)); err != nil {
+				t.Fatalf("expected other types to be unlimited, got %v", err)
+			}
+		})
+	}
+}
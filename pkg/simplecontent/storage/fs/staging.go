@@ -0,0 +1,232 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// stagingPath returns the path a staged upload of filePath is created next
+// to: filePath itself, or its name inside StagingDir
+func (b *Backend) stagingPath(filePath string) string {
+	if b.stagingDir == "" {
+		return filePath
+	}
+	return filepath.Join(b.stagingDir, filepath.Base(filePath))
+}
+
+// checkStagingDir creates the staging directory and reports whether files
+// can be renamed from it into baseDir, i.e. whether both are on one
+// filesystem. When they are not, staged uploads are copied next to their
+// object before being committed.
+func checkStagingDir(stagingDir, baseDir string) (sameDevice bool, err error) {
+	if err := os.MkdirAll(stagingDir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	file, err := createTemp(filepath.Join(stagingDir, ".staging-probe"))
+	if err != nil {
+		return false, fmt.Errorf("staging directory %s is not writable: %w", stagingDir, err)
+	}
+	file.Close()
+
+	dst := tempName(filepath.Join(baseDir, ".staging-probe"))
+	if err := os.Rename(file.Name(), dst); isCrossDevice(err) {
+		os.Remove(file.Name())
+		return false, nil
+	} else if err != nil {
+		os.Remove(file.Name())
+		return false, fmt.Errorf("staging directory %s cannot be committed from into %s: %w", stagingDir, baseDir, err)
+	}
+	os.Remove(dst)
+	return true, nil
+}
+
+// copyStaged copies a file staged on another filesystem than filePath to a
+// temporary file next to filePath, from which it can be renamed into place,
+// and removes the staged file. It returns the path of the copy.
+func copyStaged(tmpPath, filePath string, sync bool) (string, error) {
+	defer os.Remove(tmpPath)
+
+	src, err := os.Open(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to open staged file: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := createTemp(filePath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create file: %w", err)
+	}
+	fail := func(err error) (string, error) {
+		dst.Close()
+		os.Remove(dst.Name())
+		return "", err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		return fail(fmt.Errorf("failed to copy staged file: %w", err))
+	}
+	if sync {
+		if err := dst.Sync(); err != nil {
+			return fail(fmt.Errorf("failed to sync file: %w", err))
+		}
+	}
+	if err := dst.Close(); err != nil {
+		os.Remove(dst.Name())
+		return "", fmt.Errorf("failed to write file: %w", err)
+	}
+	return dst.Name(), nil
+}
+
+// SweepStaging removes every file left in StagingDir and returns how many
+// were removed. Staged files only survive a crash or a batch that was never
+// committed or rolled back, so at startup, before uploads begin, everything
+// in StagingDir is incomplete. Without StagingDir it does nothing.
+func (b *Backend) SweepStaging() (_ int, err error) {
+	defer wrapError(&err, "sweep_staging", "")
+
+	if err := b.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	if b.stagingDir == "" {
+		return 0, nil
+	}
+	entries, err := os.ReadDir(b.stagingDir)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read staging directory: %w", err)
+	}
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(b.stagingDir, entry.Name())); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to remove staged file: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// Batch is a set of uploads staged together and then published with
+// CommitBatch or discarded with RollbackBatch. Nothing staged in a batch is
+// visible until it is committed. A Batch is safe for concurrent use.
+type Batch struct {
+	mu     sync.Mutex
+	staged []*stagedObject
+	keys   []string
+	done   bool
+}
+
+// NewBatch starts an empty batch
+func (b *Backend) NewBatch() *Batch {
+	return &Batch{}
+}
+
+// StageBatch writes an object into the batch without publishing it. Staged
+// files live in StagingDir when it is configured on BaseDir's filesystem,
+// and otherwise next to their object. Staging a key twice keeps the later
+// content.
+func (b *Backend) StageBatch(ctx context.Context, batch *Batch, objectKey string, reader io.Reader) (err error) {
+	defer wrapError(&err, "stage_batch", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	filePath, err := b.prepareWrite(objectKey, b.onCollision)
+	if err != nil {
+		return err
+	}
+	staged, err := b.stageObject(filePath, reader, simplecontent.UploadParams{ObjectKey: objectKey}, nil)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		staged.discard()
+		return err
+	}
+
+	batch.mu.Lock()
+	defer batch.mu.Unlock()
+	if batch.done {
+		staged.discard()
+		return errors.New("batch already committed or rolled back")
+	}
+	for i, key := range batch.keys {
+		if key == objectKey {
+			batch.staged[i].discard()
+			batch.staged[i] = staged
+			return nil
+		}
+	}
+	batch.staged = append(batch.staged, staged)
+	batch.keys = append(batch.keys, objectKey)
+	return nil
+}
+
+// CommitBatch publishes every object staged in the batch, in staging order.
+// Each object is replaced atomically, but the batch as a whole is not: if a
+// commit fails, objects committed before it stay published and the rest are
+// discarded. OnKeyCollision applies to each object as it does to Upload.
+// Batches can only be committed once.
+func (b *Backend) CommitBatch(ctx context.Context, batch *Batch) (err error) {
+	defer wrapError(&err, "commit_batch", "")
+
+	if err := b.checkWritable(); err != nil {
+		return err
+	}
+
+	staged, keys, err := batch.finish()
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		discardStaged(staged)
+		return err
+	}
+	for i, s := range staged {
+		if _, err := b.commitKey(s, keys[i], b.onCollision); err != nil {
+			discardStaged(staged[i+1:])
+			return err
+		}
+	}
+	return nil
+}
+
+// RollbackBatch discards every object staged in the batch
+func (b *Backend) RollbackBatch(batch *Batch) (err error) {
+	defer wrapError(&err, "rollback_batch", "")
+
+	staged, _, err := batch.finish()
+	if err != nil {
+		return err
+	}
+	discardStaged(staged)
+	return nil
+}
+
+// finish closes the batch to further staging and returns its staged objects
+// and their keys
+func (t *Batch) finish() ([]*stagedObject, []string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return nil, nil, errors.New("batch already committed or rolled back")
+	}
+	t.done = true
+	return t.staged, t.keys, nil
+}
+
+// discardStaged removes the files of staged objects
+func discardStaged(staged []*stagedObject) {
+	for _, s := range staged {
+		s.discard()
+	}
+}
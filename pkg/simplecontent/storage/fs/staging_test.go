@@ -0,0 +1,120 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFSBackend_StagingDir(t *testing.T) {
+	dir := t.TempDir()
+	b, err := New(Config{BaseDir: dir, StagingDir: "incoming"})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+	stagingDir := filepath.Join(dir, "incoming")
+
+	batch := backend.NewBatch()
+	for _, key := range []string{"import/a", "import/b", "import/a"} {
+		if err := backend.StageBatch(ctx, batch, key, strings.NewReader("content of "+key)); err != nil {
+			t.Fatalf("stage %s: %v", key, err)
+		}
+	}
+	if entries, _ := os.ReadDir(stagingDir); len(entries) != 2 {
+		t.Fatalf("expected 2 staged files in the staging directory, got %d", len(entries))
+	}
+	if keys := listKeys(t, backend, ""); len(keys) != 0 {
+		t.Fatalf("expected nothing visible before commit, got %v", keys)
+	}
+
+	if err := backend.CommitBatch(ctx, batch); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+	if keys := listKeys(t, backend, ""); len(keys) != 2 {
+		t.Fatalf("expected committed objects listed without the staging directory, got %v", keys)
+	}
+	if got := readObject(t, backend, "import/a"); got != "content of import/a" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	if err := backend.CommitBatch(ctx, batch); err == nil {
+		t.Fatalf("expected second commit to fail")
+	}
+
+	rollback := backend.NewBatch()
+	if err := backend.StageBatch(ctx, rollback, "import/c", strings.NewReader("discard me")); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := backend.RollbackBatch(rollback); err != nil {
+		t.Fatalf("rollback: %v", err)
+	}
+	if entries, _ := os.ReadDir(stagingDir); len(entries) != 0 {
+		t.Fatalf("expected staging directory empty after rollback, got %d entries", len(entries))
+	}
+
+	// Plain uploads stage there too; leftovers of a crash are swept
+	if err := backend.Upload(ctx, "import/d", strings.NewReader("d")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	abandoned := backend.NewBatch()
+	if err := backend.StageBatch(ctx, abandoned, "import/e", strings.NewReader("never committed")); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if n, err := backend.SweepStaging(); err != nil || n != 1 {
+		t.Fatalf("expected 1 file swept, got %d: %v", n, err)
+	}
+	if _, err := os.Stat(mustObjectPath(t, backend, "import/e")); !os.IsNotExist(err) {
+		t.Fatalf("expected swept upload not to be published")
+	}
+}
+
+func TestFSBackend_StagingDirOtherFilesystem(t *testing.T) {
+	stagingDir, err := os.MkdirTemp("/dev/shm", "staging-")
+	if err != nil {
+		t.Skipf("no tmpfs to stage on: %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(stagingDir) })
+
+	b, err := New(Config{BaseDir: t.TempDir(), StagingDir: stagingDir, FileMode: 0640})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	if !backend.stagingCopy {
+		t.Skip("staging directory is on the same filesystem as the base directory")
+	}
+	ctx := context.Background()
+
+	if err := backend.Upload(ctx, "import/a", strings.NewReader("content of a")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	batch := backend.NewBatch()
+	if err := backend.StageBatch(ctx, batch, "import/b", strings.NewReader("content of b")); err != nil {
+		t.Fatalf("stage: %v", err)
+	}
+	if err := backend.CommitBatch(ctx, batch); err != nil {
+		t.Fatalf("commit: %v", err)
+	}
+
+	for _, key := range []string{"import/a", "import/b"} {
+		if got := readObject(t, backend, key); got != "content of "+key[len("import/"):] {
+			t.Fatalf("unexpected content of %s: %q", key, got)
+		}
+	}
+	info, err := os.Stat(mustObjectPath(t, backend, "import/a"))
+	if err != nil || info.Mode().Perm() != 0640 {
+		t.Fatalf("expected the copied object to take FileMode, got %v, %v", info, err)
+	}
+	if entries, _ := os.ReadDir(stagingDir); len(entries) != 0 {
+		t.Fatalf("expected the staging directory empty after commit, got %d entries", len(entries))
+	}
+	entries, _ := os.ReadDir(filepath.Dir(mustObjectPath(t, backend, "import/a")))
+	for _, entry := range entries {
+		if isTempName(entry.Name()) {
+			t.Fatalf("unexpected temporary file %s left next to the objects", entry.Name())
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_StatObject(t *testing.T) {
+	b := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+	content := strings.Repeat("<html>stat</html>", 100)
+	if err := b.Upload(ctx, "page", strings.NewReader(content)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := b.UploadWithParams(ctx, strings.NewReader("a,b"), simplecontent.UploadParams{
+		ObjectKey: "data.csv",
+		MimeType:  "text/csv",
+		Metadata:  map[string]string{"owner": "ops"},
+	}); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	meta, err := b.StatObject(ctx, "page")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	full, err := b.GetObjectMeta(ctx, "page")
+	if err != nil {
+		t.Fatalf("get object meta: %v", err)
+	}
+	if meta.Key != "page" || meta.Size != int64(len(content)) || !meta.UpdatedAt.Equal(full.UpdatedAt) || meta.ETag != full.ETag {
+		t.Fatalf("unexpected stat %+v, object meta %+v", meta, full)
+	}
+	if meta.ContentType != "" || full.ContentType == "" {
+		t.Fatalf("expected only GetObjectMeta to detect the type, got %q and %q", meta.ContentType, full.ContentType)
+	}
+
+	meta, err = b.StatObject(ctx, "data.csv")
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if meta.ContentType != "text/csv" || meta.Metadata["owner"] != "ops" {
+		t.Fatalf("expected the declared type and user metadata, got %+v", meta)
+	}
+
+	if _, err := b.StatObject(ctx, "missing"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected ErrObjectNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// cachedStats is a Stats result and when it stops being reused
+type cachedStats struct {
+	stats   simplecontent.StorageStats
+	expires time.Time
+}
+
+// Stats aggregates the objects whose key starts with prefix, as List counts
+// them, from a single directory walk. Sizes and content types come from
+// sidecars, so objects are never opened; objects uploaded without a content
+// type count under "". Oldest and Newest are modification times.
+//
+// With StatsCacheTTL set, a result is reused for that long for the same
+// prefix, so writes since the walk may be missing.
+func (b *Backend) Stats(ctx context.Context, prefix string) (_ simplecontent.StorageStats, err error) {
+	defer wrapError(&err, "stats", prefix)
+
+	if b.statsTTL > 0 {
+		b.statsMu.Lock()
+		cached, ok := b.statsCache[prefix]
+		b.statsMu.Unlock()
+		if ok && time.Now().Before(cached.expires) {
+			return cloneStats(cached.stats), nil
+		}
+	}
+
+	var stats simplecontent.StorageStats
+	err = b.walk(ctx, prefix, func(meta simplecontent.ObjectMeta) error {
+		stats.Add(meta)
+		return nil
+	})
+	if err != nil {
+		return simplecontent.StorageStats{}, err
+	}
+
+	if b.statsTTL > 0 {
+		b.statsMu.Lock()
+		now := time.Now()
+		for key, cached := range b.statsCache {
+			if !now.Before(cached.expires) {
+				delete(b.statsCache, key)
+			}
+		}
+		b.statsCache[prefix] = cachedStats{stats: cloneStats(stats), expires: now.Add(b.statsTTL)}
+		b.statsMu.Unlock()
+	}
+	return stats, nil
+}
+
+// cloneStats copies stats so callers cannot modify a cached result
+func cloneStats(stats simplecontent.StorageStats) simplecontent.StorageStats {
+	stats.ByContentType = maps.Clone(stats.ByContentType)
+	return stats
+}
@@ -0,0 +1,85 @@
+package fs
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_Stats(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), StatsCacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	uploads := []simplecontent.UploadParams{
+		{ObjectKey: "docs/a.txt", MimeType: "text/plain"},
+		{ObjectKey: "docs/b.txt", MimeType: "text/plain"},
+		{ObjectKey: "docs/c.json", MimeType: "application/json"},
+		{ObjectKey: "docs/raw"},
+		{ObjectKey: "other/d.txt", MimeType: "text/plain"},
+	}
+	for _, params := range uploads {
+		if err := backend.UploadWithParams(ctx, strings.NewReader(params.ObjectKey), params); err != nil {
+			t.Fatalf("upload %s: %v", params.ObjectKey, err)
+		}
+	}
+	oldest, newest := time.Now().Add(-time.Hour), time.Now().Add(-time.Minute)
+	if err := os.Chtimes(mustObjectPath(t, backend, "docs/a.txt"), oldest, oldest); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := os.Chtimes(mustObjectPath(t, backend, "docs/raw"), newest, newest); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	if err := backend.Reserve(ctx, "docs/pending.txt", time.Minute); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+
+	stats, err := backend.Stats(ctx, "docs/")
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if stats.Objects != 4 || stats.Bytes != 10+10+11+8 {
+		t.Fatalf("unexpected totals: %+v", stats)
+	}
+	want := map[string]simplecontent.ContentTypeStats{
+		"text/plain":       {Objects: 2, Bytes: 20},
+		"application/json": {Objects: 1, Bytes: 11},
+		"":                 {Objects: 1, Bytes: 8},
+	}
+	for contentType, counts := range want {
+		if got := stats.ByContentType[contentType]; got != counts {
+			t.Fatalf("expected %+v for %q, got %+v", counts, contentType, got)
+		}
+	}
+	if !stats.Oldest.Equal(oldest) {
+		t.Fatalf("expected oldest %v, got %v", oldest, stats.Oldest)
+	}
+	if stats.Newest.Before(newest) || stats.Newest.Equal(oldest) {
+		t.Fatalf("unexpected newest %v", stats.Newest)
+	}
+
+	// Served from the cache until the TTL passes, and callers cannot
+	// modify the cached result
+	stats.ByContentType["text/plain"] = simplecontent.ContentTypeStats{}
+	if err := backend.Upload(ctx, "docs/e.txt", strings.NewReader("e")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	cached, err := backend.Stats(ctx, "docs/")
+	if err != nil {
+		t.Fatalf("stats: %v", err)
+	}
+	if cached.Objects != 4 || cached.ByContentType["text/plain"].Objects != 2 {
+		t.Fatalf("expected cached stats, got %+v", cached)
+	}
+	backend.statsTTL = 0
+	if fresh, err := backend.Stats(ctx, "docs/"); err != nil || fresh.Objects != 5 {
+		t.Fatalf("expected a fresh walk without caching, got %+v, %v", fresh, err)
+	}
+}
@@ -0,0 +1,63 @@
+package fs
+
+import (
+	"context"
+	"io"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// UploadTee uploads reader to objectKey while copying the same bytes to sink,
+// so a secondary consumer (a classifier, an external checksum service) sees
+// the content without a second read. It returns the number of bytes stored.
+//
+// The object is committed atomically once both the staged file and sink have
+// received every byte. If writing to sink fails, the upload is aborted and
+// the staged file removed. OnKeyCollision applies as it does to Upload.
+func (b *Backend) UploadTee(ctx context.Context, objectKey string, reader io.Reader, sink io.Writer) (_ int64, err error) {
+	defer wrapError(&err, "upload_tee", objectKey)
+
+	if err := b.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	filePath, err := b.prepareWrite(objectKey, b.onCollision)
+	if err != nil {
+		return 0, err
+	}
+
+	staged, err := b.stageObject(filePath, io.TeeReader(reader, sinkWriter{sink}), simplecontent.UploadParams{ObjectKey: objectKey}, nil)
+	if err != nil {
+		return 0, err
+	}
+	if err := ctx.Err(); err != nil {
+		staged.discard()
+		return 0, err
+	}
+	if _, err := b.commitKey(staged, objectKey, b.onCollision); err != nil {
+		return 0, err
+	}
+	return staged.written.Size, nil
+}
+
+// sinkWriter labels errors from an UploadTee sink
+type sinkWriter struct {
+	w io.Writer
+}
+
+func (s sinkWriter) Write(p []byte) (int, error) {
+	n, err := s.w.Write(p)
+	if err != nil {
+		return n, &sinkError{err: err}
+	}
+	return n, nil
+}
+
+// sinkError is an error returned by an UploadTee sink
+type sinkError struct {
+	err error
+}
+
+func (e *sinkError) Error() string { return "sink: " + e.err.Error() }
+
+func (e *sinkError) Unwrap() error { return e.err }
@@ -0,0 +1,68 @@
+package fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+type failingSink struct {
+	after int
+}
+
+func (s *failingSink) Write(p []byte) (int, error) {
+	if s.after -= len(p); s.after < 0 {
+		return 0, errors.New("analyzer unavailable")
+	}
+	return len(p), nil
+}
+
+func TestFSBackend_UploadTee(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+
+	hash := sha256.New()
+	n, err := backend.UploadTee(ctx, "ingest/doc.txt", strings.NewReader("classify me"), hash)
+	if err != nil || n != 11 {
+		t.Fatalf("upload tee: n=%d err=%v", n, err)
+	}
+	if got := readObject(t, backend, "ingest/doc.txt"); got != "classify me" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	stored, err := backend.contentSHA256(mustObjectPath(t, backend, "ingest/doc.txt"))
+	if err != nil || stored != hex.EncodeToString(hash.Sum(nil)) {
+		t.Fatalf("expected sink to see the stored bytes, err=%v", err)
+	}
+
+	_, err = backend.UploadTee(ctx, "ingest/other.txt", strings.NewReader(strings.Repeat("x", 64<<10)), &failingSink{after: 1024})
+	if err == nil || !strings.Contains(err.Error(), "analyzer unavailable") {
+		t.Fatalf("expected sink error, got %v", err)
+	}
+	if errors.Is(err, simplecontent.ErrSourceRead) {
+		t.Fatalf("expected sink error not to be reported as a source error")
+	}
+	if _, err := os.Stat(mustObjectPath(t, backend, "ingest/other.txt")); !os.IsNotExist(err) {
+		t.Fatalf("expected aborted upload to publish nothing")
+	}
+	entries, _ := os.ReadDir(mustObjectPath(t, backend, "ingest"))
+	for _, e := range entries {
+		if isTempName(e.Name()) {
+			t.Fatalf("expected staged file removed, found %s", e.Name())
+		}
+	}
+}
+
+func mustObjectPath(t *testing.T, b *Backend, key string) string {
+	t.Helper()
+	path, err := b.objectPath(key)
+	if err != nil {
+		t.Fatalf("object path %s: %v", key, err)
+	}
+	return path
+}
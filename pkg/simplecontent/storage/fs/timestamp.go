@@ -0,0 +1,49 @@
+package fs
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// TimestampSource selects where ObjectMeta.CreatedAt comes from
+type TimestampSource string
+
+const (
+	// TimestampMtime reports the file modification time (default)
+	TimestampMtime TimestampSource = "mtime"
+
+	// TimestampCtime reports the inode change time where the platform exposes
+	// it, falling back to the modification time elsewhere
+	TimestampCtime TimestampSource = "ctime"
+
+	// TimestampSidecar reports the creation time recorded in the metadata
+	// sidecar when the key was first written
+	TimestampSidecar TimestampSource = "sidecar"
+)
+
+// validate checks that the timestamp source is known
+func (s TimestampSource) validate() error {
+	switch s {
+	case TimestampMtime, TimestampCtime, TimestampSidecar:
+		return nil
+	default:
+		return fmt.Errorf("unknown timestamp source %q", s)
+	}
+}
+
+// createdAt returns the creation time for an object according to the
+// configured timestamp source
+func (b *Backend) createdAt(info os.FileInfo, sc *sidecar) time.Time {
+	switch b.timestampSource {
+	case TimestampCtime:
+		if t, ok := changeTime(info); ok {
+			return t
+		}
+	case TimestampSidecar:
+		if sc != nil && !sc.CreatedAt.IsZero() {
+			return sc.CreatedAt
+		}
+	}
+	return info.ModTime()
+}
@@ -0,0 +1,77 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFSBackend_TimestampSidecar(t *testing.T) {
+	tmp := t.TempDir()
+	b, err := New(Config{BaseDir: tmp, TimestampSource: TimestampSidecar})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	ctx := context.Background()
+
+	if err := b.Upload(ctx, "sync/a", bytes.NewReader([]byte("v1"))); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	first, err := b.GetObjectMeta(ctx, "sync/a")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+
+	// Push mtime forward and overwrite; creation time must not move
+	future := time.Now().Add(time.Hour)
+	if err := b.Upload(ctx, "sync/a", bytes.NewReader([]byte("v2"))); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := os.Chtimes(filepath.Join(tmp, "sync/a"), future, future); err != nil {
+		t.Fatalf("chtimes: %v", err)
+	}
+	second, err := b.GetObjectMeta(ctx, "sync/a")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+	if !second.CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("expected stable creation time, got %v then %v", first.CreatedAt, second.CreatedAt)
+	}
+	if !second.UpdatedAt.After(second.CreatedAt) {
+		t.Fatalf("expected updated %v after created %v", second.UpdatedAt, second.CreatedAt)
+	}
+
+	if err := b.Delete(ctx, "sync/a"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(tmp, "sync")); !os.IsNotExist(err) {
+		t.Fatalf("expected sidecar and directory removed, stat err=%v", err)
+	}
+}
+
+func TestFSBackend_TimestampDefaults(t *testing.T) {
+	for _, source := range []TimestampSource{"", TimestampMtime, TimestampCtime} {
+		b, err := New(Config{BaseDir: t.TempDir(), TimestampSource: source})
+		if err != nil {
+			t.Fatalf("new fs backend: %v", err)
+		}
+		ctx := context.Background()
+		if err := b.Upload(ctx, "k", bytes.NewReader([]byte("x"))); err != nil {
+			t.Fatalf("upload: %v", err)
+		}
+		meta, err := b.GetObjectMeta(ctx, "k")
+		if err != nil {
+			t.Fatalf("get meta: %v", err)
+		}
+		if meta.CreatedAt.IsZero() || meta.UpdatedAt.IsZero() {
+			t.Fatalf("expected timestamps populated for source %q", source)
+		}
+	}
+
+	if _, err := New(Config{BaseDir: t.TempDir(), TimestampSource: "birth"}); err == nil {
+		t.Fatalf("expected error for unknown timestamp source")
+	}
+}
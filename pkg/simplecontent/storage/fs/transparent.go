@@ -0,0 +1,86 @@
+package fs
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/mimetype"
+)
+
+// extensionCodecs maps key extensions to the codecs TransparentDecompress
+// decodes them with
+var extensionCodecs = map[string]string{
+	".gz":  CodecGzip,
+	".zst": CodecZstd,
+}
+
+// extensionCodec returns the codec to decode objectKey with when
+// TransparentDecompress is enabled, or "" when the key is read as stored
+func (b *Backend) extensionCodec(objectKey string) string {
+	if !b.decompressExt {
+		return ""
+	}
+	return extensionCodecs[strings.ToLower(path.Ext(objectKey))]
+}
+
+// decodeExtension wraps rc with the decompressor matching objectKey's
+// extension under TransparentDecompress
+func (b *Backend) decodeExtension(rc io.ReadCloser, objectKey string) (io.ReadCloser, error) {
+	codec := b.extensionCodec(objectKey)
+	if codec == "" {
+		return rc, nil
+	}
+	return openDecoded(rc, codec)
+}
+
+// DownloadRaw returns an object's bytes as uploaded, without the extension
+// based decompression TransparentDecompress applies in Download.
+func (b *Backend) DownloadRaw(ctx context.Context, objectKey string) (_ io.ReadCloser, err error) {
+	defer wrapError(&err, "download_raw", objectKey)
+	return b.download(ctx, objectKey)
+}
+
+// describeDecompressed replaces the size and content type in meta with those
+// of the decompressed content. The content type comes from the extension
+// left after stripping the compression extension, or is sniffed from the
+// decompressed bytes. Determining the size reads the whole object.
+func (b *Backend) describeDecompressed(meta *simplecontent.ObjectMeta, filePath, storedCodec, codec string) error {
+	file, err := openObject(filePath)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+	content, err := openDecoded(file, storedCodec)
+	if err != nil {
+		return err
+	}
+	if content, err = openDecoded(content, codec); err != nil {
+		return err
+	}
+	defer content.Close()
+
+	buffer := make([]byte, mimetype.SniffLen)
+	n, err := io.ReadFull(content, buffer)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return fmt.Errorf("failed to decompress %s object: %w", codec, err)
+	}
+	rest, err := io.Copy(io.Discard, content)
+	if err != nil {
+		return fmt.Errorf("failed to decompress %s object: %w", codec, err)
+	}
+	meta.Size = int64(n) + rest
+
+	inner := strings.TrimSuffix(meta.Key, path.Ext(meta.Key))
+	if ct := mime.TypeByExtension(path.Ext(inner)); ct != "" {
+		meta.ContentType = ct
+	} else if n > 0 {
+		meta.ContentType = mimetype.Detect(buffer[:n])
+	} else {
+		meta.ContentType = "application/octet-stream"
+	}
+	return nil
+}
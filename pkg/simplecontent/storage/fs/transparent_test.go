@@ -0,0 +1,75 @@
+package fs
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := io.WriteString(zw, data); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("gzip: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestFSBackend_TransparentDecompress(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), TransparentDecompress: true})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	doc := `{"name":"report","pages":3}`
+	compressed := gzipBytes(t, doc)
+	if err := backend.Upload(ctx, "exports/report.json.gz", bytes.NewReader(compressed)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	if got := readObject(t, backend, "exports/report.json.gz"); got != doc {
+		t.Fatalf("expected decompressed content, got %q", got)
+	}
+
+	meta, err := backend.GetObjectMeta(ctx, "exports/report.json.gz")
+	if err != nil {
+		t.Fatalf("get meta: %v", err)
+	}
+	if meta.ContentType != "application/json" || meta.Size != int64(len(doc)) {
+		t.Fatalf("expected decompressed type and size, got %q %d", meta.ContentType, meta.Size)
+	}
+
+	var buf bytes.Buffer
+	if _, err := backend.DownloadTo(ctx, "exports/report.json.gz", &buf); err != nil || buf.String() != doc {
+		t.Fatalf("unexpected DownloadTo result %q: %v", buf.String(), err)
+	}
+
+	rc, err := backend.DownloadRaw(ctx, "exports/report.json.gz")
+	if err != nil {
+		t.Fatalf("download raw: %v", err)
+	}
+	raw, _ := io.ReadAll(rc)
+	rc.Close()
+	if !bytes.Equal(raw, compressed) {
+		t.Fatalf("expected raw download to return compressed bytes")
+	}
+}
+
+func TestFSBackend_TransparentDecompressDisabled(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	compressed := gzipBytes(t, "plain text")
+	if err := backend.Upload(context.Background(), "a.txt.gz", bytes.NewReader(compressed)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if got := readObject(t, backend, "a.txt.gz"); got != string(compressed) {
+		t.Fatalf("expected stored bytes when TransparentDecompress is off")
+	}
+}
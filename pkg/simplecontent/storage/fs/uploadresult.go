@@ -0,0 +1,35 @@
+package fs
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"io"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+// UploadResult describes a committed upload
+type UploadResult struct {
+	Key    string // Key the object was committed under (see FinalizeKey)
+	Size   int64  // Size of the content in bytes, before compression
+	SHA256 string // Hex SHA-256 of the content, as recorded in its sidecar
+	MD5    string // Hex MD5 of the content
+}
+
+// UploadWithResult uploads like UploadWithParams and returns the size and
+// checksums of the stored content, for verifying integrity later. Both
+// digests are computed while the content streams into its staged file, so
+// nothing is read twice; MD5 is only computed here, not by the other upload
+// methods.
+func (b *Backend) UploadWithResult(ctx context.Context, reader io.Reader, params simplecontent.UploadParams) (_ *UploadResult, err error) {
+	defer wrapError(&err, "upload_with_result", params.ObjectKey)
+
+	hash := md5.New()
+	result, err := b.upload(ctx, io.TeeReader(reader, hash), params)
+	if err != nil {
+		return nil, err
+	}
+	result.MD5 = hex.EncodeToString(hash.Sum(nil))
+	return &result, nil
+}
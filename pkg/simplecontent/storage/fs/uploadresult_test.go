@@ -0,0 +1,52 @@
+package fs
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_UploadWithResult(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), CodecGzip)
+	ctx := context.Background()
+
+	content := strings.Repeat("integrity ", 1000)
+	sha := sha256.Sum256([]byte(content))
+	sum := md5.Sum([]byte(content))
+
+	result, err := backend.UploadWithResult(ctx, strings.NewReader(content), simplecontent.UploadParams{ObjectKey: "docs/a.txt"})
+	if err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if result.Key != "docs/a.txt" || result.Size != int64(len(content)) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if result.SHA256 != hex.EncodeToString(sha[:]) || result.MD5 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("unexpected digests %+v", result)
+	}
+	stored, err := backend.contentSHA256(mustObjectPath(t, backend, "docs/a.txt"))
+	if err != nil || stored != result.SHA256 {
+		t.Fatalf("expected sidecar checksum %s, got %s err=%v", result.SHA256, stored, err)
+	}
+
+	// A matching expected checksum is accepted in either case
+	params := simplecontent.UploadParams{ObjectKey: "docs/b.txt", SHA256: strings.ToUpper(result.SHA256)}
+	if err := backend.UploadWithParams(ctx, strings.NewReader(content), params); err != nil {
+		t.Fatalf("upload with expected checksum: %v", err)
+	}
+
+	params = simplecontent.UploadParams{ObjectKey: "docs/c.txt", SHA256: result.SHA256}
+	err = backend.UploadWithParams(ctx, strings.NewReader(content+"tampered"), params)
+	if !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+	if exists, err := backend.Exists(ctx, "docs/c.txt"); err != nil || exists {
+		t.Fatalf("expected mismatched upload to publish nothing, exists=%v err=%v", exists, err)
+	}
+}
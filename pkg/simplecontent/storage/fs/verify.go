@@ -0,0 +1,61 @@
+package fs
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+)
+
+// DownloadWithVerify opens an object like Download and hashes it as the
+// caller reads, so serving it also checks it against the checksum recorded
+// when it was written. After reading the stream to EOF, call verify: it
+// returns an error wrapping simplecontent.ErrChecksumMismatch when the
+// content has drifted, and nil for objects without a recorded checksum.
+// Calling verify before the stream has been fully read returns an error.
+// Aliases and packed objects are checked as Verify checks them.
+// With QuarantineOnCorruption a corrupt object is quarantined by verify.
+func (b *Backend) DownloadWithVerify(ctx context.Context, objectKey string) (_ io.ReadCloser, _ func() error, err error) {
+	defer wrapError(&err, "download_with_verify", objectKey)
+
+	obj, err := b.openResolved(ctx, objectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// The checksum covers the uploaded bytes, so hash below any
+	// TransparentDecompress decoding
+	hr := &hashingReader{ReadCloser: obj.rc, hash: sha256.New()}
+	out, err := b.decodeExtension(hr, objectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	verify := func() (err error) {
+		defer wrapError(&err, "verify", objectKey)
+
+		if !hr.eof {
+			return errors.New("stream not fully read")
+		}
+		return b.checkSHA256(obj.key, obj.sc.SHA256, hex.EncodeToString(hr.hash.Sum(nil)))
+	}
+	return out, verify, nil
+}
+
+// hashingReader hashes everything read through it and records reaching EOF
+type hashingReader struct {
+	io.ReadCloser
+	hash hash.Hash
+	eof  bool
+}
+
+func (r *hashingReader) Read(p []byte) (int, error) {
+	n, err := r.ReadCloser.Read(p)
+	r.hash.Write(p[:n])
+	if err == io.EOF {
+		r.eof = true
+	}
+	return n, err
+}
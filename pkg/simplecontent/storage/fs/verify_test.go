@@ -0,0 +1,76 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_DownloadWithVerify(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "data/blob", strings.NewReader("intact content")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	rc, verify, err := backend.DownloadWithVerify(ctx, "data/blob")
+	if err != nil {
+		t.Fatalf("download with verify: %v", err)
+	}
+	if err := verify(); err == nil {
+		t.Fatalf("expected verify to fail before the stream is read")
+	}
+	if got, _ := io.ReadAll(rc); string(got) != "intact content" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	rc.Close()
+	if err := verify(); err != nil {
+		t.Fatalf("expected intact object to verify, got %v", err)
+	}
+
+	// Simulate bit-rot without going through the backend
+	path, _ := backend.objectPath("data/blob")
+	if err := os.WriteFile(path, []byte("intact c0ntent"), 0644); err != nil {
+		t.Fatalf("corrupt object: %v", err)
+	}
+	rc, verify, err = backend.DownloadWithVerify(ctx, "data/blob")
+	if err != nil {
+		t.Fatalf("download with verify: %v", err)
+	}
+	io.Copy(io.Discard, rc)
+	rc.Close()
+	if err := verify(); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch, got %v", err)
+	}
+}
+
+func TestFSBackend_DownloadWithVerifyAlias(t *testing.T) {
+	backend := newCompressedBackend(t, t.TempDir(), "")
+	ctx := context.Background()
+	if err := backend.Upload(ctx, "data/target", strings.NewReader("intact content")); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if err := backend.CreateAlias(ctx, "data/alias", "data/target"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	if err := os.WriteFile(mustObjectPath(t, backend, "data/target"), []byte("rotted content"), 0644); err != nil {
+		t.Fatalf("corrupt: %v", err)
+	}
+
+	rc, verify, err := backend.DownloadWithVerify(ctx, "data/alias")
+	if err != nil {
+		t.Fatalf("download with verify: %v", err)
+	}
+	if got, _ := io.ReadAll(rc); string(got) != "rotted content" {
+		t.Fatalf("unexpected content %q", got)
+	}
+	rc.Close()
+	if err := verify(); !errors.Is(err, simplecontent.ErrChecksumMismatch) {
+		t.Fatalf("expected ErrChecksumMismatch for a corrupt alias target, got %v", err)
+	}
+}
@@ -0,0 +1,46 @@
+package fs
+
+import (
+	"context"
+	"io"
+)
+
+// OpenWriterTo opens an object for proxying and returns it as an io.WriterTo
+// together with its size, suitable for a Content-Length header.
+//
+// The object is resolved and opened as Download opens it, following aliases
+// and reading packed objects. The returned value is single-use: it closes
+// the underlying file once WriteTo returns. For uncompressed objects it is
+// backed by an *os.File, so writing to a destination that implements
+// io.ReaderFrom (such as a TCP-backed http.ResponseWriter) lets the runtime
+// use sendfile. Compressed objects are decoded and the returned size is
+// their original size.
+func (b *Backend) OpenWriterTo(ctx context.Context, objectKey string) (_ io.WriterTo, _ int64, err error) {
+	defer wrapError(&err, "open_writer_to", objectKey)
+
+	rc, size, err := b.downloadSized(ctx, objectKey)
+	if err != nil {
+		return nil, 0, err
+	}
+	if size < 0 {
+		meta, err := b.GetObjectMeta(ctx, objectKey)
+		if err != nil {
+			rc.Close()
+			return nil, 0, err
+		}
+		size = meta.Size
+	}
+	return &fileWriterTo{src: rc}, size, nil
+}
+
+// fileWriterTo writes a file to a destination once and then closes it
+type fileWriterTo struct {
+	src io.ReadCloser
+}
+
+// WriteTo implements io.WriterTo. io.Copy defers to the *os.File WriteTo
+// for uncompressed objects.
+func (w *fileWriterTo) WriteTo(dst io.Writer) (int64, error) {
+	defer w.src.Close()
+	return io.Copy(dst, w.src)
+}
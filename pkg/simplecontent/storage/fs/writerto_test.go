@@ -0,0 +1,96 @@
+package fs
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func TestFSBackend_OpenWriterTo(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	data := []byte("proxied bytes")
+	if err := backend.Upload(ctx, "proxy/obj", bytes.NewReader(data)); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+
+	wt, size, err := backend.OpenWriterTo(ctx, "proxy/obj")
+	if err != nil {
+		t.Fatalf("open writer to: %v", err)
+	}
+	if size != int64(len(data)) {
+		t.Fatalf("expected size %d, got %d", len(data), size)
+	}
+
+	var buf bytes.Buffer
+	n, err := wt.WriteTo(&buf)
+	if err != nil {
+		t.Fatalf("write to: %v", err)
+	}
+	if n != size || buf.String() != string(data) {
+		t.Fatalf("unexpected content %q (%d bytes)", buf.String(), n)
+	}
+
+	// Download also exposes io.WriterTo for efficient proxying
+	rc, err := backend.Download(ctx, "proxy/obj")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer rc.Close()
+	if _, ok := rc.(io.WriterTo); !ok {
+		t.Fatalf("expected download reader to implement io.WriterTo")
+	}
+}
+
+func TestFSBackend_OpenWriterToMatchesDownload(t *testing.T) {
+	b, err := New(Config{BaseDir: t.TempDir(), PackAge: time.Hour})
+	if err != nil {
+		t.Fatalf("new fs backend: %v", err)
+	}
+	backend := b.(*Backend)
+	ctx := context.Background()
+
+	for _, key := range []string{"live/a", "archive/b"} {
+		if err := backend.Upload(ctx, key, strings.NewReader("content of "+key)); err != nil {
+			t.Fatalf("upload %s: %v", key, err)
+		}
+	}
+	if err := backend.CreateAlias(ctx, "current", "live/a"); err != nil {
+		t.Fatalf("create alias: %v", err)
+	}
+	age(t, backend, "archive/b", 2*time.Hour)
+	if err := backend.Compact(ctx, "archive/"); err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if err := backend.Reserve(ctx, "pending", time.Hour); err != nil {
+		t.Fatalf("reserve: %v", err)
+	}
+
+	for key, want := range map[string]string{"current": "content of live/a", "archive/b": "content of archive/b"} {
+		wt, size, err := backend.OpenWriterTo(ctx, key)
+		if err != nil {
+			t.Fatalf("open writer to %s: %v", key, err)
+		}
+		var buf bytes.Buffer
+		if _, err := wt.WriteTo(&buf); err != nil {
+			t.Fatalf("write to: %v", err)
+		}
+		if buf.String() != want || size != int64(len(want)) {
+			t.Fatalf("%s: unexpected %q (%d bytes)", key, buf.String(), size)
+		}
+	}
+	if _, _, err := backend.OpenWriterTo(ctx, "pending"); !errors.Is(err, simplecontent.ErrObjectNotFound) {
+		t.Fatalf("expected a reservation to be missing, got %v", err)
+	}
+}
@@ -0,0 +1,20 @@
+package memory
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func init() {
+	simplecontent.RegisterScheme("mem", openDSN)
+}
+
+// openDSN creates a backend from a mem:// DSN, which takes no options
+func openDSN(dsn *url.URL) (simplecontent.BlobStore, error) {
+	for name := range dsn.Query() {
+		return nil, fmt.Errorf("unknown option %q", name)
+	}
+	return New(), nil
+}
@@ -3,8 +3,14 @@ package memory
 import (
 	"bytes"
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
+	"sort"
+	"strings"
 	"sync"
 
 	"github.com/tendant/simple-content/pkg/simplecontent"
@@ -15,6 +21,13 @@ type Backend struct {
 	mu              sync.RWMutex
 	objects         map[string][]byte
 	objectsMimeType map[string]string
+	uploads         map[string]*multipartUpload
+}
+
+// multipartUpload is a multipart upload in progress
+type multipartUpload struct {
+	key   string
+	parts map[int][]byte
 }
 
 // New creates a new in-memory storage backend
@@ -22,6 +35,7 @@ func New() simplecontent.BlobStore {
 	return &Backend{
 		objects:         make(map[string][]byte),
 		objectsMimeType: make(map[string]string),
+		uploads:         make(map[string]*multipartUpload),
 	}
 }
 
@@ -32,11 +46,11 @@ func (b *Backend) GetObjectMeta(ctx context.Context, objectKey string) (*simplec
 
 	data, exists := b.objects[objectKey]
 	if !exists {
-		return nil, errors.New("object not found")
+		return nil, simplecontent.ErrObjectNotFound
 	}
 	mimeType, exists := b.objectsMimeType[objectKey]
 	if !exists {
-		return nil, errors.New("object not found")
+		return nil, simplecontent.ErrObjectNotFound
 	}
 
 	meta := &simplecontent.ObjectMeta{
@@ -49,6 +63,36 @@ func (b *Backend) GetObjectMeta(ctx context.Context, objectKey string) (*simplec
 	return meta, nil
 }
 
+// List returns the metadata of the objects whose key starts with prefix,
+// ordered by key
+func (b *Backend) List(ctx context.Context, prefix string) ([]simplecontent.ObjectMeta, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var objects []simplecontent.ObjectMeta
+	for key, data := range b.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		objects = append(objects, simplecontent.ObjectMeta{
+			Key:         key,
+			Size:        int64(len(data)),
+			ContentType: b.objectsMimeType[key],
+		})
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Key < objects[j].Key })
+	return objects, nil
+}
+
+// Exists reports whether an object is stored in memory
+func (b *Backend) Exists(ctx context.Context, objectKey string) (bool, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	_, ok := b.objects[objectKey]
+	return ok, nil
+}
+
 // GetUploadURL returns a URL for uploading content
 // In-memory implementation doesn't use URLs
 func (b *Backend) GetUploadURL(ctx context.Context, objectKey string) (string, error) {
@@ -75,11 +119,31 @@ func (b *Backend) Upload(ctx context.Context, objectKey string, reader io.Reader
 
 // UploadWithParams uploads content with parameters
 func (b *Backend) UploadWithParams(ctx context.Context, reader io.Reader, params simplecontent.UploadParams) error {
-	err := b.Upload(ctx, params.ObjectKey, reader)
+	data, err := io.ReadAll(reader)
 	if err != nil {
 		return err
 	}
-	b.objectsMimeType[params.ObjectKey] = params.MimeType
+	if params.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, params.SHA256) {
+			return fmt.Errorf("%w: expected sha256 %s, got %s", simplecontent.ErrChecksumMismatch, params.SHA256, got)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.objects[params.ObjectKey]; exists && params.IfNotExists {
+		return fmt.Errorf("%w: %s", simplecontent.ErrObjectExists, params.ObjectKey)
+	}
+	b.objects[params.ObjectKey] = data
+	// The declared MIME type is reported verbatim; without one the object
+	// keeps the default Upload sets
+	if params.MimeType != "" {
+		b.objectsMimeType[params.ObjectKey] = params.MimeType
+	} else if _, exists := b.objectsMimeType[params.ObjectKey]; !exists {
+		b.objectsMimeType[params.ObjectKey] = "application/octet-stream"
+	}
 	return nil
 }
 
@@ -101,21 +165,182 @@ func (b *Backend) Download(ctx context.Context, objectKey string) (io.ReadCloser
 
 	data, exists := b.objects[objectKey]
 	if !exists {
-		return nil, errors.New("object not found")
+		return nil, simplecontent.ErrObjectNotFound
 	}
 
 	return io.NopCloser(bytes.NewReader(data)), nil
 }
 
+// DownloadRange downloads part of the content, to the end when length is
+// negative
+func (b *Backend) DownloadRange(ctx context.Context, objectKey string, offset, length int64) (*simplecontent.ObjectRange, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	data, exists := b.objects[objectKey]
+	if !exists {
+		return nil, simplecontent.ErrObjectNotFound
+	}
+	length, err := simplecontent.ClampRange(offset, length, int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &simplecontent.ObjectRange{
+		ReadCloser: io.NopCloser(bytes.NewReader(data[offset : offset+length])),
+		Offset:     offset,
+		Length:     length,
+		Size:       int64(len(data)),
+	}, nil
+}
+
 // Delete deletes content
 func (b *Backend) Delete(ctx context.Context, objectKey string) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	if _, exists := b.objects[objectKey]; !exists {
-		return errors.New("object not found")
+		return simplecontent.ErrObjectNotFound
 	}
 
 	delete(b.objects, objectKey)
 	return nil
+}
+
+// Copy duplicates an object under another key
+func (b *Backend) Copy(ctx context.Context, srcKey, dstKey string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data, exists := b.objects[srcKey]
+	if !exists {
+		return simplecontent.ErrObjectNotFound
+	}
+	b.objects[dstKey] = data
+	b.objectsMimeType[dstKey] = b.objectsMimeType[srcKey]
+	return nil
+}
+
+// Move re-keys an object
+func (b *Backend) Move(ctx context.Context, srcKey, dstKey string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data, exists := b.objects[srcKey]
+	if !exists {
+		return simplecontent.ErrObjectNotFound
+	}
+	mimeType := b.objectsMimeType[srcKey]
+	delete(b.objects, srcKey)
+	delete(b.objectsMimeType, srcKey)
+	b.objects[dstKey] = data
+	b.objectsMimeType[dstKey] = mimeType
+	return nil
+}
+
+// DeleteMany deletes every key, recording the keys that were not found
+func (b *Backend) DeleteMany(ctx context.Context, keys []string) (map[string]error, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	failed := make(map[string]error)
+	for _, key := range keys {
+		if _, exists := b.objects[key]; !exists {
+			failed[key] = simplecontent.ErrObjectNotFound
+			continue
+		}
+		delete(b.objects, key)
+		delete(b.objectsMimeType, key)
+	}
+	return failed, nil
+}
+
+// Stats aggregates the objects whose key starts with prefix. The memory
+// backend records no modification times, so Oldest and Newest stay zero.
+func (b *Backend) Stats(ctx context.Context, prefix string) (simplecontent.StorageStats, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	var stats simplecontent.StorageStats
+	for key, data := range b.objects {
+		if strings.HasPrefix(key, prefix) {
+			stats.Add(simplecontent.ObjectMeta{Key: key, Size: int64(len(data)), ContentType: b.objectsMimeType[key]})
+		}
+	}
+	return stats, nil
+}
+
+// InitiateMultipart starts a multipart upload to objectKey
+func (b *Backend) InitiateMultipart(ctx context.Context, objectKey string) (string, error) {
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id[:])
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.uploads[uploadID] = &multipartUpload{key: objectKey, parts: make(map[int][]byte)}
+	return uploadID, nil
+}
+
+// UploadPart stores a part of a multipart upload
+func (b *Backend) UploadPart(ctx context.Context, uploadID string, partNumber int, r io.Reader) error {
+	if partNumber < 1 {
+		return fmt.Errorf("%w: part number %d is below 1", simplecontent.ErrInvalidPart, partNumber)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	upload, exists := b.uploads[uploadID]
+	if !exists {
+		return simplecontent.ErrUploadNotFound
+	}
+	upload.parts[partNumber] = data
+	return nil
+}
+
+// CompleteMultipart stores the concatenated parts of an upload as its object
+func (b *Backend) CompleteMultipart(ctx context.Context, uploadID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	upload, exists := b.uploads[uploadID]
+	if !exists {
+		return simplecontent.ErrUploadNotFound
+	}
+	if len(upload.parts) == 0 {
+		return fmt.Errorf("%w: no parts were uploaded", simplecontent.ErrInvalidPart)
+	}
+	var data []byte
+	for n := 1; n <= len(upload.parts); n++ {
+		part, exists := upload.parts[n]
+		if !exists {
+			return fmt.Errorf("%w: part %d is missing", simplecontent.ErrInvalidPart, n)
+		}
+		data = append(data, part...)
+	}
+
+	b.objects[upload.key] = data
+	if _, exists := b.objectsMimeType[upload.key]; !exists {
+		b.objectsMimeType[upload.key] = "application/octet-stream"
+	}
+	delete(b.uploads, uploadID)
+	return nil
+}
+
+// AbortMultipart discards a multipart upload and its parts
+func (b *Backend) AbortMultipart(ctx context.Context, uploadID string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, exists := b.uploads[uploadID]; !exists {
+		return simplecontent.ErrUploadNotFound
+	}
+	delete(b.uploads, uploadID)
+	return nil
 }
\ No newline at end of file
@@ -36,6 +36,158 @@ func TestMemoryBackend(t *testing.T) {
 		assert.Contains(t, meta.Metadata, "mime_type")
 	})
 
+	t.Run("Exists", func(t *testing.T) {
+		exists, err := backend.Exists(ctx, testKey)
+		assert.NoError(t, err)
+		assert.True(t, exists)
+
+		exists, err = backend.Exists(ctx, "non/existent/key")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("List", func(t *testing.T) {
+		assert.NoError(t, backend.Upload(ctx, "test/other", strings.NewReader("x")))
+		defer backend.Delete(ctx, "test/other")
+
+		objects, err := backend.List(ctx, "test/")
+		require.NoError(t, err)
+		require.Len(t, objects, 2)
+		assert.Equal(t, testKey, objects[0].Key)
+		assert.Equal(t, int64(len(testData)), objects[0].Size)
+		assert.Equal(t, "test/other", objects[1].Key)
+
+		objects, err = backend.List(ctx, "missing/")
+		assert.NoError(t, err)
+		assert.Empty(t, objects)
+	})
+
+	t.Run("DownloadRange", func(t *testing.T) {
+		rng, err := backend.DownloadRange(ctx, testKey, 7, 5)
+		require.NoError(t, err)
+		data, err := io.ReadAll(rng)
+		assert.NoError(t, err)
+		assert.Equal(t, "World", string(data))
+		assert.Equal(t, int64(len(testData)), rng.Size)
+
+		rng, err = backend.DownloadRange(ctx, testKey, 14, -1)
+		require.NoError(t, err)
+		data, _ = io.ReadAll(rng)
+		assert.Equal(t, testData[14:], string(data))
+
+		_, err = backend.DownloadRange(ctx, testKey, int64(len(testData)), 1)
+		assert.ErrorIs(t, err, simplecontent.ErrRangeNotSatisfiable)
+	})
+
+	t.Run("CopyAndMove", func(t *testing.T) {
+		require.NoError(t, backend.Copy(ctx, testKey, "copies/a"))
+		require.NoError(t, backend.Move(ctx, "copies/a", "copies/b"))
+
+		exists, err := backend.Exists(ctx, "copies/a")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+		reader, err := backend.Download(ctx, "copies/b")
+		require.NoError(t, err)
+		data, _ := io.ReadAll(reader)
+		assert.Equal(t, testData, string(data))
+		assert.NoError(t, backend.Delete(ctx, "copies/b"))
+
+		assert.ErrorIs(t, backend.Copy(ctx, "missing", "copies/c"), simplecontent.ErrObjectNotFound)
+		assert.ErrorIs(t, backend.Move(ctx, "missing", "copies/c"), simplecontent.ErrObjectNotFound)
+	})
+
+	t.Run("DeleteMany", func(t *testing.T) {
+		assert.NoError(t, backend.Upload(ctx, "batch/a", strings.NewReader("a")))
+		assert.NoError(t, backend.Upload(ctx, "batch/b", strings.NewReader("b")))
+
+		failed, err := backend.DeleteMany(ctx, []string{"batch/a", "batch/missing", "batch/b"})
+		require.NoError(t, err)
+		require.Len(t, failed, 1)
+		assert.ErrorIs(t, failed["batch/missing"], simplecontent.ErrObjectNotFound)
+		exists, err := backend.Exists(ctx, "batch/b")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("DeclaredMimeType", func(t *testing.T) {
+		svg := `<svg xmlns="http://www.w3.org/2000/svg"></svg>`
+		assert.NoError(t, backend.UploadWithParams(ctx, strings.NewReader(svg), simplecontent.UploadParams{ObjectKey: "mime/logo.svg", MimeType: "image/svg+xml"}))
+		meta, err := backend.GetObjectMeta(ctx, "mime/logo.svg")
+		require.NoError(t, err)
+		assert.Equal(t, "image/svg+xml", meta.ContentType)
+
+		assert.NoError(t, backend.UploadWithParams(ctx, strings.NewReader("x"), simplecontent.UploadParams{ObjectKey: "mime/plain"}))
+		meta, err = backend.GetObjectMeta(ctx, "mime/plain")
+		require.NoError(t, err)
+		assert.Equal(t, "application/octet-stream", meta.ContentType)
+	})
+
+	t.Run("Stats", func(t *testing.T) {
+		assert.NoError(t, backend.UploadWithParams(ctx, strings.NewReader("abc"), simplecontent.UploadParams{ObjectKey: "stats/a.txt", MimeType: "text/plain"}))
+		assert.NoError(t, backend.UploadWithParams(ctx, strings.NewReader("de"), simplecontent.UploadParams{ObjectKey: "stats/b.txt", MimeType: "text/plain"}))
+		assert.NoError(t, backend.UploadWithParams(ctx, strings.NewReader("{}"), simplecontent.UploadParams{ObjectKey: "stats/c.json", MimeType: "application/json"}))
+
+		stats, err := backend.Stats(ctx, "stats/")
+		require.NoError(t, err)
+		assert.Equal(t, int64(3), stats.Objects)
+		assert.Equal(t, int64(7), stats.Bytes)
+		assert.Equal(t, simplecontent.ContentTypeStats{Objects: 2, Bytes: 5}, stats.ByContentType["text/plain"])
+		assert.Equal(t, simplecontent.ContentTypeStats{Objects: 1, Bytes: 2}, stats.ByContentType["application/json"])
+	})
+
+	t.Run("Multipart", func(t *testing.T) {
+		uploadID, err := backend.InitiateMultipart(ctx, "multipart/object")
+		require.NoError(t, err)
+		require.NoError(t, backend.UploadPart(ctx, uploadID, 2, strings.NewReader("world")))
+		assert.ErrorIs(t, backend.CompleteMultipart(ctx, uploadID), simplecontent.ErrInvalidPart)
+		require.NoError(t, backend.UploadPart(ctx, uploadID, 1, strings.NewReader("hello ")))
+		require.NoError(t, backend.CompleteMultipart(ctx, uploadID))
+
+		reader, err := backend.Download(ctx, "multipart/object")
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "hello world", string(data))
+		assert.ErrorIs(t, backend.CompleteMultipart(ctx, uploadID), simplecontent.ErrUploadNotFound)
+
+		uploadID, err = backend.InitiateMultipart(ctx, "multipart/aborted")
+		require.NoError(t, err)
+		require.NoError(t, backend.UploadPart(ctx, uploadID, 1, strings.NewReader("x")))
+		require.NoError(t, backend.AbortMultipart(ctx, uploadID))
+		assert.ErrorIs(t, backend.UploadPart(ctx, uploadID, 2, strings.NewReader("y")), simplecontent.ErrUploadNotFound)
+		exists, err := backend.Exists(ctx, "multipart/aborted")
+		require.NoError(t, err)
+		assert.False(t, exists)
+	})
+
+	t.Run("UploadIfNotExists", func(t *testing.T) {
+		params := simplecontent.UploadParams{ObjectKey: "exclusive/key", IfNotExists: true}
+		require.NoError(t, backend.UploadWithParams(ctx, strings.NewReader("first"), params))
+		err := backend.UploadWithParams(ctx, strings.NewReader("second"), params)
+		assert.ErrorIs(t, err, simplecontent.ErrObjectExists)
+
+		reader, err := backend.Download(ctx, "exclusive/key")
+		require.NoError(t, err)
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "first", string(data))
+	})
+
+	t.Run("UploadWithChecksum", func(t *testing.T) {
+		params := simplecontent.UploadParams{
+			ObjectKey: "checked/key",
+			SHA256:    "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", // "hello"
+		}
+		assert.NoError(t, backend.UploadWithParams(ctx, strings.NewReader("hello"), params))
+
+		params.ObjectKey = "checked/other"
+		err := backend.UploadWithParams(ctx, strings.NewReader("goodbye"), params)
+		assert.ErrorIs(t, err, simplecontent.ErrChecksumMismatch)
+		exists, err := backend.Exists(ctx, "checked/other")
+		assert.NoError(t, err)
+		assert.False(t, exists)
+	})
+
 	t.Run("Download", func(t *testing.T) {
 		reader, err := backend.Download(ctx, testKey)
 		assert.NoError(t, err)
@@ -108,17 +260,18 @@ func TestMemoryBackend(t *testing.T) {
 
 		// GetObjectMeta for non-existent object
 		meta, err := backend.GetObjectMeta(ctx, nonExistentKey)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, simplecontent.ErrObjectNotFound)
+		assert.EqualError(t, err, "object not found")
 		assert.Nil(t, meta)
 
 		// Download non-existent object
 		reader, err := backend.Download(ctx, nonExistentKey)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, simplecontent.ErrObjectNotFound)
 		assert.Nil(t, reader)
 
 		// Delete non-existent object
 		err = backend.Delete(ctx, nonExistentKey)
-		assert.Error(t, err)
+		assert.ErrorIs(t, err, simplecontent.ErrObjectNotFound)
 	})
 }
 
@@ -0,0 +1,71 @@
+package s3
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+)
+
+func init() {
+	simplecontent.RegisterScheme("s3", openDSN)
+}
+
+// openDSN creates a backend from an s3://bucket DSN. Options:
+//
+//	region, access_key_id, secret_access_key, endpoint, sse_algorithm,
+//	sse_kms_key_id                          the Config fields of the same name
+//	use_ssl, use_path_style, enable_sse,
+//	create_bucket_if_not_exist              booleans
+//	presign_expires                         PresignDuration, as a duration such as 1h
+func openDSN(dsn *url.URL) (simplecontent.BlobStore, error) {
+	config := Config{Bucket: dsn.Host, UseSSL: true}
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required")
+	}
+
+	for name, values := range dsn.Query() {
+		value := values[len(values)-1]
+		var target *bool
+		switch name {
+		case "region":
+			config.Region = value
+		case "access_key_id":
+			config.AccessKeyID = value
+		case "secret_access_key":
+			config.SecretAccessKey = value
+		case "endpoint":
+			config.Endpoint = value
+		case "sse_algorithm":
+			config.SSEAlgorithm = value
+		case "sse_kms_key_id":
+			config.SSEKMSKeyID = value
+		case "presign_expires":
+			d, err := time.ParseDuration(value)
+			if err != nil || d < time.Second {
+				return nil, fmt.Errorf("invalid presign_expires %q: expected a duration of at least 1s", value)
+			}
+			config.PresignDuration = int(d / time.Second)
+		case "use_ssl":
+			target = &config.UseSSL
+		case "use_path_style":
+			target = &config.UsePathStyle
+		case "enable_sse":
+			target = &config.EnableSSE
+		case "create_bucket_if_not_exist":
+			target = &config.CreateBucketIfNotExist
+		default:
+			return nil, fmt.Errorf("unknown option %q", name)
+		}
+		if target != nil {
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: expected true or false", name, value)
+			}
+			*target = b
+		}
+	}
+	return New(config)
+}
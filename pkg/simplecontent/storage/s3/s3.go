@@ -1,7 +1,9 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
@@ -15,6 +17,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
 	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/aws/smithy-go"
 	smithyendpoints "github.com/aws/smithy-go/endpoints"
 	"github.com/tendant/simple-content/pkg/simplecontent"
 )
@@ -193,7 +196,7 @@ func (b *Backend) GetObjectMeta(ctx context.Context, objectKey string) (*simplec
 	if err != nil {
 		var notFound *types.NotFound
 		if errors.As(err, &notFound) {
-			return nil, errors.New("object not found")
+			return nil, simplecontent.ErrObjectNotFound
 		}
 		return nil, fmt.Errorf("failed to get object metadata: %w", err)
 	}
@@ -221,6 +224,75 @@ func (b *Backend) GetObjectMeta(ctx context.Context, objectKey string) (*simplec
 	return meta, nil
 }
 
+// List returns the metadata of the objects whose key starts with prefix,
+// ordered by key, paging through ListObjectsV2. ContentType is not reported;
+// use GetObjectMeta for it.
+func (b *Backend) List(ctx context.Context, prefix string) ([]simplecontent.ObjectMeta, error) {
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var objects []simplecontent.ObjectMeta
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			objects = append(objects, simplecontent.ObjectMeta{
+				Key:       aws.ToString(obj.Key),
+				Size:      aws.ToInt64(obj.Size),
+				UpdatedAt: aws.ToTime(obj.LastModified),
+				ETag:      strings.Trim(aws.ToString(obj.ETag), "\""),
+			})
+		}
+	}
+	return objects, nil
+}
+
+// Stats aggregates the objects whose key starts with prefix from a single
+// listing. Listings carry no content type, so every object is counted under
+// the unknown ("") type rather than fetching each object's headers.
+func (b *Backend) Stats(ctx context.Context, prefix string) (simplecontent.StorageStats, error) {
+	paginator := s3.NewListObjectsV2Paginator(b.client, &s3.ListObjectsV2Input{
+		Bucket: aws.String(b.bucket),
+		Prefix: aws.String(prefix),
+	})
+
+	var stats simplecontent.StorageStats
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return simplecontent.StorageStats{}, fmt.Errorf("failed to list objects: %w", err)
+		}
+		for _, obj := range page.Contents {
+			stats.Add(simplecontent.ObjectMeta{
+				Key:       aws.ToString(obj.Key),
+				Size:      aws.ToInt64(obj.Size),
+				UpdatedAt: aws.ToTime(obj.LastModified),
+			})
+		}
+	}
+	return stats, nil
+}
+
+// Exists reports whether an object is stored in S3
+func (b *Backend) Exists(ctx context.Context, objectKey string) (bool, error) {
+	_, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(objectKey),
+	})
+	if err != nil {
+		var notFound *types.NotFound
+		if errors.As(err, &notFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check object: %w", err)
+	}
+	return true, nil
+}
+
 // GetUploadURL returns a presigned URL for uploading content
 func (b *Backend) GetUploadURL(ctx context.Context, objectKey string) (string, error) {
 	input := &s3.PutObjectInput{
@@ -294,6 +366,12 @@ func (b *Backend) UploadWithParams(ctx context.Context, reader io.Reader, params
 		ContentType: aws.String(params.MimeType),
 	}
 
+	// Conditional writes: the uploader carries the condition on to the
+	// completion of multipart uploads
+	if params.IfNotExists {
+		input.IfNoneMatch = aws.String("*")
+	}
+
 	// Add server-side encryption if enabled
 	if b.config.EnableSSE {
 		switch b.config.SSEAlgorithm {
@@ -309,6 +387,10 @@ func (b *Backend) UploadWithParams(ctx context.Context, reader io.Reader, params
 
 	_, err := uploader.Upload(ctx, input)
 	if err != nil {
+		var apiErr smithy.APIError
+		if params.IfNotExists && errors.As(err, &apiErr) && apiErr.ErrorCode() == "PreconditionFailed" {
+			return fmt.Errorf("%w: %s", simplecontent.ErrObjectExists, params.ObjectKey)
+		}
 		return fmt.Errorf("failed to upload to S3 with params: %w", err)
 	}
 
@@ -367,7 +449,7 @@ func (b *Backend) Download(ctx context.Context, objectKey string) (io.ReadCloser
 	if err != nil {
 		var notFound *types.NoSuchKey
 		if errors.As(err, &notFound) {
-			return nil, errors.New("object not found")
+			return nil, simplecontent.ErrObjectNotFound
 		}
 		return nil, fmt.Errorf("failed to download from S3: %w", err)
 	}
@@ -375,6 +457,151 @@ func (b *Backend) Download(ctx context.Context, objectKey string) (io.ReadCloser
 	return result.Body, nil
 }
 
+// DownloadRange downloads part of the content from S3 with a ranged GET, to
+// the end when length is negative
+func (b *Backend) DownloadRange(ctx context.Context, objectKey string, offset, length int64) (*simplecontent.ObjectRange, error) {
+	if offset < 0 {
+		return nil, simplecontent.ErrRangeNotSatisfiable
+	}
+	if length == 0 {
+		// A ranged GET cannot ask for no bytes
+		head, err := b.client.HeadObject(ctx, &s3.HeadObjectInput{
+			Bucket: aws.String(b.bucket),
+			Key:    aws.String(objectKey),
+		})
+		if err != nil {
+			var notFound *types.NotFound
+			if errors.As(err, &notFound) {
+				return nil, simplecontent.ErrObjectNotFound
+			}
+			return nil, fmt.Errorf("failed to get object metadata from S3: %w", err)
+		}
+		size := aws.ToInt64(head.ContentLength)
+		if _, err := simplecontent.ClampRange(offset, 0, size); err != nil {
+			return nil, err
+		}
+		return &simplecontent.ObjectRange{ReadCloser: io.NopCloser(strings.NewReader("")), Offset: offset, Size: size}, nil
+	}
+
+	byteRange := fmt.Sprintf("bytes=%d-", offset)
+	if length > 0 {
+		byteRange = fmt.Sprintf("bytes=%d-%d", offset, offset+length-1)
+	}
+	result, err := b.client.GetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(objectKey),
+		Range:  aws.String(byteRange),
+	})
+	if err != nil {
+		var notFound *types.NoSuchKey
+		if errors.As(err, &notFound) {
+			return nil, simplecontent.ErrObjectNotFound
+		}
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "InvalidRange" {
+			return nil, simplecontent.ErrRangeNotSatisfiable
+		}
+		return nil, fmt.Errorf("failed to download range from S3: %w", err)
+	}
+
+	// Content-Range is "bytes first-last/size"
+	var first, last, size int64
+	if _, err := fmt.Sscanf(aws.ToString(result.ContentRange), "bytes %d-%d/%d", &first, &last, &size); err != nil {
+		result.Body.Close()
+		return nil, fmt.Errorf("unexpected Content-Range %q from S3", aws.ToString(result.ContentRange))
+	}
+	return &simplecontent.ObjectRange{
+		ReadCloser: result.Body,
+		Offset:     first,
+		Length:     last - first + 1,
+		Size:       size,
+	}, nil
+}
+
+// maxDeleteObjects is the most keys a single DeleteObjects request accepts
+const maxDeleteObjects = 1000
+
+// DeleteMany deletes the keys with DeleteObjects requests of up to 1000 keys,
+// recording the keys S3 failed to delete. As S3 deletes are idempotent,
+// missing keys are not reported. The error is non-nil when a request as a
+// whole failed; its keys are recorded with that error and the remaining
+// batches are not sent.
+func (b *Backend) DeleteMany(ctx context.Context, keys []string) (map[string]error, error) {
+	failed := make(map[string]error)
+	for start := 0; start < len(keys); start += maxDeleteObjects {
+		batch := keys[start:min(start+maxDeleteObjects, len(keys))]
+		objects := make([]types.ObjectIdentifier, len(batch))
+		for i, key := range batch {
+			objects[i] = types.ObjectIdentifier{Key: aws.String(key)}
+		}
+
+		result, err := b.client.DeleteObjects(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(b.bucket),
+			Delete: &types.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			err = fmt.Errorf("failed to delete from S3: %w", err)
+			for _, key := range keys[start:] {
+				failed[key] = err
+			}
+			return failed, err
+		}
+		for _, e := range result.Errors {
+			failed[aws.ToString(e.Key)] = fmt.Errorf("failed to delete from S3: %s: %s", aws.ToString(e.Code), aws.ToString(e.Message))
+		}
+	}
+	return failed, nil
+}
+
+// Copy duplicates an object under another key with a server-side
+// CopyObject, keeping its content type and metadata. A single CopyObject is
+// limited to objects of up to 5 GB.
+func (b *Backend) Copy(ctx context.Context, srcKey, dstKey string) error {
+	input := &s3.CopyObjectInput{
+		Bucket:     aws.String(b.bucket),
+		Key:        aws.String(dstKey),
+		CopySource: aws.String(b.bucket + "/" + strings.ReplaceAll(url.PathEscape(srcKey), "%2F", "/")),
+	}
+
+	// Add server-side encryption if enabled
+	if b.config.EnableSSE {
+		switch b.config.SSEAlgorithm {
+		case "AES256":
+			input.ServerSideEncryption = types.ServerSideEncryptionAes256
+		case "aws:kms":
+			input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+			if b.config.SSEKMSKeyID != "" {
+				input.SSEKMSKeyId = aws.String(b.config.SSEKMSKeyID)
+			}
+		}
+	}
+
+	if _, err := b.client.CopyObject(ctx, input); err != nil {
+		var apiErr smithy.APIError
+		if errors.As(err, &apiErr) && apiErr.ErrorCode() == "NoSuchKey" {
+			return simplecontent.ErrObjectNotFound
+		}
+		return fmt.Errorf("failed to copy in S3: %w", err)
+	}
+	return nil
+}
+
+// Move re-keys an object by copying it and deleting the source, as S3 has
+// no rename
+func (b *Backend) Move(ctx context.Context, srcKey, dstKey string) error {
+	if srcKey == dstKey {
+		exists, err := b.Exists(ctx, srcKey)
+		if err == nil && !exists {
+			err = simplecontent.ErrObjectNotFound
+		}
+		return err
+	}
+	if err := b.Copy(ctx, srcKey, dstKey); err != nil {
+		return err
+	}
+	return b.Delete(ctx, srcKey)
+}
+
 // Delete deletes content from S3
 func (b *Backend) Delete(ctx context.Context, objectKey string) error {
 	_, err := b.client.DeleteObject(ctx, &s3.DeleteObjectInput{
@@ -388,3 +615,150 @@ func (b *Backend) Delete(ctx context.Context, objectKey string) error {
 
 	return nil
 }
+
+// InitiateMultipart starts an S3 multipart upload to objectKey. The upload
+// ID returned also records the key, which S3 requires alongside its own ID.
+func (b *Backend) InitiateMultipart(ctx context.Context, objectKey string) (string, error) {
+	input := &s3.CreateMultipartUploadInput{
+		Bucket: aws.String(b.bucket),
+		Key:    aws.String(objectKey),
+	}
+
+	// Add server-side encryption if enabled
+	if b.config.EnableSSE {
+		switch b.config.SSEAlgorithm {
+		case "AES256":
+			input.ServerSideEncryption = types.ServerSideEncryptionAes256
+		case "aws:kms":
+			input.ServerSideEncryption = types.ServerSideEncryptionAwsKms
+			if b.config.SSEKMSKeyID != "" {
+				input.SSEKMSKeyId = aws.String(b.config.SSEKMSKeyID)
+			}
+		}
+	}
+
+	out, err := b.client.CreateMultipartUpload(ctx, input)
+	if err != nil {
+		return "", fmt.Errorf("failed to initiate multipart upload: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString([]byte(objectKey)) + "." + aws.ToString(out.UploadId), nil
+}
+
+// parseUploadID splits an upload ID from InitiateMultipart into the object
+// key and the S3 upload ID
+func parseUploadID(uploadID string) (objectKey, s3UploadID string, err error) {
+	encodedKey, s3UploadID, ok := strings.Cut(uploadID, ".")
+	if !ok || s3UploadID == "" {
+		return "", "", simplecontent.ErrUploadNotFound
+	}
+	key, err := base64.RawURLEncoding.DecodeString(encodedKey)
+	if err != nil {
+		return "", "", simplecontent.ErrUploadNotFound
+	}
+	return string(key), s3UploadID, nil
+}
+
+// multipartError maps the error S3 returns for unknown, completed or
+// aborted uploads to ErrUploadNotFound
+func multipartError(msg string, err error) error {
+	var noSuchUpload *types.NoSuchUpload
+	if errors.As(err, &noSuchUpload) {
+		return fmt.Errorf("%s: %w", msg, simplecontent.ErrUploadNotFound)
+	}
+	return fmt.Errorf("%s: %w", msg, err)
+}
+
+// UploadPart uploads a part of a multipart upload. S3 requires every part
+// but the last to be at least 5 MiB, and a known length, so readers that
+// cannot seek are buffered in memory.
+func (b *Backend) UploadPart(ctx context.Context, uploadID string, partNumber int, r io.Reader) error {
+	objectKey, s3UploadID, err := parseUploadID(uploadID)
+	if err != nil {
+		return err
+	}
+	if partNumber < 1 || partNumber > 10000 {
+		return fmt.Errorf("%w: part number %d is outside 1 to 10000", simplecontent.ErrInvalidPart, partNumber)
+	}
+	body, ok := r.(io.ReadSeeker)
+	if !ok {
+		data, err := io.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("failed to read part: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	_, err = b.client.UploadPart(ctx, &s3.UploadPartInput{
+		Bucket:     aws.String(b.bucket),
+		Key:        aws.String(objectKey),
+		UploadId:   aws.String(s3UploadID),
+		PartNumber: aws.Int32(int32(partNumber)),
+		Body:       body,
+	})
+	if err != nil {
+		return multipartError("failed to upload part", err)
+	}
+	return nil
+}
+
+// CompleteMultipart completes a multipart upload with the parts S3 lists
+// for it, which must be numbered 1 to N
+func (b *Backend) CompleteMultipart(ctx context.Context, uploadID string) error {
+	objectKey, s3UploadID, err := parseUploadID(uploadID)
+	if err != nil {
+		return err
+	}
+
+	paginator := s3.NewListPartsPaginator(b.client, &s3.ListPartsInput{
+		Bucket:   aws.String(b.bucket),
+		Key:      aws.String(objectKey),
+		UploadId: aws.String(s3UploadID),
+	})
+	var parts []types.CompletedPart
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return multipartError("failed to list parts", err)
+		}
+		for _, part := range page.Parts {
+			parts = append(parts, types.CompletedPart{ETag: part.ETag, PartNumber: part.PartNumber})
+		}
+	}
+	if len(parts) == 0 {
+		return fmt.Errorf("%w: no parts were uploaded", simplecontent.ErrInvalidPart)
+	}
+	for i, part := range parts {
+		if int(aws.ToInt32(part.PartNumber)) != i+1 {
+			return fmt.Errorf("%w: part %d is missing", simplecontent.ErrInvalidPart, i+1)
+		}
+	}
+
+	_, err = b.client.CompleteMultipartUpload(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(b.bucket),
+		Key:             aws.String(objectKey),
+		UploadId:        aws.String(s3UploadID),
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return multipartError("failed to complete multipart upload", err)
+	}
+	return nil
+}
+
+// AbortMultipart aborts a multipart upload, discarding its parts
+func (b *Backend) AbortMultipart(ctx context.Context, uploadID string) error {
+	objectKey, s3UploadID, err := parseUploadID(uploadID)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.client.AbortMultipartUpload(ctx, &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(b.bucket),
+		Key:      aws.String(objectKey),
+		UploadId: aws.String(s3UploadID),
+	})
+	if err != nil {
+		return multipartError("failed to abort multipart upload", err)
+	}
+	return nil
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"strings"
 	"testing"
@@ -12,6 +14,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/tendant/simple-content/pkg/simplecontent"
 )
 
 // TestS3Backend_BasicConfiguration tests the configuration and creation of S3 backend
@@ -235,6 +238,14 @@ func TestS3Backend_Integration(t *testing.T) {
 		assert.Contains(t, previewURL, bucket, "URL should contain bucket name")
 	})
 
+	t.Run("UploadIfNotExists", func(t *testing.T) {
+		err := backend.UploadWithParams(ctx, bytes.NewReader([]byte("replacement")), simplecontent.UploadParams{
+			ObjectKey:   objectKey,
+			IfNotExists: true,
+		})
+		require.ErrorIs(t, err, simplecontent.ErrObjectExists, "Conditional upload should not replace the object")
+	})
+
 	t.Run("Delete", func(t *testing.T) {
 		// Delete the object
 		err := backend.Delete(ctx, objectKey)
@@ -253,6 +264,41 @@ func TestS3Backend_Integration(t *testing.T) {
 	})
 }
 
+// TestS3Backend_UploadIfNotExists checks conditional uploads against a stub
+// server that holds every key
+func TestS3Backend_UploadIfNotExists(t *testing.T) {
+	var conditions []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		conditions = append(conditions, r.Header.Get("If-None-Match"))
+		if r.Header.Get("If-None-Match") == "*" {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			fmt.Fprint(w, `<?xml version="1.0" encoding="UTF-8"?><Error><Code>PreconditionFailed</Code><Message>At least one of the pre-conditions you specified did not hold</Message></Error>`)
+			return
+		}
+		w.Header().Set("ETag", `"etag"`)
+	}))
+	defer srv.Close()
+
+	backend, err := New(Config{
+		Bucket:          "bucket",
+		Region:          "us-east-1",
+		AccessKeyID:     "key",
+		SecretAccessKey: "secret",
+		Endpoint:        srv.URL,
+		UsePathStyle:    true,
+	})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	err = backend.UploadWithParams(ctx, strings.NewReader("data"), simplecontent.UploadParams{ObjectKey: "a.txt", IfNotExists: true})
+	require.ErrorIs(t, err, simplecontent.ErrObjectExists)
+
+	err = backend.UploadWithParams(ctx, strings.NewReader("data"), simplecontent.UploadParams{ObjectKey: "a.txt"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"*", ""}, conditions)
+}
+
 // TestS3Backend_ErrorHandling tests error scenarios
 func TestS3Backend_ErrorHandling(t *testing.T) {
 	// Create a backend with invalid credentials to test error handling
@@ -0,0 +1,35 @@
+package simplecontent
+
+import (
+	"context"
+	"fmt"
+	"io"
+)
+
+// FuncUploader is implemented by stores that can consume an upload more than
+// once, such as retrying decorators, calling open for a fresh reader on each
+// attempt
+type FuncUploader interface {
+	UploadFunc(ctx context.Context, objectKey string, open func() (io.ReadCloser, error)) error
+}
+
+// UploadFunc uploads the content returned by open to objectKey. Stores
+// implementing FuncUploader are handed open directly, so they can retry
+// without buffering the content; other stores get a single attempt with one
+// reader. Either way the readers are closed.
+//
+// open may be called any number of times and must return equivalent bytes
+// each time, e.g. by reopening a file or rewinding a spooled request body.
+// An error from open is reported as ErrSourceRead.
+func UploadFunc(ctx context.Context, store BlobStore, objectKey string, open func() (io.ReadCloser, error)) error {
+	if u, ok := store.(FuncUploader); ok {
+		return u.UploadFunc(ctx, objectKey, open)
+	}
+
+	rc, err := open()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSourceRead, err)
+	}
+	defer rc.Close()
+	return store.Upload(ctx, objectKey, rc)
+}
@@ -0,0 +1,70 @@
+package simplecontent_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/tendant/simple-content/pkg/simplecontent"
+	"github.com/tendant/simple-content/pkg/simplecontent/storage/memory"
+)
+
+// flakyStore fails the first upload attempt after consuming its reader
+type flakyStore struct {
+	simplecontent.BlobStore
+	attempts int
+}
+
+func (s *flakyStore) UploadFunc(ctx context.Context, objectKey string, open func() (io.ReadCloser, error)) error {
+	for {
+		s.attempts++
+		rc, err := open()
+		if err != nil {
+			return err
+		}
+		if s.attempts == 1 {
+			io.Copy(io.Discard, rc)
+			rc.Close()
+			continue
+		}
+		defer rc.Close()
+		return s.Upload(ctx, objectKey, rc)
+	}
+}
+
+func TestUploadFunc(t *testing.T) {
+	ctx := context.Background()
+	opens := 0
+	open := func() (io.ReadCloser, error) {
+		opens++
+		return io.NopCloser(strings.NewReader("payload")), nil
+	}
+
+	store := memory.New()
+	if err := simplecontent.UploadFunc(ctx, store, "a.txt", open); err != nil {
+		t.Fatalf("upload: %v", err)
+	}
+	if opens != 1 {
+		t.Fatalf("expected a single attempt, got %d opens", opens)
+	}
+
+	flaky := &flakyStore{BlobStore: store}
+	if err := simplecontent.UploadFunc(ctx, flaky, "b.txt", open); err != nil {
+		t.Fatalf("retried upload: %v", err)
+	}
+	rc, err := store.Download(ctx, "b.txt")
+	if err != nil {
+		t.Fatalf("download: %v", err)
+	}
+	defer rc.Close()
+	if data, _ := io.ReadAll(rc); string(data) != "payload" || flaky.attempts != 2 {
+		t.Fatalf("expected retried content after 2 attempts, got %q after %d", data, flaky.attempts)
+	}
+
+	failing := func() (io.ReadCloser, error) { return nil, errors.New("spool gone") }
+	if err := simplecontent.UploadFunc(ctx, store, "c.txt", failing); !errors.Is(err, simplecontent.ErrSourceRead) {
+		t.Fatalf("expected ErrSourceRead, got %v", err)
+	}
+}